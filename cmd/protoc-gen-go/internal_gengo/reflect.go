@@ -191,6 +191,9 @@ func genReflectFileDescriptor(gen *protogen.Plugin, g *protogen.GeneratedFile, f
 				// Associate the wrapper types by directly passing them to the MessageInfo.
 				g.P(typesVar, "[", idx, "].OneofWrappers = []interface{} {")
 				for _, oneof := range message.Oneofs {
+					if oneof.Desc.IsSynthetic() {
+						continue
+					}
 					for _, field := range oneof.Fields {
 						g.P("(*", field.GoIdent, ")(nil),")
 					}
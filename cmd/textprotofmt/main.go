@@ -0,0 +1,260 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The textprotofmt binary formats files in the protocol buffer text format.
+//
+// Without an explicit path, it processes the standard input. Given a file,
+// it operates on that file; given a directory, it operates on all files in
+// the directory and its subdirectories with a .textproto, .txtpb, .pbtxt or
+// .prototxt extension. By default, it prints the formatted files to the
+// standard output.
+//
+// Usage:
+//
+//	textprotofmt [flags] [path ...]
+//
+// The flags are:
+//
+//	-l
+//		Do not print formatted files to the standard output. Instead, print
+//		the names of files whose formatting differs from textprotofmt's.
+//	-w
+//		Do not print formatted files to the standard output. Instead,
+//		overwrite files whose formatting differs from textprotofmt's.
+//	-indent string
+//		Indentation of each level of nesting. Defaults to two spaces.
+//	-sort
+//		Sort the fields of each message by field number. Requires -message.
+//	-descriptor_set file
+//		Read message types from a binary google.protobuf.FileDescriptorSet,
+//		such as one produced by protoc's --descriptor_set_out flag with
+//		--include_imports.
+//	-message name
+//		Full name of the message type of the formatted files. If set, files
+//		must be valid text format representations of the message type, and
+//		fields identified by number are named, enum values written as numbers
+//		are replaced by names, and extension names are normalized.
+//
+// Formatting preserves comments, and the order of fields unless -sort is set.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/textedit"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	list          = flag.Bool("l", false, "list files whose formatting differs from textprotofmt's")
+	write         = flag.Bool("w", false, "write result to (source) file instead of stdout")
+	indent        = flag.String("indent", "", "indentation of each level of nesting")
+	sortFields    = flag.Bool("sort", false, "sort fields by field number")
+	descriptorSet = flag.String("descriptor_set", "", "binary FileDescriptorSet with the message types")
+	messageName   = flag.String("message", "", "full name of the message type of the files")
+)
+
+var exitCode = 0
+
+func report(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	exitCode = 2
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: textprotofmt [flags] [path ...]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	opts, err := parseOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "textprotofmt: %v\n", err)
+		os.Exit(2)
+	}
+	reformat := textedit.ReformatOptions{Indent: *indent, SortFields: *sortFields}
+
+	if flag.NArg() == 0 {
+		if err := processFile(os.Stdout, "<standard input>", os.Stdin, opts, reformat); err != nil {
+			report(err)
+		}
+		os.Exit(exitCode)
+	}
+
+	for _, path := range flag.Args() {
+		switch fi, err := os.Stat(path); {
+		case err != nil:
+			report(err)
+		case fi.IsDir():
+			filepath.Walk(path, func(path string, fi os.FileInfo, err error) error {
+				if err == nil && !fi.IsDir() && isTextprotoFile(fi.Name()) {
+					err = processFile(os.Stdout, path, nil, opts, reformat)
+				}
+				if err != nil {
+					report(err)
+				}
+				return nil
+			})
+		default:
+			if err := processFile(os.Stdout, path, nil, opts, reformat); err != nil {
+				report(err)
+			}
+		}
+	}
+	os.Exit(exitCode)
+}
+
+func isTextprotoFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".textproto", ".txtpb", ".pbtxt", ".prototxt":
+		return !strings.HasPrefix(name, ".")
+	}
+	return false
+}
+
+// parseOptions returns the options for parsing files from the flags.
+func parseOptions() (textedit.ParseOptions, error) {
+	var opts textedit.ParseOptions
+	if *write && flag.NArg() == 0 {
+		return opts, fmt.Errorf("cannot use -w with standard input")
+	}
+	if *messageName == "" {
+		if *sortFields {
+			return opts, fmt.Errorf("-sort requires -message")
+		}
+		if *descriptorSet != "" {
+			return opts, fmt.Errorf("-descriptor_set requires -message")
+		}
+		return opts, nil
+	}
+
+	files := protoregistry.GlobalFiles
+	types := protoregistry.GlobalTypes
+	if *descriptorSet != "" {
+		var err error
+		files, types, err = loadDescriptorSet(*descriptorSet)
+		if err != nil {
+			return opts, err
+		}
+	}
+	d, err := files.FindDescriptorByName(pref.FullName(*messageName))
+	if err != nil {
+		return opts, fmt.Errorf("cannot find message %s: %v", *messageName, err)
+	}
+	md, ok := d.(pref.MessageDescriptor)
+	if !ok {
+		return opts, fmt.Errorf("%s is not a message", *messageName)
+	}
+	opts.Schema = md
+	opts.Resolver = types
+	return opts, nil
+}
+
+// loadDescriptorSet reads a FileDescriptorSet and returns its files and
+// the types declared therein.
+func loadDescriptorSet(path string) (*protoregistry.Files, *protoregistry.Types, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	fds := new(descriptorpb.FileDescriptorSet)
+	if err := proto.Unmarshal(b, fds); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	types := new(protoregistry.Types)
+	files.RangeFiles(func(fd pref.FileDescriptor) bool {
+		err = registerTypes(types, fd.Messages(), fd.Extensions())
+		return err == nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return files, types, nil
+}
+
+// registerTypes registers dynamic types for the given messages and
+// extensions, and those nested in the messages.
+func registerTypes(types *protoregistry.Types, mds pref.MessageDescriptors, xds pref.ExtensionDescriptors) error {
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		if err := types.RegisterMessage(dynamicpb.NewMessageType(md)); err != nil {
+			return err
+		}
+		if err := registerTypes(types, md.Messages(), md.Extensions()); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < xds.Len(); i++ {
+		if err := types.RegisterExtension(dynamicpb.NewExtensionType(xds.Get(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processFile formats the file at path, which is read from in if non-nil.
+// Formatted files and the names of files listed by -l are written to out.
+func processFile(out io.Writer, path string, in io.Reader, opts textedit.ParseOptions, reformat textedit.ReformatOptions) error {
+	var src []byte
+	var err error
+	if in != nil {
+		src, err = ioutil.ReadAll(in)
+	} else {
+		src, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	doc, err := opts.Parse(src)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if err := doc.Reformat(reformat); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	res := doc.Format()
+
+	if !*list && !*write {
+		_, err := out.Write(res)
+		return err
+	}
+	if bytes.Equal(src, res) {
+		return nil
+	}
+	if *list {
+		fmt.Fprintln(out, path)
+	}
+	if *write {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, res, fi.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/textedit"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// testFile is a file declaring the message type fmttest.M, which is not
+// linked into the test binary.
+const testFile = `
+	name:    "fmttest.proto"
+	package: "fmttest"
+	message_type: [{
+		name: "M"
+		field: [
+			{name:"a"     number:1 label:LABEL_OPTIONAL type:TYPE_INT32},
+			{name:"b"     number:2 label:LABEL_OPTIONAL type:TYPE_STRING},
+			{name:"child" number:3 label:LABEL_OPTIONAL type:TYPE_MESSAGE type_name:".fmttest.M"}
+		]
+		nested_type: [{name: "Nested"}]
+		extension_range: [{start:100 end:536870912}]
+	}]
+	extension: [{name:"ext" number:100 label:LABEL_OPTIONAL type:TYPE_INT32 extendee:".fmttest.M"}]
+`
+
+// setup writes a descriptor set declaring testFile and the given files to a
+// new temporary directory, whose path it returns.
+func setup(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "textprotofmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := new(descriptorpb.FileDescriptorProto)
+	if err := prototext.Unmarshal([]byte(testFile), fd); err != nil {
+		t.Fatal(err)
+	}
+	b, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fd}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["fmttest.pb"] = string(b)
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// setFlags resets the flags of textprotofmt to their defaults and then
+// parses args.
+func setFlags(t *testing.T, args ...string) {
+	t.Helper()
+	flag.VisitAll(func(f *flag.Flag) {
+		if !strings.HasPrefix(f.Name, "test.") {
+			f.Value.Set(f.DefValue)
+		}
+	})
+	if err := flag.CommandLine.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// format runs processFile on each of the given files in dir, as main does
+// with the flags and the non-flag arguments set by setFlags, and returns its
+// output.
+func format(t *testing.T, dir string, names ...string) string {
+	t.Helper()
+	opts, err := parseOptions()
+	if err != nil {
+		t.Fatalf("parseOptions() error: %v", err)
+	}
+	reformat := textedit.ReformatOptions{Indent: *indent, SortFields: *sortFields}
+	var out bytes.Buffer
+	for _, name := range names {
+		if err := processFile(&out, filepath.Join(dir, name), nil, opts, reformat); err != nil {
+			t.Fatalf("processFile(%q) error: %v", name, err)
+		}
+	}
+	return out.String()
+}
+
+const (
+	unformatted = "b:\"x\" a:1 # c\nchild{b:\"y\" a:2}\n"
+	formatted   = "b: \"x\"\na: 1 # c\nchild {\n  b: \"y\"\n  a: 2\n}\n"
+)
+
+func TestProcessFile(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"unformatted.textproto": unformatted,
+		"formatted.textproto":   formatted,
+	})
+	defer os.RemoveAll(dir)
+
+	t.Run("stdout", func(t *testing.T) {
+		setFlags(t, "unformatted.textproto")
+		if got := format(t, dir, "unformatted.textproto", "formatted.textproto"); got != formatted+formatted {
+			t.Errorf("output mismatch\n<got>\n%s\n<want>\n%s", got, formatted+formatted)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		setFlags(t, "-l", "unformatted.textproto")
+		want := filepath.Join(dir, "unformatted.textproto") + "\n"
+		if got := format(t, dir, "unformatted.textproto", "formatted.textproto"); got != want {
+			t.Errorf("-l output got %q, want %q", got, want)
+		}
+		if b, _ := ioutil.ReadFile(filepath.Join(dir, "unformatted.textproto")); string(b) != unformatted {
+			t.Errorf("-l modified the file:\n%s", b)
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		setFlags(t, "-w", "unformatted.textproto")
+		if got := format(t, dir, "unformatted.textproto", "formatted.textproto"); got != "" {
+			t.Errorf("-w output got %q, want none", got)
+		}
+		for _, name := range []string{"unformatted.textproto", "formatted.textproto"} {
+			if b, _ := ioutil.ReadFile(filepath.Join(dir, name)); string(b) != formatted {
+				t.Errorf("-w result for %s mismatch\n<got>\n%s\n<want>\n%s", name, b, formatted)
+			}
+		}
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		setFlags(t, "-sort", "-message=fmttest.M", "-descriptor_set="+filepath.Join(dir, "fmttest.pb"), "formatted.textproto")
+		want := "a: 1 # c\nb: \"x\"\nchild {\n  a: 2\n  b: \"y\"\n}\n"
+		if got := format(t, dir, "formatted.textproto"); got != want {
+			t.Errorf("-sort output mismatch\n<got>\n%s\n<want>\n%s", got, want)
+		}
+	})
+
+	t.Run("message", func(t *testing.T) {
+		setFlags(t, "-indent=\t", "-message=fmttest.M", "-descriptor_set="+filepath.Join(dir, "fmttest.pb"), "numbers.textproto")
+		if err := ioutil.WriteFile(filepath.Join(dir, "numbers.textproto"), []byte("3 {1: 2}\n[fmttest.ext]: 4\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want := "child {\n\ta: 2\n}\n[fmttest.ext]: 4\n"
+		if got := format(t, dir, "numbers.textproto"); got != want {
+			t.Errorf("-message output mismatch\n<got>\n%s\n<want>\n%s", got, want)
+		}
+	})
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	dir := setup(t, map[string]string{"invalid.pb": "\xff"})
+	defer os.RemoveAll(dir)
+	descriptorSet := "-descriptor_set=" + filepath.Join(dir, "fmttest.pb")
+
+	tests := []struct {
+		args    []string
+		wantErr string // Expected error substring.
+	}{{
+		args:    []string{"-w"},
+		wantErr: "cannot use -w with standard input",
+	}, {
+		args:    []string{"-sort", "a.textproto"},
+		wantErr: "-sort requires -message",
+	}, {
+		args:    []string{descriptorSet, "a.textproto"},
+		wantErr: "-descriptor_set requires -message",
+	}, {
+		args:    []string{"-message=fmttest.M"},
+		wantErr: "cannot find message fmttest.M",
+	}, {
+		args:    []string{"-message=fmttest.Missing", descriptorSet},
+		wantErr: "cannot find message fmttest.Missing",
+	}, {
+		args:    []string{"-message=fmttest.ext", descriptorSet},
+		wantErr: "fmttest.ext is not a message",
+	}, {
+		args:    []string{"-message=fmttest.M", "-descriptor_set=" + filepath.Join(dir, "missing.pb")},
+		wantErr: "missing.pb",
+	}, {
+		args:    []string{"-message=fmttest.M", "-descriptor_set=" + filepath.Join(dir, "invalid.pb")},
+		wantErr: "invalid.pb",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			setFlags(t, tt.args...)
+			_, err := parseOptions()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseOptions() error got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDescriptorSet(t *testing.T) {
+	dir := setup(t, map[string]string{})
+	defer os.RemoveAll(dir)
+
+	files, types, err := loadDescriptorSet(filepath.Join(dir, "fmttest.pb"))
+	if err != nil {
+		t.Fatalf("loadDescriptorSet() error: %v", err)
+	}
+	if _, err := files.FindFileByPath("fmttest.proto"); err != nil {
+		t.Errorf("FindFileByPath() error: %v", err)
+	}
+	for _, name := range []string{"fmttest.M", "fmttest.M.Nested"} {
+		if _, err := types.FindMessageByName(pref.FullName(name)); err != nil {
+			t.Errorf("FindMessageByName(%q) error: %v", name, err)
+		}
+	}
+	if _, err := types.FindExtensionByName("fmttest.ext"); err != nil {
+		t.Errorf("FindExtensionByName(%q) error: %v", "fmttest.ext", err)
+	}
+}
+
+func TestIsTextprotoFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"a.textproto": true,
+		"a.txtpb":     true,
+		"a.pbtxt":     true,
+		"a.prototxt":  true,
+		"a.txt":       false,
+		"a.proto":     false,
+		".textproto":  false,
+		".a.pbtxt":    false,
+	} {
+		if got := isTextprotoFile(name); got != want {
+			t.Errorf("isTextprotoFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,707 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package jsonschema generates JSON Schema documents (draft 2020-12) that
+// describe the JSON representation of protocol buffer messages as implemented
+// by the protojson package.
+//
+// A generated schema accepts every JSON document that protojson.Unmarshal
+// accepts for the message type (modulo constraints that JSON Schema cannot
+// express, such as the exact range of 64-bit integers encoded as strings),
+// which includes every document that protojson.Marshal emits.
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/internal/encoding/json"
+	"google.golang.org/protobuf/internal/encoding/messageset"
+	"google.golang.org/protobuf/internal/fieldnum"
+	"google.golang.org/protobuf/internal/pragma"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Draft is the JSON Schema meta-schema URI of generated documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+const defaultIndent = "  "
+
+// Patterns used for JSON strings that protojson parses as numbers.
+const (
+	numberPattern = `^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`
+	floatPattern  = `^(NaN|Infinity|-Infinity|-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?)$`
+	bytesPattern  = `^[-_A-Za-z0-9+/]*=*$`
+
+	intKeyPattern  = `^[-+]?[0-9]+$`
+	uintKeyPattern = `^\+?[0-9]+$`
+	boolKeyPattern = `^(true|false)$`
+
+	durationPattern = `^[-+]?((0|[1-9][0-9]*)(\.[0-9]{0,9})?|\.[0-9]{1,9})s$`
+)
+
+// Generate returns the JSON Schema for the given message type using default
+// options.
+func Generate(md pref.MessageDescriptor) ([]byte, error) {
+	return Options{}.Generate(md)
+}
+
+// Options is a configurable JSON Schema generator.
+type Options struct {
+	pragma.NoUnkeyedLiterals
+
+	// Multiline specifies whether the generator should format the output in
+	// indented-form with every textual element on a new line.
+	// If Indent is an empty string, then an arbitrary indent is chosen.
+	Multiline bool
+
+	// Indent specifies the set of indentation characters to use in a multiline
+	// formatted output such that every entry is preceded by Indent and
+	// terminated by a newline. If non-empty, then Multiline is treated as true.
+	// Indent can only be composed of space or tab characters.
+	Indent string
+
+	// UseProtoNames specifies that the proto field name is the primary
+	// property name, matching protojson.MarshalOptions.UseProtoNames.
+	// Regardless of this option, both the proto field name and the JSON field
+	// name are declared as properties since protojson accepts either.
+	UseProtoNames bool
+
+	// OneofEmptyAsString specifies that a message consisting of a single
+	// oneof may also be a string naming its populated field if that field is
+	// of a message type without fields, such as google.protobuf.Empty,
+	// matching protojson.UnmarshalOptions.OneofEmptyAsString.
+	OneofEmptyAsString bool
+}
+
+// Generate returns a JSON Schema document for the given message type. The
+// document references a definition of the message in its "$defs" section
+// together with definitions for all transitively referenced message and enum
+// types, each keyed by its full name.
+func (o Options) Generate(md pref.MessageDescriptor) ([]byte, error) {
+	if o.Multiline && o.Indent == "" {
+		o.Indent = defaultIndent
+	}
+	enc, err := json.NewEncoder(o.Indent)
+	if err != nil {
+		return nil, err
+	}
+
+	g := generator{Encoder: enc, opts: o, seen: map[pref.FullName]bool{}}
+	g.collectMessage(md)
+
+	g.StartObject()
+	g.WriteName("$schema")
+	g.WriteString(Draft)
+	g.WriteName("$ref")
+	g.WriteString(ref(md.FullName()))
+	g.WriteName("$defs")
+	g.StartObject()
+	for _, d := range g.defs {
+		if err := g.WriteName(string(d.FullName())); err != nil {
+			return nil, err
+		}
+		switch d := d.(type) {
+		case pref.MessageDescriptor:
+			g.writeMessage(d)
+		case pref.EnumDescriptor:
+			g.writeEnum(d)
+		}
+	}
+	g.EndObject()
+	g.EndObject()
+	return g.Bytes(), nil
+}
+
+type generator struct {
+	*json.Encoder
+	opts Options
+
+	// defs contains the message and enum types in the order they are written
+	// out to the "$defs" section.
+	defs []pref.Descriptor
+	seen map[pref.FullName]bool
+}
+
+// ref returns the JSON pointer to the definition of the named type.
+func ref(name pref.FullName) string {
+	return "#/$defs/" + string(name)
+}
+
+// collectMessage records md and every message and enum type it depends on.
+func (g *generator) collectMessage(md pref.MessageDescriptor) {
+	if g.seen[md.FullName()] {
+		return
+	}
+	g.seen[md.FullName()] = true
+	g.defs = append(g.defs, md)
+	if isCustomType(md.FullName()) && md.FullName() != "google.protobuf.Struct" &&
+		md.FullName() != "google.protobuf.ListValue" {
+		return
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+		g.collectField(fd)
+	}
+}
+
+func (g *generator) collectField(fd pref.FieldDescriptor) {
+	switch fd.Kind() {
+	case pref.MessageKind, pref.GroupKind:
+		g.collectMessage(fd.Message())
+	case pref.EnumKind:
+		ed := fd.Enum()
+		if g.seen[ed.FullName()] || ed.FullName() == "google.protobuf.NullValue" {
+			return
+		}
+		g.seen[ed.FullName()] = true
+		g.defs = append(g.defs, ed)
+	}
+}
+
+// writeMessage writes the definition of a message type.
+func (g *generator) writeMessage(md pref.MessageDescriptor) {
+	if isCustomType(md.FullName()) {
+		g.writeCustomType(md)
+		return
+	}
+
+	g.StartObject()
+	defer g.EndObject()
+
+	g.writeTitle(md)
+	if messageset.IsMessageSet(md) {
+		// MessageSets only contain extensions.
+		g.WriteName("type")
+		g.WriteString("object")
+		g.writeExtensionProperties()
+		return
+	}
+
+	// Messages consisting of a single oneof may be encoded as a bare string
+	// naming the populated member if it is an empty message.
+	if names := g.emptyVariantNames(md); len(names) > 0 {
+		g.WriteName("anyOf")
+		g.StartArray()
+		g.StartObject()
+		g.writeObjectBody(md)
+		g.EndObject()
+		g.StartObject()
+		g.WriteName("type")
+		g.WriteString("string")
+		g.WriteName("enum")
+		g.StartArray()
+		for _, s := range names {
+			g.WriteString(s)
+		}
+		g.EndArray()
+		g.EndObject()
+		g.EndArray()
+		return
+	}
+	g.writeObjectBody(md)
+}
+
+// writeObjectBody writes the object keywords describing the fields of md.
+func (g *generator) writeObjectBody(md pref.MessageDescriptor) {
+	g.WriteName("type")
+	g.WriteString("object")
+
+	fields := md.Fields()
+	g.WriteName("properties")
+	g.StartObject()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		for _, name := range g.fieldNames(fd) {
+			g.WriteName(name)
+			g.writeField(fd)
+		}
+	}
+	g.EndObject()
+
+	if md.ExtensionRanges().Len() > 0 {
+		g.writeExtensionProperties()
+	}
+	g.WriteName("additionalProperties")
+	g.WriteBool(false)
+
+	// Each field may only be specified once using one of its names,
+	// and at most one member of each oneof may be specified.
+	var exclusive [][]string
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.ContainingOneof() != nil {
+			continue // covered by the oneof below
+		}
+		if names := g.fieldNames(fd); len(names) > 1 {
+			exclusive = append(exclusive, names)
+		}
+	}
+	oneofs := md.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		var names []string
+		ofields := oneofs.Get(i).Fields()
+		for j := 0; j < ofields.Len(); j++ {
+			names = append(names, g.fieldNames(ofields.Get(j))...)
+		}
+		if len(names) > 1 {
+			exclusive = append(exclusive, names)
+		}
+	}
+
+	var required [][]string
+	for i := 0; i < fields.Len(); i++ {
+		if fd := fields.Get(i); fd.Cardinality() == pref.Required {
+			required = append(required, g.fieldNames(fd))
+		}
+	}
+
+	if len(exclusive) == 0 && len(required) == 0 {
+		return
+	}
+	g.WriteName("allOf")
+	g.StartArray()
+	for _, names := range required {
+		g.writeRequired(names)
+	}
+	for _, names := range exclusive {
+		g.writeExclusive(names)
+	}
+	g.EndArray()
+}
+
+// writeRequired writes a schema requiring any one of the given names.
+func (g *generator) writeRequired(names []string) {
+	g.StartObject()
+	defer g.EndObject()
+	if len(names) == 1 {
+		g.WriteName("required")
+		g.writeStrings(names)
+		return
+	}
+	g.WriteName("anyOf")
+	g.StartArray()
+	for _, name := range names {
+		g.StartObject()
+		g.WriteName("required")
+		g.writeStrings([]string{name})
+		g.EndObject()
+	}
+	g.EndArray()
+}
+
+// writeExclusive writes a schema forbidding any two of the given names from
+// being present at the same time.
+func (g *generator) writeExclusive(names []string) {
+	g.StartObject()
+	defer g.EndObject()
+	g.WriteName("not")
+	g.StartObject()
+	defer g.EndObject()
+	g.WriteName("anyOf")
+	g.StartArray()
+	defer g.EndArray()
+	for i := 0; i < len(names); i++ {
+		for j := i + 1; j < len(names); j++ {
+			g.StartObject()
+			g.WriteName("required")
+			g.writeStrings([]string{names[i], names[j]})
+			g.EndObject()
+		}
+	}
+}
+
+func (g *generator) writeStrings(ss []string) {
+	g.StartArray()
+	for _, s := range ss {
+		g.WriteString(s)
+	}
+	g.EndArray()
+}
+
+// writeExtensionProperties writes the schema for extension fields, which are
+// keyed by their full name enclosed in brackets.
+func (g *generator) writeExtensionProperties() {
+	g.WriteName("patternProperties")
+	g.StartObject()
+	g.WriteName(`^\[[^\]]+\]$`)
+	g.StartObject()
+	g.EndObject()
+	g.EndObject()
+}
+
+// writeTitle writes the "title" keyword naming the type.
+func (g *generator) writeTitle(d pref.Descriptor) {
+	g.WriteName("title")
+	g.WriteString(string(d.FullName()))
+}
+
+// fieldNames returns the property names that protojson accepts for the field,
+// starting with the name that protojson emits.
+func (g *generator) fieldNames(fd pref.FieldDescriptor) []string {
+	jsonName := fd.JSONName()
+	protoName := string(fd.Name())
+	if fd.Kind() == pref.GroupKind {
+		// Use type name for group field name.
+		protoName = string(fd.Message().Name())
+	}
+	if jsonName == protoName {
+		return []string{jsonName}
+	}
+	if g.opts.UseProtoNames {
+		return []string{protoName, jsonName}
+	}
+	return []string{jsonName, protoName}
+}
+
+// writeField writes the schema of a field value. The JSON null value is
+// accepted for every field and is treated as if the field is not set.
+func (g *generator) writeField(fd pref.FieldDescriptor) {
+	switch {
+	case fd.IsList():
+		g.StartObject()
+		g.WriteName("type")
+		g.writeStrings([]string{"array", "null"})
+		g.WriteName("items")
+		g.writeSingular(fd, false)
+		g.EndObject()
+	case fd.IsMap():
+		g.StartObject()
+		g.WriteName("type")
+		g.writeStrings([]string{"object", "null"})
+		if pattern := mapKeyPattern(fd.MapKey()); pattern != "" {
+			g.WriteName("propertyNames")
+			g.StartObject()
+			g.WriteName("pattern")
+			g.WriteString(pattern)
+			g.EndObject()
+		}
+		g.WriteName("additionalProperties")
+		g.writeSingular(fd.MapValue(), false)
+		g.EndObject()
+	default:
+		g.writeSingular(fd, true)
+	}
+}
+
+// mapKeyPattern returns the pattern for map keys of the given key field.
+func mapKeyPattern(fd pref.FieldDescriptor) string {
+	switch fd.Kind() {
+	case pref.BoolKind:
+		return boolKeyPattern
+	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind,
+		pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
+		return intKeyPattern
+	case pref.Uint32Kind, pref.Fixed32Kind, pref.Uint64Kind, pref.Fixed64Kind:
+		return uintKeyPattern
+	}
+	return ""
+}
+
+// writeSingular writes the schema of a singular value of the given field.
+func (g *generator) writeSingular(fd pref.FieldDescriptor, nullable bool) {
+	switch fd.Kind() {
+	case pref.MessageKind, pref.GroupKind:
+		g.writeRef(fd.Message().FullName(), nullable)
+	case pref.EnumKind:
+		if fd.Enum().FullName() == "google.protobuf.NullValue" {
+			g.writeType("null")
+			return
+		}
+		g.writeRef(fd.Enum().FullName(), nullable)
+	default:
+		g.writeScalar(fd.Kind(), nullable)
+	}
+}
+
+// writeRef writes a reference to the definition of the named type.
+func (g *generator) writeRef(name pref.FullName, nullable bool) {
+	g.StartObject()
+	defer g.EndObject()
+	if !nullable {
+		g.WriteName("$ref")
+		g.WriteString(ref(name))
+		return
+	}
+	g.WriteName("anyOf")
+	g.StartArray()
+	g.StartObject()
+	g.WriteName("$ref")
+	g.WriteString(ref(name))
+	g.EndObject()
+	g.StartObject()
+	g.WriteName("type")
+	g.WriteString("null")
+	g.EndObject()
+	g.EndArray()
+}
+
+// writeType writes a schema consisting of only the "type" keyword.
+func (g *generator) writeType(typ string) {
+	g.StartObject()
+	g.WriteName("type")
+	g.WriteString(typ)
+	g.EndObject()
+}
+
+// writeScalar writes the schema of a scalar kind.
+func (g *generator) writeScalar(kind pref.Kind, nullable bool) {
+	g.StartObject()
+	defer g.EndObject()
+
+	types := func(ts ...string) {
+		if nullable {
+			ts = append(ts, "null")
+		}
+		g.WriteName("type")
+		if len(ts) == 1 {
+			g.WriteString(ts[0])
+			return
+		}
+		g.writeStrings(ts)
+	}
+	bounds := func(min, max interface{}) {
+		g.WriteName("minimum")
+		g.writeNumber(min)
+		g.WriteName("maximum")
+		g.writeNumber(max)
+	}
+
+	switch kind {
+	case pref.BoolKind:
+		types("boolean")
+	case pref.StringKind:
+		types("string")
+	case pref.BytesKind:
+		types("string")
+		g.WriteName("contentEncoding")
+		g.WriteString("base64")
+		g.WriteName("pattern")
+		g.WriteString(bytesPattern)
+
+	// Integers are emitted as JSON numbers (or as JSON strings for 64-bit
+	// kinds), but protojson accepts both forms for every integer kind.
+	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind:
+		types("integer", "string")
+		bounds(int64(math.MinInt32), int64(math.MaxInt32))
+		g.WriteName("pattern")
+		g.WriteString(numberPattern)
+	case pref.Uint32Kind, pref.Fixed32Kind:
+		types("integer", "string")
+		bounds(int64(0), int64(math.MaxUint32))
+		g.WriteName("pattern")
+		g.WriteString(numberPattern)
+	case pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
+		types("string", "integer")
+		bounds(int64(math.MinInt64), int64(math.MaxInt64))
+		g.WriteName("pattern")
+		g.WriteString(numberPattern)
+	case pref.Uint64Kind, pref.Fixed64Kind:
+		types("string", "integer")
+		bounds(uint64(0), uint64(math.MaxUint64))
+		g.WriteName("pattern")
+		g.WriteString(numberPattern)
+
+	// Special floating-point values are represented as JSON strings.
+	case pref.FloatKind:
+		types("number", "string")
+		bounds(float64(-math.MaxFloat32), float64(math.MaxFloat32))
+		g.WriteName("pattern")
+		g.WriteString(floatPattern)
+	case pref.DoubleKind:
+		types("number", "string")
+		g.WriteName("pattern")
+		g.WriteString(floatPattern)
+
+	default:
+		panic(fmt.Sprintf("invalid scalar kind %v", kind))
+	}
+}
+
+func (g *generator) writeNumber(v interface{}) {
+	switch v := v.(type) {
+	case int64:
+		g.WriteInt(v)
+	case uint64:
+		g.WriteUint(v)
+	case float64:
+		g.WriteFloat(v, 64)
+	}
+}
+
+// writeEnum writes the definition of an enum type. Enum values are emitted
+// as their names but protojson also accepts any 32-bit enum number.
+func (g *generator) writeEnum(ed pref.EnumDescriptor) {
+	g.StartObject()
+	defer g.EndObject()
+
+	g.writeTitle(ed)
+	g.WriteName("anyOf")
+	g.StartArray()
+	g.StartObject()
+	g.WriteName("type")
+	g.WriteString("string")
+	g.WriteName("enum")
+	g.StartArray()
+	values := ed.Values()
+	for i := 0; i < values.Len(); i++ {
+		g.WriteString(string(values.Get(i).Name()))
+	}
+	g.EndArray()
+	g.EndObject()
+	g.StartObject()
+	g.WriteName("type")
+	g.WriteString("integer")
+	g.WriteName("minimum")
+	g.WriteInt(math.MinInt32)
+	g.WriteName("maximum")
+	g.WriteInt(math.MaxInt32)
+	g.EndObject()
+	g.EndArray()
+}
+
+// emptyVariantNames returns the names under which the fields of a message
+// consisting of a single oneof can be specified as a JSON string if
+// OneofEmptyAsString is set. These are the fields of message types without
+// fields. It returns nil if md has a different shape.
+func (g *generator) emptyVariantNames(md pref.MessageDescriptor) []string {
+	fields := md.Fields()
+	if !g.opts.OneofEmptyAsString || md.Oneofs().Len() != 1 || md.Oneofs().Get(0).IsSynthetic() || fields.Len() == 0 {
+		return nil
+	}
+	var names []string
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.ContainingOneof() == nil {
+			return nil
+		}
+		if fd.Message() == nil || fd.Message().Fields().Len() != 0 {
+			continue
+		}
+		names = append(names, string(fd.Name()))
+		if fd.JSONName() != string(fd.Name()) {
+			names = append(names, fd.JSONName())
+		}
+	}
+	return names
+}
+
+// isCustomType returns true if type name has special JSON conversion rules.
+// This list must match the one in the protojson package.
+func isCustomType(name pref.FullName) bool {
+	switch name {
+	case "google.protobuf.Any",
+		"google.protobuf.BoolValue",
+		"google.protobuf.DoubleValue",
+		"google.protobuf.FloatValue",
+		"google.protobuf.Int32Value",
+		"google.protobuf.Int64Value",
+		"google.protobuf.UInt32Value",
+		"google.protobuf.UInt64Value",
+		"google.protobuf.StringValue",
+		"google.protobuf.BytesValue",
+		"google.protobuf.Empty",
+		"google.protobuf.Struct",
+		"google.protobuf.ListValue",
+		"google.protobuf.Value",
+		"google.protobuf.Duration",
+		"google.protobuf.Timestamp",
+		"google.protobuf.FieldMask":
+		return true
+	}
+	return false
+}
+
+// writeCustomType writes the definition of a well-known type that has special
+// JSON conversion rules.
+func (g *generator) writeCustomType(md pref.MessageDescriptor) {
+	g.StartObject()
+	defer g.EndObject()
+
+	g.writeTitle(md)
+	switch name := md.FullName(); name {
+	case "google.protobuf.Any":
+		// The embedded message fields are inlined next to the "@type" field.
+		g.WriteName("type")
+		g.WriteString("object")
+		g.WriteName("properties")
+		g.StartObject()
+		g.WriteName("@type")
+		g.writeType("string")
+		g.EndObject()
+		g.WriteName("dependentRequired")
+		g.StartObject()
+		g.WriteName("value")
+		g.writeStrings([]string{"@type"})
+		g.EndObject()
+
+	case "google.protobuf.BoolValue",
+		"google.protobuf.DoubleValue",
+		"google.protobuf.FloatValue",
+		"google.protobuf.Int32Value",
+		"google.protobuf.Int64Value",
+		"google.protobuf.UInt32Value",
+		"google.protobuf.UInt64Value",
+		"google.protobuf.StringValue",
+		"google.protobuf.BytesValue":
+		// Wrappers are represented as their wrapped value.
+		fd := md.Fields().ByNumber(fieldnum.BoolValue_Value)
+		g.WriteName("allOf")
+		g.StartArray()
+		g.writeScalar(fd.Kind(), false)
+		g.EndArray()
+
+	case "google.protobuf.Empty":
+		g.WriteName("type")
+		g.WriteString("object")
+		g.WriteName("additionalProperties")
+		g.WriteBool(false)
+
+	case "google.protobuf.Struct":
+		g.WriteName("type")
+		g.WriteString("object")
+		g.WriteName("additionalProperties")
+		g.StartObject()
+		g.WriteName("$ref")
+		g.WriteString(ref("google.protobuf.Value"))
+		g.EndObject()
+
+	case "google.protobuf.ListValue":
+		g.WriteName("type")
+		g.WriteString("array")
+		g.WriteName("items")
+		g.StartObject()
+		g.WriteName("$ref")
+		g.WriteString(ref("google.protobuf.Value"))
+		g.EndObject()
+
+	case "google.protobuf.Value":
+		// Any JSON value is accepted.
+
+	case "google.protobuf.Duration":
+		g.WriteName("type")
+		g.WriteString("string")
+		g.WriteName("pattern")
+		g.WriteString(durationPattern)
+
+	case "google.protobuf.Timestamp":
+		g.WriteName("type")
+		g.WriteString("string")
+		g.WriteName("format")
+		g.WriteString("date-time")
+
+	case "google.protobuf.FieldMask":
+		// Comma-separated list of lowerCamelCase paths.
+		g.WriteName("type")
+		g.WriteString("string")
+
+	default:
+		panic(fmt.Sprintf("%s does not have a custom schema", name))
+	}
+}
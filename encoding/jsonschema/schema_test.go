@@ -0,0 +1,198 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/protobuf/encoding/jsonschema"
+	"google.golang.org/protobuf/proto"
+
+	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
+	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		desc  string
+		opts  jsonschema.Options
+		input proto.Message
+		// want maps JSON pointers into the generated document to the
+		// expected values at those locations.
+		want map[string]interface{}
+	}{{
+		desc:  "root reference",
+		input: &pb3.Nests{},
+		want: map[string]interface{}{
+			"/$schema":                              jsonschema.Draft,
+			"/$ref":                                 "#/$defs/pb3.Nests",
+			"/$defs/pb3.Nests/type":                 "object",
+			"/$defs/pb3.Nested/title":               "pb3.Nested",
+			"/$defs/pb3.Nests/additionalProperties": false,
+			"/$defs/pb3.Nests/properties/sNested/anyOf/0/$ref":  "#/$defs/pb3.Nested",
+			"/$defs/pb3.Nests/properties/s_nested/anyOf/1/type": "null",
+			"/$defs/pb3.Nested/properties/sNested/anyOf/0/$ref": "#/$defs/pb3.Nested",
+			"/$defs/pb3.Nests/allOf/0/not/anyOf/0/required/0":   "sNested",
+			"/$defs/pb3.Nests/allOf/0/not/anyOf/0/required/1":   "s_nested",
+			"/$defs/pb3.Nested/properties/sString/type":         []interface{}{"string", "null"},
+			"/$defs/pb3.Nested/properties/s_string/type":        []interface{}{"string", "null"},
+			"/$defs/pb3.Nested/allOf/0/not/anyOf/0/required/0":  "sString",
+			"/$defs/pb3.Nested/allOf/1/not/anyOf/0/required/1":  "s_nested",
+		},
+	}, {
+		desc:  "scalars",
+		input: &pb3.Scalars{},
+		want: map[string]interface{}{
+			"/$defs/pb3.Scalars/properties/sBool/type":             []interface{}{"boolean", "null"},
+			"/$defs/pb3.Scalars/properties/sInt32/type":            []interface{}{"integer", "string", "null"},
+			"/$defs/pb3.Scalars/properties/sInt32/maximum":         float64(2147483647),
+			"/$defs/pb3.Scalars/properties/sUint32/minimum":        float64(0),
+			"/$defs/pb3.Scalars/properties/sInt64/type":            []interface{}{"string", "integer", "null"},
+			"/$defs/pb3.Scalars/properties/sFloat/type":            []interface{}{"number", "string", "null"},
+			"/$defs/pb3.Scalars/properties/sBytes/contentEncoding": "base64",
+		},
+	}, {
+		desc:  "UseProtoNames",
+		opts:  jsonschema.Options{UseProtoNames: true},
+		input: &pb3.JSONNames{},
+		want: map[string]interface{}{
+			"/$defs/pb3.JSONNames/allOf/0/not/anyOf/0/required/0": "s_string",
+			"/$defs/pb3.JSONNames/allOf/0/not/anyOf/0/required/1": "foo_bar",
+		},
+	}, {
+		desc:  "oneofs",
+		input: &pb3.Oneofs{},
+		want: map[string]interface{}{
+			"/$defs/pb3.Oneofs/allOf/0/not/anyOf/0/required/0":  "oneofEnum",
+			"/$defs/pb3.Oneofs/allOf/0/not/anyOf/0/required/1":  "oneof_enum",
+			"/$defs/pb3.Oneofs/allOf/0/not/anyOf/14/required/0": "oneofNested",
+			"/$defs/pb3.Oneofs/allOf/0/not/anyOf/14/required/1": "oneof_nested",
+			"/$defs/pb3.Enum/anyOf/0/enum":                      []interface{}{"ZERO", "ONE", "TWO", "TEN"},
+			"/$defs/pb3.Enum/anyOf/1/type":                      "integer",
+		},
+	}, {
+		desc:  "union message",
+		input: &pb3.Union{},
+		want: map[string]interface{}{
+			"/$defs/pb3.Union/type":                           "object",
+			"/$defs/pb3.Union/properties/none/anyOf/0/$ref":   "#/$defs/google.protobuf.Empty",
+			"/$defs/pb3.Union/allOf/0/not/anyOf/0/required/0": "none",
+		},
+	}, {
+		desc:  "OneofEmptyAsString",
+		opts:  jsonschema.Options{OneofEmptyAsString: true},
+		input: &pb3.Union{},
+		want: map[string]interface{}{
+			"/$defs/pb3.Union/anyOf/0/type":                         "object",
+			"/$defs/pb3.Union/anyOf/0/properties/none/anyOf/0/$ref": "#/$defs/google.protobuf.Empty",
+			"/$defs/pb3.Union/anyOf/1/type":                         "string",
+			"/$defs/pb3.Union/anyOf/1/enum":                         []interface{}{"none"},
+		},
+	}, {
+		desc:  "maps",
+		input: &pb3.Maps{},
+		want: map[string]interface{}{
+			"/$defs/pb3.Maps/properties/int32ToStr/type":                        []interface{}{"object", "null"},
+			"/$defs/pb3.Maps/properties/int32ToStr/propertyNames/pattern":       `^[-+]?[0-9]+$`,
+			"/$defs/pb3.Maps/properties/int32ToStr/additionalProperties/type":   "string",
+			"/$defs/pb3.Maps/properties/boolToUint32/propertyNames/pattern":     `^(true|false)$`,
+			"/$defs/pb3.Maps/properties/strToNested/additionalProperties/$ref":  "#/$defs/pb3.Nested",
+			"/$defs/pb3.Maps/properties/uint64ToEnum/additionalProperties/$ref": "#/$defs/pb3.Enum",
+			"/$defs/pb3.Maps/properties/uint64ToEnum/propertyNames/pattern":     `^\+?[0-9]+$`,
+		},
+	}, {
+		desc:  "proto2 repeated, groups and required fields",
+		input: &pb2.Nests{},
+		want: map[string]interface{}{
+			"/$defs/pb2.Nests/properties/rptNested/type":        []interface{}{"array", "null"},
+			"/$defs/pb2.Nests/properties/rptNested/items/$ref":  "#/$defs/pb2.Nested",
+			"/$defs/pb2.Nests/properties/optgroup/anyOf/0/$ref": "#/$defs/pb2.Nests.OptGroup",
+			"/$defs/pb2.Nests/properties/OptGroup/anyOf/0/$ref": "#/$defs/pb2.Nests.OptGroup",
+		},
+	}, {
+		desc:  "required fields",
+		input: &pb2.PartialRequired{},
+		want: map[string]interface{}{
+			"/$defs/pb2.PartialRequired/allOf/0/anyOf/0/required/0": "reqString",
+			"/$defs/pb2.PartialRequired/allOf/0/anyOf/1/required/0": "req_string",
+		},
+	}, {
+		desc:  "extensions",
+		input: &pb2.Extensions{},
+		want: map[string]interface{}{
+			`/$defs/pb2.Extensions/patternProperties/^\[[^\]]+\]$`: map[string]interface{}{},
+		},
+	}, {
+		desc:  "well-known types",
+		input: &pb2.KnownTypes{},
+		want: map[string]interface{}{
+			"/$defs/pb2.KnownTypes/properties/optNull/type":           "null",
+			"/$defs/google.protobuf.Int64Value/allOf/0/type":          []interface{}{"string", "integer"},
+			"/$defs/google.protobuf.BoolValue/allOf/0/type":           "boolean",
+			"/$defs/google.protobuf.Timestamp/type":                   "string",
+			"/$defs/google.protobuf.Timestamp/format":                 "date-time",
+			"/$defs/google.protobuf.Duration/type":                    "string",
+			"/$defs/google.protobuf.FieldMask/type":                   "string",
+			"/$defs/google.protobuf.Struct/additionalProperties/$ref": "#/$defs/google.protobuf.Value",
+			"/$defs/google.protobuf.ListValue/items/$ref":             "#/$defs/google.protobuf.Value",
+			"/$defs/google.protobuf.Value/title":                      "google.protobuf.Value",
+			"/$defs/google.protobuf.Any/properties/@type/type":        "string",
+			"/$defs/google.protobuf.Empty/additionalProperties":       false,
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := tt.opts.Generate(tt.input.ProtoReflect().Descriptor())
+			if err != nil {
+				t.Fatalf("Generate() returned error: %v", err)
+			}
+			var doc interface{}
+			if err := json.Unmarshal(b, &doc); err != nil {
+				t.Fatalf("Generate() returned invalid JSON: %v\n%s", err, b)
+			}
+			for ptr, want := range tt.want {
+				got, ok := lookup(doc, ptr)
+				if !ok {
+					t.Errorf("Generate() missing %s\n%s", ptr, b)
+					continue
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("Generate() %s mismatch (-want +got):\n%s", ptr, diff)
+				}
+			}
+		})
+	}
+}
+
+// lookup resolves a simplified JSON pointer without escape sequences.
+func lookup(v interface{}, ptr string) (interface{}, bool) {
+	for _, tok := range strings.Split(ptr, "/")[1:] {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = x[tok]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			var i int
+			for _, c := range tok {
+				i = 10*i + int(c-'0')
+			}
+			if i >= len(x) {
+				return nil, false
+			}
+			v = x[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -6,6 +6,7 @@ package protojson
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"math"
 	"strconv"
@@ -18,7 +19,6 @@ import (
 	"google.golang.org/protobuf/internal/pragma"
 	"google.golang.org/protobuf/internal/set"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/reflect/protoreflect"
 	pref "google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 )
@@ -37,8 +37,91 @@ type UnmarshalOptions struct {
 	AllowPartial bool
 
 	// If DiscardUnknown is set, unknown fields are ignored.
+	// It has no effect on messages for which unknown fields are preserved
+	// using UnknownStructField or UnknownMembers.
 	DiscardUnknown bool
 
+	// UnknownStructField names a singular google.protobuf.Struct field in
+	// which to store unknown JSON object members. For every message that has
+	// such a field, each unknown member is added to it as an entry of the
+	// Struct rather than being rejected or discarded. The field itself is not
+	// addressable by its own name in JSON; use the same MarshalOptions field
+	// to have its entries written back as members of the enclosing object.
+	UnknownStructField pref.Name
+
+	// UnknownMembers, if non-nil, is populated with the unknown JSON object
+	// members of every message that has no UnknownStructField. Pass the same
+	// map to MarshalOptions.UnknownMembers to have them written back.
+	// Unmarshal first deletes any entries for the message and its
+	// submessages, so the map may be reused across calls.
+	UnknownMembers UnknownMembers
+
+	// RelaxedSyntax specifies whether to accept the following extensions to
+	// the JSON syntax, which are commonly used in hand-written files:
+	// line comments (//) and block comments (/* */), trailing commas in
+	// objects and arrays, single-quoted strings and unquoted field names.
+	// Field values are still interpreted according to the JSON format.
+	RelaxedSyntax bool
+
+	// CaseInsensitiveFieldNames specifies whether to accept, for a field,
+	// any name equal to its JSON or proto name under case folding, such as
+	// "FooBar" or "FOO_BAR" for the field foo_bar. It is an error if such a
+	// name matches more than one field of the message.
+	CaseInsensitiveFieldNames bool
+
+	// CaseInsensitiveEnums specifies whether to accept enum value names that
+	// are equal to the name of a value under case folding. It is an error if
+	// such a name matches more than one number of the enum.
+	CaseInsensitiveEnums bool
+
+	// TrimEnumPrefix specifies whether to accept enum value names without
+	// the prefix formed by the name of the enum type, such as "RED" for the
+	// value COLOR_RED of the enum Color. The prefix is matched ignoring case
+	// and underscores. It is an error if such a name matches more than one
+	// number of the enum.
+	TrimEnumPrefix bool
+
+	// ClosedEnumUnknownFields specifies whether to store numeric values that
+	// are not defined by a closed enum, which is an enum declared in a proto2
+	// file, in the unknown fields of the message as proto.Unmarshal does,
+	// rather than in the enum field. For map fields, the whole entry is
+	// stored in the unknown fields.
+	ClosedEnumUnknownFields bool
+
+	// Int64Form specifies which JSON representations are accepted for 64-bit
+	// integer values, including those of the google.protobuf.Int64Value and
+	// google.protobuf.UInt64Value wrappers. Map keys are always JSON strings
+	// and are not affected. It defaults to Int64QuotedOrRaw.
+	Int64Form Int64Form
+
+	// RejectImpreciseIntegers specifies whether to reject 64-bit integer
+	// values given as JSON numbers whose magnitude exceeds 2^53. Producers
+	// that represent JSON numbers as IEEE 754 double precision values, as
+	// JavaScript does, may have silently rounded such values.
+	RejectImpreciseIntegers bool
+
+	// BytesEncoding specifies the accepted encoding of bytes values.
+	// If zero or BytesURLBase64, standard or URL-safe base64, with or without
+	// padding, is accepted. If BytesHex, only hexadecimal strings, in upper
+	// or lower case, are accepted.
+	BytesEncoding BytesEncoding
+
+	// OneofStyle specifies the JSON representation of oneofs. Input in any
+	// other representation is rejected. It defaults to the standard
+	// OneofFlattened.
+	OneofStyle OneofStyle
+
+	// OneofTagKey is the name of the discriminator member of oneofs in the
+	// OneofInternallyTagged style. It defaults to "type".
+	OneofTagKey string
+
+	// OneofEmptyAsString specifies whether to accept a JSON string naming a
+	// oneof field of message type without fields, such as
+	// google.protobuf.Empty, in place of the representation of the oneof.
+	// Such strings are rejected by default, including for messages that
+	// consist of a single oneof. See MarshalOptions.OneofEmptyAsString.
+	OneofEmptyAsString bool
+
 	// Resolver is used for looking up types when unmarshaling
 	// google.protobuf.Any messages or extension fields.
 	// If nil, this defaults to using protoregistry.GlobalTypes.
@@ -46,21 +129,48 @@ type UnmarshalOptions struct {
 		protoregistry.MessageTypeResolver
 		protoregistry.ExtensionTypeResolver
 	}
+
+	// AnyFallbackResolver, if non-nil, is used for looking up the type of
+	// google.protobuf.Any messages whose type URL Resolver fails to resolve,
+	// for example by loading a descriptor set on demand.
+	AnyFallbackResolver func(typeURL string) (pref.MessageType, error)
+
+	// AllowUnresolvableAny specifies whether to accept a google.protobuf.Any
+	// message whose type cannot be resolved if it is represented as a JSON
+	// object with an "@type" member and a "value" member holding the base64
+	// encoding of the serialized message, as written by
+	// MarshalOptions.EmitUnresolvableAny. The value is then stored as is.
+	AllowUnresolvableAny bool
+
+	// Unmarshalers provides custom JSON representations for message types,
+	// keyed by message full name. The function is called with the raw JSON
+	// value wherever a message of that type is unmarshaled, including within
+	// lists, maps and google.protobuf.Any messages, and must populate the
+	// given empty message. A custom unmarshaler takes precedence over the
+	// built-in representation of well-known types.
+	Unmarshalers map[pref.FullName]func([]byte, proto.Message) error
 }
 
 // Unmarshal reads the given []byte and populates the given proto.Message using
 // options in UnmarshalOptions object. It will clear the message first before
 // setting the fields. If it returns an error, the given message may be
-// partially set.
+// partially set. Errors due to invalid input are of type *UnmarshalError.
 func (o UnmarshalOptions) Unmarshal(b []byte, m proto.Message) error {
+	if o.UnknownMembers != nil {
+		o.UnknownMembers.clear(m.ProtoReflect())
+	}
 	proto.Reset(m)
 
 	if o.Resolver == nil {
 		o.Resolver = protoregistry.GlobalTypes
 	}
 
-	dec := decoder{json.NewDecoder(b), o}
-	if err := dec.unmarshalMessage(m.ProtoReflect(), false); err != nil {
+	newDecoder := json.NewDecoder
+	if o.RelaxedSyntax {
+		newDecoder = json.NewRelaxedDecoder
+	}
+	dec := decoder{newDecoder(b), o, new(decodePath)}
+	if err := dec.unmarshalMessage(m.ProtoReflect(), ""); err != nil {
 		return err
 	}
 
@@ -70,7 +180,7 @@ func (o UnmarshalOptions) Unmarshal(b []byte, m proto.Message) error {
 		return err
 	}
 	if tok.Kind() != json.EOF {
-		return dec.unexpectedTokenError(tok)
+		return dec.syntaxError(tok.Pos(), "unexpected token %s", tok.RawString())
 	}
 
 	if o.AllowPartial {
@@ -79,35 +189,264 @@ func (o UnmarshalOptions) Unmarshal(b []byte, m proto.Message) error {
 	return proto.CheckInitialized(m)
 }
 
+// Int64Form specifies the accepted JSON representations of 64-bit integers.
+type Int64Form int
+
+const (
+	// Int64QuotedOrRaw accepts both JSON strings and JSON numbers.
+	Int64QuotedOrRaw Int64Form = iota
+	// Int64Quoted only accepts JSON strings, as written by Marshal by
+	// default.
+	Int64Quoted
+	// Int64Raw only accepts JSON numbers, as written by Marshal with
+	// MarshalOptions.UseRawNumeric.
+	Int64Raw
+)
+
+// UnknownMembers holds the JSON object members that did not correspond to any
+// field, keyed by the message in which they occurred. Each member maps its
+// name to its raw JSON value.
+//
+// Messages are tracked by identity, so unknown members of messages that are
+// copied, or that are serialized inside a google.protobuf.Any, are not
+// associated with the copy.
+type UnknownMembers map[proto.Message]map[string][]byte
+
+// clear deletes the entries for m and its submessages.
+func (u UnknownMembers) clear(m pref.Message) {
+	delete(u, m.Interface())
+	m.Range(func(fd pref.FieldDescriptor, v pref.Value) bool {
+		switch {
+		case fd.IsList():
+			if fd.Message() != nil {
+				list := v.List()
+				for i := 0; i < list.Len(); i++ {
+					u.clear(list.Get(i).Message())
+				}
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ pref.MapKey, v pref.Value) bool {
+					u.clear(v.Message())
+					return true
+				})
+			}
+		case fd.Message() != nil:
+			u.clear(v.Message())
+		}
+		return true
+	})
+}
+
+// ErrorKind classifies an UnmarshalError.
+type ErrorKind int
+
+const (
+	// OtherError is any error not covered by the other kinds, such as
+	// duplicate fields or unresolvable google.protobuf.Any types.
+	OtherError ErrorKind = iota
+	// SyntaxError indicates that the input is not valid JSON.
+	SyntaxError
+	// InvalidValueError indicates a JSON value of the wrong type or a value
+	// that is not valid for the field type.
+	InvalidValueError
+	// UnknownFieldError indicates a JSON object member that does not
+	// correspond to a known field.
+	UnknownFieldError
+	// InvalidEnumError indicates an enum name or number that is not valid for
+	// the enum field.
+	InvalidEnumError
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case OtherError:
+		return "other error"
+	case SyntaxError:
+		return "syntax error"
+	case InvalidValueError:
+		return "invalid value"
+	case UnknownFieldError:
+		return "unknown field"
+	case InvalidEnumError:
+		return "invalid enum value"
+	}
+	return fmt.Sprintf("<unknown:%d>", int(k))
+}
+
+// UnmarshalError is the error returned by Unmarshal when the input cannot be
+// decoded. Errors due to missing required fields are not UnmarshalErrors.
+type UnmarshalError struct {
+	// Kind classifies the error.
+	Kind ErrorKind
+
+	// Path is a JSON Pointer (RFC 6901) to the JSON value that was being
+	// decoded when the error occurred, e.g. "/items/3/price".
+	// It is empty if the error occurred at the top-level value.
+	Path string
+
+	// Field is the full name of the field that was being decoded when the
+	// error occurred. It is empty at the top-level value and for unknown fields.
+	Field pref.FullName
+
+	// Line and Column are the 1-based position of the error in the input.
+	Line, Column int
+
+	err error
+}
+
+func (e *UnmarshalError) Error() string {
+	return e.err.Error()
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.err
+}
+
 type decoder struct {
 	*json.Decoder
 	opts UnmarshalOptions
+	path *decodePath
 }
 
-// newError returns an error object with position info.
-func (d decoder) newError(pos int, f string, x ...interface{}) error {
+// decodePath tracks the location of the value being decoded.
+type decodePath struct {
+	elems []pathElem
+}
+
+type pathElem struct {
+	// token is the unescaped JSON Pointer reference token.
+	token string
+	// fd is the field the value belongs to, or nil for unknown fields.
+	fd pref.FieldDescriptor
+}
+
+// push descends into the value identified by token.
+func (d decoder) push(token string, fd pref.FieldDescriptor) {
+	d.path.elems = append(d.path.elems, pathElem{token, fd})
+}
+
+// pop ascends from the value most recently pushed.
+func (d decoder) pop() {
+	d.path.elems = d.path.elems[:len(d.path.elems)-1]
+}
+
+// pointer returns the JSON Pointer to the value being decoded.
+func (p *decodePath) pointer() string {
+	var b []byte
+	for _, e := range p.elems {
+		b = append(b, '/')
+		for i := 0; i < len(e.token); i++ {
+			switch c := e.token[i]; c {
+			case '~':
+				b = append(b, "~0"...)
+			case '/':
+				b = append(b, "~1"...)
+			default:
+				b = append(b, c)
+			}
+		}
+	}
+	return string(b)
+}
+
+// field returns the full name of the field being decoded, if any.
+func (p *decodePath) field() pref.FullName {
+	if n := len(p.elems); n > 0 && p.elems[n-1].fd != nil {
+		return p.elems[n-1].fd.FullName()
+	}
+	return ""
+}
+
+// Read returns the next JSON token, converting syntax errors to
+// UnmarshalErrors.
+func (d decoder) Read() (json.Token, error) {
+	tok, err := d.Decoder.Read()
+	return tok, d.wrapError(err)
+}
+
+// Peek looks ahead to the next JSON token, converting syntax errors to
+// UnmarshalErrors.
+func (d decoder) Peek() (json.Token, error) {
+	tok, err := d.Decoder.Peek()
+	return tok, d.wrapError(err)
+}
+
+// ReadValue reads the next JSON value, converting syntax errors to
+// UnmarshalErrors.
+func (d decoder) ReadValue() ([]byte, error) {
+	b, err := d.Decoder.ReadValue()
+	return b, d.wrapError(err)
+}
+
+// wrapError converts errors from the JSON decoder to UnmarshalErrors.
+func (d decoder) wrapError(err error) error {
+	switch e := err.(type) {
+	case nil, *UnmarshalError:
+		return err
+	case *json.SyntaxError:
+		return d.newUnmarshalError(SyntaxError, e.Line, e.Column, e)
+	}
+	if err == json.ErrUnexpectedEOF {
+		line, column := d.Position(d.Offset())
+		return d.newUnmarshalError(SyntaxError, line, column, err)
+	}
+	return err
+}
+
+func (d decoder) newUnmarshalError(kind ErrorKind, line, column int, err error) error {
+	e := &UnmarshalError{
+		Kind:   kind,
+		Line:   line,
+		Column: column,
+		err:    err,
+	}
+	if d.path != nil {
+		e.Path = d.path.pointer()
+		e.Field = d.path.field()
+	}
+	return e
+}
+
+// newErrorKind returns an UnmarshalError of the given kind with position info.
+func (d decoder) newErrorKind(kind ErrorKind, pos int, f string, x ...interface{}) error {
 	line, column := d.Position(pos)
 	head := fmt.Sprintf("(line %d:%d): ", line, column)
-	return errors.New(head+f, x...)
+	if kind == SyntaxError {
+		head = "syntax error " + head
+	}
+	return d.newUnmarshalError(kind, line, column, errors.New(head+f, x...))
 }
 
-// unexpectedTokenError returns a syntax error for the given unexpected token.
+// newError returns an error object with position info.
+func (d decoder) newError(pos int, f string, x ...interface{}) error {
+	return d.newErrorKind(OtherError, pos, f, x...)
+}
+
+// unexpectedTokenError returns an error for the given unexpected token.
 func (d decoder) unexpectedTokenError(tok json.Token) error {
-	// panic("")
-	return d.syntaxError(tok.Pos(), "unexpected token %s", tok.RawString())
+	return d.newErrorKind(InvalidValueError, tok.Pos(), "unexpected token %s", tok.RawString())
 }
 
 // syntaxError returns a syntax error for given position.
 func (d decoder) syntaxError(pos int, f string, x ...interface{}) error {
-	line, column := d.Position(pos)
-	// panic("")
-	head := fmt.Sprintf("syntax error (line %d:%d): ", line, column)
-	return errors.New(head+f, x...)
+	return d.newErrorKind(SyntaxError, pos, f, x...)
+}
+
+// invalidValueError returns an error for a value that is not valid for the
+// type it is decoded into.
+func (d decoder) invalidValueError(pos int, f string, x ...interface{}) error {
+	return d.newErrorKind(InvalidValueError, pos, f, x...)
+}
+
+// unknownFieldError returns an error for the given unknown field name token.
+func (d decoder) unknownFieldError(tok json.Token) error {
+	return d.newErrorKind(UnknownFieldError, tok.Pos(), "unknown field %v", tok.RawString())
 }
 
 // unmarshalMessage unmarshals a message into the given protoreflect.Message.
-func (d decoder) unmarshalMessage(m pref.Message, skipTypeURL bool) error {
-	if isCustomType(m.Descriptor().FullName()) {
+func (d decoder) unmarshalMessage(m pref.Message, skipName string) error {
+	if d.isCustomType(m.Descriptor().FullName()) {
 		return d.unmarshalCustomType(m)
 	}
 
@@ -115,58 +454,50 @@ func (d decoder) unmarshalMessage(m pref.Message, skipTypeURL bool) error {
 	if err != nil {
 		return err
 	}
-	// TODO unmarshall "Y" if message is `message X { oneof kind { Empty Y } }`
 	if tok.Kind() == json.String {
-		if m.Descriptor().Oneofs().Len() == 1 {
-			var fd protoreflect.FieldDescriptor
-			fields := m.Descriptor().Fields()
-			isUnion := true
-			for i := 0; i < fields.Len(); i++ {
-				f := fields.Get(i)
-				if f.ContainingOneof() == nil {
-					isUnion = false
-					break
-				}
-				str := tok.RawString()
-				str = str[1 : len(str)-1]
-				if string(f.Name()) == str || (f.HasJSONName() && string(f.JSONName()) == str) {
-					fd = f
-				}
-			}
-			if !isUnion {
-				return d.syntaxError(tok.Pos(), "token looks like a 'union' but proto structure doesn't. token '%s'", tok.RawString())
-			}
-			if fd == nil {
-				return d.syntaxError(tok.Pos(), "token looks like a 'union' but no matching field. token '%s'", tok.RawString())
-			}
-			// Required or optional fields.
-			if err := d.unmarshalSingular(m, fd); err != nil {
-				return err
-			}
-			return nil
-		}
+		return d.unmarshalEmptyVariant(m, tok)
 	}
 	if tok.Kind() != json.ObjectOpen {
 		return d.unexpectedTokenError(tok)
 	}
 
-	if err := d.unmarshalFields(m, skipTypeURL); err != nil {
+	if err := d.unmarshalFields(m, skipName); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// unmarshalFields unmarshals the fields into the given protoreflect.Message.
-func (d decoder) unmarshalFields(m pref.Message, skipTypeURL bool) error {
-	messageDesc := m.Descriptor()
-	if !flags.ProtoLegacy && messageset.IsMessageSet(messageDesc) {
-		return errors.New("no support for proto1 MessageSets")
+// isCustomType returns true if the named message type has either a custom
+// unmarshaler or a special JSON representation as a well-known type.
+func (d decoder) isCustomType(name pref.FullName) bool {
+	_, ok := d.opts.Unmarshalers[name]
+	return ok || isCustomType(name)
+}
+
+// unmarshalCustomUnmarshaler reads the next JSON value and unmarshals it into
+// the given message using the custom unmarshaler fn.
+func (d decoder) unmarshalCustomUnmarshaler(m pref.Message, fn func([]byte, proto.Message) error) error {
+	tok, err := d.Peek()
+	if err != nil {
+		return err
+	}
+	b, err := d.ReadValue()
+	if err != nil {
+		return err
 	}
+	if err := fn(b, m.Interface()); err != nil {
+		return d.newError(tok.Pos(), "%s: custom unmarshaler error: %v", m.Descriptor().FullName(), err)
+	}
+	return nil
+}
 
+// unmarshalFields unmarshals the fields into the given protoreflect.Message.
+func (d decoder) unmarshalFields(m pref.Message, skipName string) error {
+	messageDesc := m.Descriptor()
 	var seenNums set.Ints
 	var seenOneofs set.Ints
-	fieldDescs := messageDesc.Fields()
+	unknownFd := unknownStructField(messageDesc, d.opts.UnknownStructField)
 	for {
 		// Read field name.
 		tok, err := d.Read()
@@ -188,58 +519,58 @@ func (d decoder) unmarshalFields(m pref.Message, skipTypeURL bool) error {
 		// Unmarshaling a non-custom embedded message in Any will contain the
 		// JSON field "@type" which should be skipped because it is not a field
 		// of the embedded message, but simply an artifact of the Any format.
-		if skipTypeURL && name == "@type" {
+		// Likewise for the discriminator of an internally tagged oneof.
+		if skipName != "" && name == skipName {
 			d.Read()
 			continue
 		}
 
-		// Get the FieldDescriptor.
-		var fd pref.FieldDescriptor
-		if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
-			// Only extension names are in [name] format.
-			extName := pref.FullName(name[1 : len(name)-1])
-			extType, err := d.findExtension(extName)
-			if err != nil && err != protoregistry.NotFound {
-				return d.newError(tok.Pos(), "unable to resolve %s: %v", tok.RawString(), err)
+		if od := oneofByName(messageDesc, name); od != nil && d.opts.OneofStyle != OneofFlattened {
+			// Do not allow the same oneof more than once.
+			idx := uint64(od.Index())
+			if seenOneofs.Has(idx) {
+				return d.newError(tok.Pos(), "error parsing %s, oneof %v is already set", tok.RawString(), od.FullName())
 			}
-			if extType != nil {
-				fd = extType.TypeDescriptor()
-				if !messageDesc.ExtensionRanges().Has(fd.Number()) || fd.ContainingMessage().FullName() != messageDesc.FullName() {
-					return d.newError(tok.Pos(), "message %v cannot be extended by %v", messageDesc.FullName(), fd.FullName())
-				}
-			}
-		} else {
-			// The name can either be the JSON name or the proto field name.
-			fd = fieldDescs.ByJSONName(name)
-			if fd == nil {
-				fd = fieldDescs.ByName(pref.Name(name))
-				if fd == nil {
-					// The proto name of a group field is in all lowercase,
-					// while the textual field name is the group message name.
-					gd := fieldDescs.ByName(pref.Name(strings.ToLower(name)))
-					if gd != nil && gd.Kind() == pref.GroupKind && gd.Message().Name() == pref.Name(name) {
-						fd = gd
-					}
-				} else if fd.Kind() == pref.GroupKind && fd.Message().Name() != pref.Name(name) {
-					fd = nil // reset since field name is actually the message name
-				}
+			seenOneofs.Set(idx)
+
+			d.push(name, nil)
+			if err := d.unmarshalOneof(m, od); err != nil {
+				return err
 			}
+			d.pop()
+			continue
 		}
-		if flags.ProtoLegacy {
-			if fd != nil && fd.IsWeak() && fd.Message().IsPlaceholder() {
-				fd = nil // reset since the weak reference is not linked in
-			}
+
+		// Get the FieldDescriptor.
+		fd, err := d.findField(messageDesc, name, tok.Pos())
+		if err != nil {
+			return err
+		}
+		if fd != nil && fd == unknownFd {
+			fd = nil // the designated field is not addressable by name
+		}
+		if fd != nil && fd.ContainingOneof() != nil && !fd.ContainingOneof().IsSynthetic() && d.opts.OneofStyle != OneofFlattened {
+			fd = nil // oneof fields are only addressable within their oneof
 		}
 
+		d.push(name, fd)
 		if fd == nil {
 			// Field is unknown.
+			if unknownFd != nil || d.opts.UnknownMembers != nil {
+				if err := d.unmarshalUnknown(m, unknownFd, name); err != nil {
+					return err
+				}
+				d.pop()
+				continue
+			}
 			if d.opts.DiscardUnknown {
 				if err := d.skipJSONValue(); err != nil {
 					return err
 				}
+				d.pop()
 				continue
 			}
-			return d.newError(tok.Pos(), "unknown field %v", tok.RawString())
+			return d.unknownFieldError(tok)
 		}
 
 		// Do not allow duplicate fields.
@@ -253,6 +584,7 @@ func (d decoder) unmarshalFields(m pref.Message, skipTypeURL bool) error {
 		// google.protobuf.Value or google.protobuf.NullValue.
 		if tok, _ := d.Peek(); tok.Kind() == json.Null && !isKnownValue(fd) && !isNullValue(fd) {
 			d.Read()
+			d.pop()
 			continue
 		}
 
@@ -282,9 +614,119 @@ func (d decoder) unmarshalFields(m pref.Message, skipTypeURL bool) error {
 				return err
 			}
 		}
+		if d.opts.ClosedEnumUnknownFields {
+			if err := moveUndefinedEnums(m, fd); err != nil {
+				return err
+			}
+		}
+		d.pop()
 	}
 }
 
+// findField returns the field of messageDesc with the given name at input
+// position pos, which is either a field's JSON name, proto name or, for groups, message
+// name, or an extension's full name in brackets. It returns nil if there is
+// no such field.
+func (d decoder) findField(messageDesc pref.MessageDescriptor, name string, pos int) (pref.FieldDescriptor, error) {
+	fieldDescs := messageDesc.Fields()
+	var fd pref.FieldDescriptor
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		// Only extension names are in [name] format.
+		extName := pref.FullName(name[1 : len(name)-1])
+		extType, err := d.findExtension(extName)
+		if err != nil && err != protoregistry.NotFound {
+			return nil, d.newError(pos, "unable to resolve %q: %v", name, err)
+		}
+		if extType != nil {
+			fd = extType.TypeDescriptor()
+			if !messageDesc.ExtensionRanges().Has(fd.Number()) || fd.ContainingMessage().FullName() != messageDesc.FullName() {
+				return nil, d.newError(pos, "message %v cannot be extended by %v", messageDesc.FullName(), fd.FullName())
+			}
+		}
+	} else {
+		// The name can either be the JSON name or the proto field name.
+		fd = fieldDescs.ByJSONName(name)
+		if fd == nil {
+			fd = fieldDescs.ByName(pref.Name(name))
+			if fd == nil {
+				// The proto name of a group field is in all lowercase,
+				// while the textual field name is the group message name.
+				gd := fieldDescs.ByName(pref.Name(strings.ToLower(name)))
+				if gd != nil && gd.Kind() == pref.GroupKind && gd.Message().Name() == pref.Name(name) {
+					fd = gd
+				}
+			} else if fd.Kind() == pref.GroupKind && fd.Message().Name() != pref.Name(name) {
+				fd = nil // reset since field name is actually the message name
+			}
+		}
+		if fd == nil && d.opts.CaseInsensitiveFieldNames {
+			for i := 0; i < fieldDescs.Len(); i++ {
+				f := fieldDescs.Get(i)
+				if !strings.EqualFold(name, string(f.Name())) && !strings.EqualFold(name, f.JSONName()) {
+					continue
+				}
+				if fd != nil {
+					return nil, d.newError(pos, "field name %q of %v is ambiguous: matches %v and %v", name, messageDesc.FullName(), fd.Name(), f.Name())
+				}
+				fd = f
+			}
+		}
+	}
+	if flags.ProtoLegacy {
+		if fd != nil && fd.IsWeak() && fd.Message().IsPlaceholder() {
+			fd = nil // reset since the weak reference is not linked in
+		}
+	}
+	return fd, nil
+}
+
+// unknownStructField returns the field of md with the given name if it is a
+// singular google.protobuf.Struct field, otherwise nil.
+func unknownStructField(md pref.MessageDescriptor, name pref.Name) pref.FieldDescriptor {
+	if name == "" {
+		return nil
+	}
+	fd := md.Fields().ByName(name)
+	if fd == nil || fd.Cardinality() == pref.Repeated || fd.Message() == nil ||
+		fd.Message().FullName() != "google.protobuf.Struct" {
+		return nil
+	}
+	return fd
+}
+
+// unmarshalUnknown preserves the next JSON value as the unknown member name of
+// m, either as an entry of the Struct field fd or in the UnknownMembers map if
+// fd is nil.
+func (d decoder) unmarshalUnknown(m pref.Message, fd pref.FieldDescriptor, name string) error {
+	if fd == nil {
+		b, err := d.ReadValue()
+		if err != nil {
+			return err
+		}
+		key := m.Interface()
+		members := d.opts.UnknownMembers[key]
+		if members == nil {
+			members = make(map[string][]byte)
+			d.opts.UnknownMembers[key] = members
+		}
+		members[name] = b
+		return nil
+	}
+
+	st := m.Mutable(fd).Message()
+	mmap := st.Mutable(st.Descriptor().Fields().ByNumber(1)).Map()
+	key := pref.ValueOfString(name).MapKey()
+	if mmap.Has(key) {
+		return d.newError(d.Offset(), "duplicate field %q", name)
+	}
+	val := mmap.NewValue()
+	if err := d.unmarshalMessage(val.Message(), ""); err != nil {
+		return err
+	}
+	mmap.Set(key, val)
+	return nil
+}
+
 // findExtension returns protoreflect.ExtensionType from the resolver if found.
 func (d decoder) findExtension(xtName pref.FullName) (pref.ExtensionType, error) {
 	xt, err := d.opts.Resolver.FindExtensionByName(xtName)
@@ -312,7 +754,7 @@ func (d decoder) unmarshalSingular(m pref.Message, fd pref.FieldDescriptor) erro
 	switch fd.Kind() {
 	case pref.MessageKind, pref.GroupKind:
 		val = m.NewField(fd)
-		err = d.unmarshalMessage(val.Message(), false)
+		err = d.unmarshalMessage(val.Message(), "")
 	default:
 		val, err = d.unmarshalScalar(fd)
 	}
@@ -348,7 +790,14 @@ func (d decoder) unmarshalScalar(fd pref.FieldDescriptor) (pref.Value, error) {
 		}
 
 	case pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
+		if err := d.checkInt64Form(tok, kind); err != nil {
+			return pref.Value{}, err
+		}
 		if v, ok := unmarshalInt(tok, b64); ok {
+			if tok.Kind() == json.Number && d.opts.RejectImpreciseIntegers &&
+				(v.Int() < -maxExactInteger || v.Int() > maxExactInteger) {
+				return pref.Value{}, d.impreciseIntegerError(tok, kind)
+			}
 			return v, nil
 		}
 
@@ -358,7 +807,13 @@ func (d decoder) unmarshalScalar(fd pref.FieldDescriptor) (pref.Value, error) {
 		}
 
 	case pref.Uint64Kind, pref.Fixed64Kind:
+		if err := d.checkInt64Form(tok, kind); err != nil {
+			return pref.Value{}, err
+		}
 		if v, ok := unmarshalUint(tok, b64); ok {
+			if tok.Kind() == json.Number && d.opts.RejectImpreciseIntegers && v.Uint() > maxExactInteger {
+				return pref.Value{}, d.impreciseIntegerError(tok, kind)
+			}
 			return v, nil
 		}
 
@@ -378,12 +833,16 @@ func (d decoder) unmarshalScalar(fd pref.FieldDescriptor) (pref.Value, error) {
 		}
 
 	case pref.BytesKind:
-		if v, ok := unmarshalBytes(tok); ok {
+		if v, ok := unmarshalBytes(tok, d.opts.BytesEncoding); ok {
 			return v, nil
 		}
 
 	case pref.EnumKind:
-		if v, ok := unmarshalEnum(tok, fd); ok {
+		v, ok, err := d.unmarshalEnum(tok, fd)
+		if err != nil {
+			return pref.Value{}, err
+		}
+		if ok {
 			return v, nil
 		}
 
@@ -391,7 +850,28 @@ func (d decoder) unmarshalScalar(fd pref.FieldDescriptor) (pref.Value, error) {
 		panic(fmt.Sprintf("unmarshalScalar: invalid scalar kind %v", kind))
 	}
 
-	return pref.Value{}, d.newError(tok.Pos(), "invalid value for %v type: %v", kind, tok.RawString())
+	if kind == pref.EnumKind {
+		return pref.Value{}, d.newErrorKind(InvalidEnumError, tok.Pos(), "invalid value for %v type: %v", kind, tok.RawString())
+	}
+	return pref.Value{}, d.invalidValueError(tok.Pos(), "invalid value for %v type: %v", kind, tok.RawString())
+}
+
+// checkInt64Form returns an error if the representation of the 64-bit integer
+// token tok is not accepted by the Int64Form option.
+func (d decoder) checkInt64Form(tok json.Token, kind pref.Kind) error {
+	switch {
+	case d.opts.Int64Form == Int64Quoted && tok.Kind() == json.Number:
+		return d.invalidValueError(tok.Pos(), "invalid value for %v type: %v, want quoted integer", kind, tok.RawString())
+	case d.opts.Int64Form == Int64Raw && tok.Kind() == json.String:
+		return d.invalidValueError(tok.Pos(), "invalid value for %v type: %v, want unquoted integer", kind, tok.RawString())
+	}
+	return nil
+}
+
+// impreciseIntegerError returns an error for a 64-bit integer token that may
+// have been rounded by a producer using double precision numbers.
+func (d decoder) impreciseIntegerError(tok json.Token, kind pref.Kind) error {
+	return d.invalidValueError(tok.Pos(), "invalid value for %v type: %v exceeds 2^53 and may have lost precision", kind, tok.RawString())
 }
 
 func unmarshalInt(tok json.Token, bitSize int) (pref.Value, bool) {
@@ -508,12 +988,20 @@ func getFloat(tok json.Token, bitSize int) (pref.Value, bool) {
 	return pref.ValueOfFloat64(n), true
 }
 
-func unmarshalBytes(tok json.Token) (pref.Value, bool) {
+func unmarshalBytes(tok json.Token, encoding BytesEncoding) (pref.Value, bool) {
 	if tok.Kind() != json.String {
 		return pref.Value{}, false
 	}
 
 	s := tok.ParsedString()
+	if encoding == BytesHex {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return pref.Value{}, false
+		}
+		return pref.ValueOfBytes(b), true
+	}
+
 	enc := base64.StdEncoding
 	if strings.ContainsAny(s, "-_") {
 		enc = base64.URLEncoding
@@ -528,28 +1016,170 @@ func unmarshalBytes(tok json.Token) (pref.Value, bool) {
 	return pref.ValueOfBytes(b), true
 }
 
-func unmarshalEnum(tok json.Token, fd pref.FieldDescriptor) (pref.Value, bool) {
+func (d decoder) unmarshalEnum(tok json.Token, fd pref.FieldDescriptor) (pref.Value, bool, error) {
 	switch tok.Kind() {
 	case json.String:
 		// Lookup EnumNumber based on name.
 		s := tok.ParsedString()
 		if enumVal := fd.Enum().Values().ByName(pref.Name(s)); enumVal != nil {
-			return pref.ValueOfEnum(enumVal.Number()), true
+			return pref.ValueOfEnum(enumVal.Number()), true, nil
+		}
+		if d.opts.CaseInsensitiveEnums || d.opts.TrimEnumPrefix {
+			return d.matchEnum(tok, fd.Enum())
 		}
 
 	case json.Number:
 		if n, ok := tok.Int(32); ok {
-			return pref.ValueOfEnum(pref.EnumNumber(n)), true
+			return pref.ValueOfEnum(pref.EnumNumber(n)), true, nil
 		}
 
 	case json.Null:
 		// This is only valid for google.protobuf.NullValue.
 		if isNullValue(fd) {
-			return pref.ValueOfEnum(0), true
+			return pref.ValueOfEnum(0), true, nil
 		}
 	}
 
-	return pref.Value{}, false
+	return pref.Value{}, false, nil
+}
+
+// matchEnum looks up the value of ed whose name matches the given string token
+// under the CaseInsensitiveEnums and TrimEnumPrefix options.
+func (d decoder) matchEnum(tok json.Token, ed pref.EnumDescriptor) (pref.Value, bool, error) {
+	s := tok.ParsedString()
+	equal := func(x, y string) bool { return x == y }
+	if d.opts.CaseInsensitiveEnums {
+		equal = strings.EqualFold
+	}
+
+	var match pref.EnumValueDescriptor
+	values := ed.Values()
+	for i := 0; i < values.Len(); i++ {
+		v := values.Get(i)
+		name := string(v.Name())
+		ok := equal(s, name)
+		if !ok && d.opts.TrimEnumPrefix {
+			if suffix, trimmed := trimEnumPrefix(name, string(ed.Name())); trimmed {
+				ok = equal(s, suffix)
+			}
+		}
+		if !ok {
+			continue
+		}
+		if match != nil && match.Number() != v.Number() {
+			return pref.Value{}, false, d.newErrorKind(InvalidEnumError, tok.Pos(), "enum value %v of %v is ambiguous: matches %v and %v", tok.RawString(), ed.FullName(), match.Name(), v.Name())
+		}
+		match = v
+	}
+	if match == nil {
+		return pref.Value{}, false, nil
+	}
+	return pref.ValueOfEnum(match.Number()), true, nil
+}
+
+// trimEnumPrefix returns the given enum value name without the prefix formed by
+// the enum type name, which is matched ignoring case and underscores, and the
+// underscores that follow it. For example, COLOR_RED is trimmed to RED for the
+// enum Color. It reports false if the name has no such prefix or consists of
+// the prefix only.
+func trimEnumPrefix(name, enumName string) (string, bool) {
+	i := 0
+	for j := 0; j < len(enumName); j++ {
+		if enumName[j] == '_' {
+			continue
+		}
+		for i < len(name) && name[i] == '_' {
+			i++
+		}
+		if i == len(name) || toLowerASCII(name[i]) != toLowerASCII(enumName[j]) {
+			return name, false
+		}
+		i++
+	}
+	for i < len(name) && name[i] == '_' {
+		i++
+	}
+	if i == len(name) {
+		return name, false
+	}
+	return name[i:], true
+}
+
+func toLowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// moveUndefinedEnums moves the values of the field fd of m that are not
+// defined by its closed enum type into the unknown fields of m. For map
+// fields, entries whose value is not defined are moved.
+func moveUndefinedEnums(m pref.Message, fd pref.FieldDescriptor) error {
+	vd := fd
+	if fd.IsMap() {
+		vd = fd.MapValue()
+	}
+	if vd.Kind() != pref.EnumKind || vd.Enum().Syntax() != pref.Proto2 {
+		return nil
+	}
+	values := vd.Enum().Values()
+	isUndefined := func(v pref.Value) bool {
+		return values.ByNumber(v.Enum()) == nil
+	}
+
+	// Collect the undefined values in a new message to marshal them in the
+	// wire format.
+	unknown := m.New()
+	switch {
+	case fd.IsList():
+		list := m.Mutable(fd).List()
+		var n int
+		for i := 0; i < list.Len(); i++ {
+			if v := list.Get(i); isUndefined(v) {
+				unknown.Mutable(fd).List().Append(v)
+			} else {
+				list.Set(n, v)
+				n++
+			}
+		}
+		list.Truncate(n)
+		if n == 0 {
+			m.Clear(fd)
+		}
+	case fd.IsMap():
+		mmap := m.Mutable(fd).Map()
+		var keys []pref.MapKey
+		mmap.Range(func(k pref.MapKey, v pref.Value) bool {
+			if isUndefined(v) {
+				unknown.Mutable(fd).Map().Set(k, v)
+				keys = append(keys, k)
+			}
+			return true
+		})
+		for _, k := range keys {
+			mmap.Clear(k)
+		}
+	default:
+		if !m.Has(fd) || !isUndefined(m.Get(fd)) {
+			return nil
+		}
+		unknown.Set(fd, m.Get(fd))
+		m.Clear(fd)
+	}
+	if !unknown.Has(fd) {
+		return nil
+	}
+
+	b, err := proto.MarshalOptions{
+		AllowPartial:  true,
+		Deterministic: true,
+	}.Marshal(unknown.Interface())
+	if err != nil {
+		return err
+	}
+	m.SetUnknown(append(m.GetUnknown(), b...))
+	return nil
 }
 
 func (d decoder) unmarshalList(list pref.List, fd pref.FieldDescriptor) error {
@@ -574,11 +1204,13 @@ func (d decoder) unmarshalList(list pref.List, fd pref.FieldDescriptor) error {
 				return nil
 			}
 
+			d.push(strconv.Itoa(list.Len()), fd)
 			val := list.NewElement()
-			if err := d.unmarshalMessage(val.Message(), false); err != nil {
+			if err := d.unmarshalMessage(val.Message(), ""); err != nil {
 				return err
 			}
 			list.Append(val)
+			d.pop()
 		}
 	default:
 		for {
@@ -592,11 +1224,13 @@ func (d decoder) unmarshalList(list pref.List, fd pref.FieldDescriptor) error {
 				return nil
 			}
 
+			d.push(strconv.Itoa(list.Len()), fd)
 			val, err := d.unmarshalScalar(fd)
 			if err != nil {
 				return err
 			}
 			list.Append(val)
+			d.pop()
 		}
 	}
 
@@ -620,7 +1254,7 @@ func (d decoder) unmarshalMap(mmap pref.Map, fd pref.FieldDescriptor) error {
 	case pref.MessageKind, pref.GroupKind:
 		unmarshalMapValue = func() (pref.Value, error) {
 			val := mmap.NewValue()
-			if err := d.unmarshalMessage(val.Message(), false); err != nil {
+			if err := d.unmarshalMessage(val.Message(), ""); err != nil {
 				return pref.Value{}, err
 			}
 			return val, nil
@@ -648,6 +1282,7 @@ Loop:
 		}
 
 		// Unmarshal field name.
+		d.push(tok.Name(), fd)
 		pkey, err := d.unmarshalMapKey(tok, fd.MapKey())
 		if err != nil {
 			return err
@@ -665,6 +1300,7 @@ Loop:
 		}
 
 		mmap.Set(pkey, pval)
+		d.pop()
 	}
 
 	return nil
@@ -673,47 +1309,55 @@ Loop:
 // unmarshalMapKey converts given token of Name kind into a protoreflect.MapKey.
 // A map key type is any integral or string type.
 func (d decoder) unmarshalMapKey(tok json.Token, fd pref.FieldDescriptor) (pref.MapKey, error) {
+	if key, ok := parseMapKey(tok.Name(), fd); ok {
+		return key, nil
+	}
+	return pref.MapKey{}, d.invalidValueError(tok.Pos(), "invalid value for %v key: %s", fd.Kind(), tok.RawString())
+}
+
+// parseMapKey converts the given JSON object member name into a
+// protoreflect.MapKey of the kind of fd.
+func parseMapKey(name string, fd pref.FieldDescriptor) (pref.MapKey, bool) {
 	const b32 = 32
 	const b64 = 64
 	const base10 = 10
 
-	name := tok.Name()
 	kind := fd.Kind()
 	switch kind {
 	case pref.StringKind:
-		return pref.ValueOfString(name).MapKey(), nil
+		return pref.ValueOfString(name).MapKey(), true
 
 	case pref.BoolKind:
 		switch name {
 		case "true":
-			return pref.ValueOfBool(true).MapKey(), nil
+			return pref.ValueOfBool(true).MapKey(), true
 		case "false":
-			return pref.ValueOfBool(false).MapKey(), nil
+			return pref.ValueOfBool(false).MapKey(), true
 		}
 
 	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind:
 		if n, err := strconv.ParseInt(name, base10, b32); err == nil {
-			return pref.ValueOfInt32(int32(n)).MapKey(), nil
+			return pref.ValueOfInt32(int32(n)).MapKey(), true
 		}
 
 	case pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
 		if n, err := strconv.ParseInt(name, base10, b64); err == nil {
-			return pref.ValueOfInt64(int64(n)).MapKey(), nil
+			return pref.ValueOfInt64(int64(n)).MapKey(), true
 		}
 
 	case pref.Uint32Kind, pref.Fixed32Kind:
 		if n, err := strconv.ParseUint(name, base10, b32); err == nil {
-			return pref.ValueOfUint32(uint32(n)).MapKey(), nil
+			return pref.ValueOfUint32(uint32(n)).MapKey(), true
 		}
 
 	case pref.Uint64Kind, pref.Fixed64Kind:
 		if n, err := strconv.ParseUint(name, base10, b64); err == nil {
-			return pref.ValueOfUint64(uint64(n)).MapKey(), nil
+			return pref.ValueOfUint64(uint64(n)).MapKey(), true
 		}
 
 	default:
 		panic(fmt.Sprintf("invalid kind for map key: %v", kind))
 	}
 
-	return pref.MapKey{}, d.newError(tok.Pos(), "invalid value for %v key: %s", kind, tok.RawString())
+	return pref.MapKey{}, false
 }
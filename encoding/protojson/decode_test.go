@@ -5,19 +5,25 @@
 package protojson_test
 
 import (
+	"encoding/json"
 	"math"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/internal/errors"
 	"google.golang.org/protobuf/internal/flags"
 	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
 	preg "google.golang.org/protobuf/reflect/protoregistry"
 
 	fieldmaskpb "google.golang.org/protobuf/internal/testprotos/fieldmaskpb"
 	testpb "google.golang.org/protobuf/internal/testprotos/test"
 	weakpb "google.golang.org/protobuf/internal/testprotos/test/weak1"
+	test3pb "google.golang.org/protobuf/internal/testprotos/test3"
 	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
 	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -147,6 +153,68 @@ func TestUnmarshal(t *testing.T) {
   "sString": null
 }`,
 		wantMessage: &pb3.Scalars{},
+	}, {
+		desc:         "proto3 optional set to zero values",
+		inputMessage: &test3pb.TestProto3Optional{},
+		inputText: `{
+  "optionalInt32": 0,
+  "optionalInt64": 0,
+  "optionalUint32": 0,
+  "optionalUint64": 0,
+  "optionalSint32": 0,
+  "optionalSint64": 0,
+  "optionalFixed32": 0,
+  "optionalFixed64": 0,
+  "optionalSfixed32": 0,
+  "optionalSfixed64": 0,
+  "optionalFloat": 0,
+  "optionalDouble": 0,
+  "optionalBool": false,
+  "optionalString": "",
+  "optionalBytes": "",
+  "optionalNestedMessage": {},
+  "optionalNestedEnum": "FOO"
+}`,
+		wantMessage: &test3pb.TestProto3Optional{
+			OptionalInt32:         proto.Int32(0),
+			OptionalInt64:         proto.Int64(0),
+			OptionalUint32:        proto.Uint32(0),
+			OptionalUint64:        proto.Uint64(0),
+			OptionalSint32:        proto.Int32(0),
+			OptionalSint64:        proto.Int64(0),
+			OptionalFixed32:       proto.Uint32(0),
+			OptionalFixed64:       proto.Uint64(0),
+			OptionalSfixed32:      proto.Int32(0),
+			OptionalSfixed64:      proto.Int64(0),
+			OptionalFloat:         proto.Float32(0),
+			OptionalDouble:        proto.Float64(0),
+			OptionalBool:          proto.Bool(false),
+			OptionalString:        proto.String(""),
+			OptionalBytes:         []byte{},
+			OptionalNestedMessage: &test3pb.TestAllTypes_NestedMessage{},
+			OptionalNestedEnum:    test3pb.TestAllTypes_FOO.Enum(),
+		},
+	}, {
+		desc:         "proto3 optional set to null",
+		inputMessage: &test3pb.TestProto3Optional{},
+		inputText: `{
+  "optionalInt32": null,
+  "optionalString": null,
+  "optionalNestedMessage": null,
+  "optionalNestedEnum": null
+}`,
+		wantMessage: &test3pb.TestProto3Optional{},
+	}, {
+		desc:         "proto3 optional alongside oneof",
+		inputMessage: &test3pb.TestProto3Optional{},
+		inputText: `{
+  "optionalUint32": 0,
+  "oneofUint32": 0
+}`,
+		wantMessage: &test3pb.TestProto3Optional{
+			OptionalUint32: proto.Uint32(0),
+			OneofField:     &test3pb.TestProto3Optional_OneofUint32{},
+		},
 	}, {
 		desc:         "boolean",
 		inputMessage: &pb3.Scalars{},
@@ -1349,7 +1417,6 @@ func TestUnmarshal(t *testing.T) {
 			})
 			return m
 		}(),
-		skip: !flags.ProtoLegacy,
 	}, {
 		desc:         "not real MessageSet 1",
 		inputMessage: &pb2.FakeMessageSet{},
@@ -1365,7 +1432,6 @@ func TestUnmarshal(t *testing.T) {
 			})
 			return m
 		}(),
-		skip: !flags.ProtoLegacy,
 	}, {
 		desc:         "not real MessageSet 2",
 		inputMessage: &pb2.FakeMessageSet{},
@@ -1375,7 +1441,6 @@ func TestUnmarshal(t *testing.T) {
   }
 }`,
 		wantErr: `unknown field "[pb2.FakeMessageSetExtension]"`,
-		skip:    !flags.ProtoLegacy,
 	}, {
 		desc:         "not real MessageSet 3",
 		inputMessage: &pb2.MessageSet{},
@@ -1391,7 +1456,6 @@ func TestUnmarshal(t *testing.T) {
 			})
 			return m
 		}(),
-		skip: !flags.ProtoLegacy,
 	}, {
 		desc:         "Empty",
 		inputMessage: &emptypb.Empty{},
@@ -1939,6 +2003,44 @@ func TestUnmarshal(t *testing.T) {
 		inputMessage: &anypb.Any{},
 		inputText:    `{"@type": "foo/pb2.Nested"}`,
 		wantErr:      `(line 1:11): unable to resolve "foo/pb2.Nested":`,
+	}, {
+		desc: "Any without registered type and AllowUnresolvableAny",
+		umo: protojson.UnmarshalOptions{
+			Resolver:             new(preg.Types),
+			AllowUnresolvableAny: true,
+		},
+		inputMessage: &anypb.Any{},
+		inputText:    `{"@type": "foo/pb2.Nested", "value": "CgF4"}`,
+		wantMessage:  &anypb.Any{TypeUrl: "foo/pb2.Nested", Value: []byte("\x0a\x01x")},
+	}, {
+		desc: "Any without registered type and fields with AllowUnresolvableAny",
+		umo: protojson.UnmarshalOptions{
+			Resolver:             new(preg.Types),
+			AllowUnresolvableAny: true,
+		},
+		inputMessage: &anypb.Any{},
+		inputText:    `{"@type": "foo/pb2.Nested", "optString": "x"}`,
+		wantErr:      `(line 1:11): unable to resolve "foo/pb2.Nested":`,
+	}, {
+		desc: "Any with invalid value and AllowUnresolvableAny",
+		umo: protojson.UnmarshalOptions{
+			Resolver:             new(preg.Types),
+			AllowUnresolvableAny: true,
+		},
+		inputMessage: &anypb.Any{},
+		inputText:    `{"@type": "foo/pb2.Nested", "value": 1}`,
+		wantErr:      `invalid value for Any.value: 1`,
+	}, {
+		desc: "Any with AnyFallbackResolver",
+		umo: protojson.UnmarshalOptions{
+			Resolver: new(preg.Types),
+			AnyFallbackResolver: func(url string) (pref.MessageType, error) {
+				return preg.GlobalTypes.FindMessageByURL(url)
+			},
+		},
+		inputMessage: &anypb.Any{},
+		inputText:    `{"@type": "foo/pb2.Nested", "optString": "x"}`,
+		wantMessage:  &anypb.Any{TypeUrl: "foo/pb2.Nested", Value: []byte("\x0a\x01x")},
 	}, {
 		desc:         "Any with missing required",
 		inputMessage: &anypb.Any{},
@@ -2403,6 +2505,280 @@ func TestUnmarshal(t *testing.T) {
 		inputText:    `{"weak_message1":{"a":1}, "weak_message2":{"a":1}}`,
 		wantErr:      `unknown field "weak_message2"`, // weak_message2 is unknown since the package containing it is not imported
 		skip:         !flags.ProtoLegacy,
+	}, {
+		desc: "custom unmarshaler",
+		umo: protojson.UnmarshalOptions{
+			Unmarshalers: map[pref.FullName]func([]byte, proto.Message) error{
+				"pb2.Nested": func(b []byte, m proto.Message) error {
+					s, err := strconv.Unquote(string(b))
+					if err != nil {
+						return err
+					}
+					m.(*pb2.Nested).OptString = proto.String(s)
+					return nil
+				},
+			},
+		},
+		inputMessage: &pb2.Nests{},
+		inputText:    `{"optNested": "one", "rptNested": ["two", "three"]}`,
+		wantMessage: &pb2.Nests{
+			OptNested: &pb2.Nested{OptString: proto.String("one")},
+			RptNested: []*pb2.Nested{
+				{OptString: proto.String("two")},
+				{OptString: proto.String("three")},
+			},
+		},
+	}, {
+		desc: "custom unmarshaler in map",
+		umo: protojson.UnmarshalOptions{
+			Unmarshalers: map[pref.FullName]func([]byte, proto.Message) error{
+				"pb3.Nested": func(b []byte, m proto.Message) error {
+					m.(*pb3.Nested).SString = string(b)
+					return nil
+				},
+			},
+		},
+		inputMessage: &pb3.Maps{},
+		inputText:    `{"strToNested": {"nested": [1, {"x": null}]}}`,
+		wantMessage: &pb3.Maps{
+			StrToNested: map[string]*pb3.Nested{
+				"nested": {SString: `[1, {"x": null}]`},
+			},
+		},
+	}, {
+		desc: "custom unmarshaler overrides well-known type",
+		umo: protojson.UnmarshalOptions{
+			Unmarshalers: map[pref.FullName]func([]byte, proto.Message) error{
+				"google.protobuf.Duration": func(b []byte, m proto.Message) error {
+					n, err := strconv.ParseInt(string(b), 10, 64)
+					m.(*durationpb.Duration).Seconds = n
+					return err
+				},
+			},
+		},
+		inputMessage: &pb2.KnownTypes{},
+		inputText:    `{"optDuration": 60}`,
+		wantMessage:  &pb2.KnownTypes{OptDuration: &durationpb.Duration{Seconds: 60}},
+	}, {
+		desc: "custom unmarshaler in Any",
+		umo: protojson.UnmarshalOptions{
+			Unmarshalers: map[pref.FullName]func([]byte, proto.Message) error{
+				"pb2.Nested": func(b []byte, m proto.Message) error {
+					s, err := strconv.Unquote(string(b))
+					m.(*pb2.Nested).OptString = proto.String(s)
+					return err
+				},
+			},
+		},
+		inputMessage: &anypb.Any{},
+		inputText:    `{"@type": "pb2.Nested", "value": "embedded"}`,
+		wantMessage: func() proto.Message {
+			b, err := proto.MarshalOptions{Deterministic: true}.Marshal(&pb2.Nested{OptString: proto.String("embedded")})
+			if err != nil {
+				t.Fatalf("error in binary marshaling message for Any.value: %v", err)
+			}
+			return &anypb.Any{TypeUrl: "pb2.Nested", Value: b}
+		}(),
+	}, {
+		desc: "custom unmarshaler returns error",
+		umo: protojson.UnmarshalOptions{
+			Unmarshalers: map[pref.FullName]func([]byte, proto.Message) error{
+				"pb2.Nested": func([]byte, proto.Message) error {
+					return errors.New("failed")
+				},
+			},
+		},
+		inputMessage: &pb2.Nests{},
+		inputText:    `{"optNested": {}}`,
+		wantErr:      `(line 1:15): pb2.Nested: custom unmarshaler error: failed`,
+	}, {
+		desc:         "RelaxedSyntax",
+		umo:          protojson.UnmarshalOptions{RelaxedSyntax: true},
+		inputMessage: &pb2.Nests{},
+		inputText: `// Leading comment.
+{
+  optNested: {
+    'optString': 'single "quoted"', /* inline comment */
+  },
+  rptNested: [
+    {optString: "one"},
+    {opt_string: 'two'}, // trailing comma
+  ],
+}`,
+		wantMessage: &pb2.Nests{
+			OptNested: &pb2.Nested{OptString: proto.String(`single "quoted"`)},
+			RptNested: []*pb2.Nested{
+				{OptString: proto.String("one")},
+				{OptString: proto.String("two")},
+			},
+		},
+	}, {
+		desc:         "RelaxedSyntax with Any",
+		umo:          protojson.UnmarshalOptions{RelaxedSyntax: true},
+		inputMessage: &anypb.Any{},
+		inputText: `{
+  optString: "embedded", // comment
+  '@type': "foo/pb2.Nested",
+}`,
+		wantMessage: func() proto.Message {
+			b, err := proto.MarshalOptions{Deterministic: true}.Marshal(&pb2.Nested{OptString: proto.String("embedded")})
+			if err != nil {
+				t.Fatalf("error in binary marshaling message for Any.value: %v", err)
+			}
+			return &anypb.Any{TypeUrl: "foo/pb2.Nested", Value: b}
+		}(),
+	}, {
+		desc:         "comments are rejected without RelaxedSyntax",
+		inputMessage: &pb2.Nests{},
+		inputText:    `{/* comment */}`,
+		wantErr:      `syntax error (line 1:2): invalid value /`,
+	}, {
+		desc:         "trailing comma is rejected without RelaxedSyntax",
+		inputMessage: &pb2.Nests{},
+		inputText:    `{"optNested": {},}`,
+		wantErr:      `syntax error (line 1:18): unexpected token }`,
+	}, {
+		desc:         "UnknownStructField stores unknown members",
+		umo:          protojson.UnmarshalOptions{UnknownStructField: "opt_struct"},
+		inputMessage: &pb2.KnownTypes{},
+		inputText: `{
+  "optBool": true,
+  "newField": "hello",
+  "newObject": {"a": [1, null]}
+}`,
+		wantMessage: &pb2.KnownTypes{
+			OptBool: &wrapperspb.BoolValue{Value: true},
+			OptStruct: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"newField": {Kind: &structpb.Value_StringValue{StringValue: "hello"}},
+					"newObject": {Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{
+						Fields: map[string]*structpb.Value{
+							"a": {Kind: &structpb.Value_ListValue{ListValue: &structpb.ListValue{
+								Values: []*structpb.Value{
+									{Kind: &structpb.Value_NumberValue{NumberValue: 1}},
+									{Kind: &structpb.Value_NullValue{}},
+								},
+							}}},
+						},
+					}}},
+				},
+			},
+		},
+	}, {
+		desc:         "UnknownStructField field is not addressable by name",
+		umo:          protojson.UnmarshalOptions{UnknownStructField: "opt_struct"},
+		inputMessage: &pb2.KnownTypes{},
+		inputText:    `{"optStruct": true}`,
+		wantMessage: &pb2.KnownTypes{
+			OptStruct: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"optStruct": {Kind: &structpb.Value_BoolValue{BoolValue: true}},
+				},
+			},
+		},
+	}, {
+		desc:         "UnknownStructField ignored for messages without the field",
+		umo:          protojson.UnmarshalOptions{UnknownStructField: "opt_struct"},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `{"newField": 1}`,
+		wantErr:      `unknown field "newField"`,
+	}, {
+		desc:         "UnknownStructField with invalid member value",
+		umo:          protojson.UnmarshalOptions{UnknownStructField: "opt_struct"},
+		inputMessage: &pb2.KnownTypes{},
+		inputText:    `{"newField": }`,
+		wantErr:      `invalid google.protobuf.Value: }`,
+	}, {
+		desc:         "Int64Raw accepts unquoted 64-bit integers",
+		umo:          protojson.UnmarshalOptions{Int64Form: protojson.Int64Raw},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `{"optInt64": -9223372036854775808, "optFixed64": 18446744073709551615}`,
+		wantMessage: &pb2.Scalars{
+			OptInt64:   proto.Int64(math.MinInt64),
+			OptFixed64: proto.Uint64(math.MaxUint64),
+		},
+	}, {
+		desc:         "Int64Raw rejects quoted 64-bit integers",
+		umo:          protojson.UnmarshalOptions{Int64Form: protojson.Int64Raw},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `{"optUint64": "1"}`,
+		wantErr:      `invalid value for uint64 type: "1", want unquoted integer`,
+	}, {
+		desc:         "Int64Raw does not affect 32-bit integers",
+		umo:          protojson.UnmarshalOptions{Int64Form: protojson.Int64Raw},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `{"optInt32": "1"}`,
+		wantMessage:  &pb2.Scalars{OptInt32: proto.Int32(1)},
+	}, {
+		desc:         "Int64Raw does not affect map keys",
+		umo:          protojson.UnmarshalOptions{Int64Form: protojson.Int64Raw},
+		inputMessage: &pb3.Maps{},
+		inputText:    `{"uint64ToEnum": {"18446744073709551615": "TEN"}}`,
+		wantMessage: &pb3.Maps{
+			Uint64ToEnum: map[uint64]pb3.Enum{math.MaxUint64: pb3.Enum_TEN},
+		},
+	}, {
+		desc:         "Int64Quoted rejects unquoted 64-bit integers",
+		umo:          protojson.UnmarshalOptions{Int64Form: protojson.Int64Quoted},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `{"optSfixed64": 1}`,
+		wantErr:      `invalid value for sfixed64 type: 1, want quoted integer`,
+	}, {
+		desc:         "Int64Quoted rejects unquoted 64-bit integers in wrappers",
+		umo:          protojson.UnmarshalOptions{Int64Form: protojson.Int64Quoted},
+		inputMessage: &pb2.KnownTypes{},
+		inputText:    `{"optUint64": 1}`,
+		wantErr:      `invalid value for uint64 type: 1, want quoted integer`,
+	}, {
+		desc:         "RejectImpreciseIntegers",
+		umo:          protojson.UnmarshalOptions{RejectImpreciseIntegers: true},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `{"optInt64": 9007199254740993}`,
+		wantErr:      `invalid value for int64 type: 9007199254740993 exceeds 2^53 and may have lost precision`,
+	}, {
+		desc:         "RejectImpreciseIntegers for unsigned wrapper",
+		umo:          protojson.UnmarshalOptions{RejectImpreciseIntegers: true},
+		inputMessage: &pb2.KnownTypes{},
+		inputText:    `{"optUint64": 18446744073709551615}`,
+		wantErr:      `invalid value for uint64 type: 18446744073709551615 exceeds 2^53`,
+	}, {
+		desc:         "RejectImpreciseIntegers accepts exact and quoted values",
+		umo:          protojson.UnmarshalOptions{RejectImpreciseIntegers: true},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `{"optInt64": -9007199254740992, "optUint64": "18446744073709551615"}`,
+		wantMessage: &pb2.Scalars{
+			OptInt64:  proto.Int64(-1 << 53),
+			OptUint64: proto.Uint64(math.MaxUint64),
+		},
+	}, {
+		desc:         "fixed precision floats",
+		inputMessage: &pb3.Scalars{},
+		inputText:    `{"sFloat": 1.50, "sDouble": "-1000000000000000000000.00"}`,
+		wantMessage:  &pb3.Scalars{SFloat: 1.5, SDouble: -1e21},
+	}, {
+		desc:         "BytesHex",
+		umo:          protojson.UnmarshalOptions{BytesEncoding: protojson.BytesHex},
+		inputMessage: &pb3.Scalars{},
+		inputText:    `{"sBytes": "DEADbeef"}`,
+		wantMessage:  &pb3.Scalars{SBytes: []byte{0xde, 0xad, 0xbe, 0xef}},
+	}, {
+		desc:         "BytesHex in wrapper",
+		umo:          protojson.UnmarshalOptions{BytesEncoding: protojson.BytesHex},
+		inputMessage: &wrapperspb.BytesValue{},
+		inputText:    `"00ff"`,
+		wantMessage:  &wrapperspb.BytesValue{Value: []byte{0x00, 0xff}},
+	}, {
+		desc:         "BytesHex rejects base64",
+		umo:          protojson.UnmarshalOptions{BytesEncoding: protojson.BytesHex},
+		inputMessage: &pb3.Scalars{},
+		inputText:    `{"sBytes": "aGVsbG8="}`,
+		wantErr:      `invalid value for bytes type: "aGVsbG8="`,
+	}, {
+		desc:         "BytesURLBase64 without padding",
+		umo:          protojson.UnmarshalOptions{BytesEncoding: protojson.BytesURLBase64},
+		inputMessage: &pb3.Scalars{},
+		inputText:    `{"sBytes": "-_8B"}`,
+		wantMessage:  &pb3.Scalars{SBytes: []byte{0xfb, 0xff, 0x01}},
 	}}
 
 	for _, tt := range tests {
@@ -2429,3 +2805,173 @@ func TestUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestUnknownMembers(t *testing.T) {
+	input := `{"optNested":{"optString":"inner","innerNew":[1,2]},"optString":"outer","outerNew":{"x":"y"}}`
+	unknown := protojson.UnknownMembers{}
+	m := &pb2.Nested{}
+	if err := (protojson.UnmarshalOptions{UnknownMembers: unknown}).Unmarshal([]byte(input), m); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+	if got := string(unknown[m]["outerNew"]); got != `{"x":"y"}` {
+		t.Errorf("UnknownMembers[outer][outerNew] = %s, want {\"x\":\"y\"}", got)
+	}
+	if got := string(unknown[m.OptNested]["innerNew"]); got != `[1,2]` {
+		t.Errorf("UnknownMembers[inner][innerNew] = %s, want [1,2]", got)
+	}
+
+	b, err := protojson.MarshalOptions{UnknownMembers: unknown}.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %v", err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Marshal() returned invalid JSON: %v\n%s", err, b)
+	}
+	json.Unmarshal([]byte(input), &want)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+
+	// Reusing the map drops the entries of the previous contents.
+	inner := m.OptNested
+	if err := (protojson.UnmarshalOptions{UnknownMembers: unknown}).Unmarshal([]byte(`{"optString":"x"}`), m); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v", err)
+	}
+	if _, ok := unknown[m]; ok {
+		t.Errorf("UnknownMembers[outer] is present after Unmarshal without unknown members")
+	}
+	if _, ok := unknown[inner]; ok {
+		t.Errorf("UnknownMembers[inner] is present after Unmarshal into the parent")
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	tests := []struct {
+		desc         string
+		umo          protojson.UnmarshalOptions
+		inputMessage proto.Message
+		inputText    string
+		want         protojson.UnmarshalError
+	}{{
+		desc:         "syntax error",
+		inputMessage: &pb3.Nests{},
+		inputText:    `{"sNested": {"sString": tru}}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.SyntaxError,
+			Path:   "/sNested/sString",
+			Field:  "pb3.Nested.s_string",
+			Line:   1,
+			Column: 25,
+		},
+	}, {
+		desc:         "unexpected EOF",
+		inputMessage: &pb3.Nests{},
+		inputText:    "{\n\"sNested\": {",
+		want: protojson.UnmarshalError{
+			Kind:   protojson.SyntaxError,
+			Path:   "/sNested",
+			Field:  "pb3.Nests.s_nested",
+			Line:   2,
+			Column: 13,
+		},
+	}, {
+		desc:         "type mismatch",
+		inputMessage: &pb2.Nests{},
+		inputText: `{
+  "rptNested": [
+    {},
+    {"optNested": {"optString": 1}}
+  ]
+}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.InvalidValueError,
+			Path:   "/rptNested/1/optNested/optString",
+			Field:  "pb2.Nested.opt_string",
+			Line:   4,
+			Column: 33,
+		},
+	}, {
+		desc:         "message type mismatch",
+		inputMessage: &pb3.Nests{},
+		inputText:    `{"s_nested": []}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.InvalidValueError,
+			Path:   "/s_nested",
+			Field:  "pb3.Nests.s_nested",
+			Line:   1,
+			Column: 14,
+		},
+	}, {
+		desc:         "unknown field",
+		inputMessage: &pb3.Maps{},
+		inputText:    `{"strToNested": {"a/b": {"unknown": 1}}}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.UnknownFieldError,
+			Path:   "/strToNested/a~1b/unknown",
+			Line:   1,
+			Column: 26,
+		},
+	}, {
+		desc:         "invalid enum value",
+		inputMessage: &pb2.Enums{},
+		inputText:    `{"rptEnum": ["ONE", "UNKNOWN"]}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.InvalidEnumError,
+			Path:   "/rptEnum/1",
+			Field:  "pb2.Enums.rpt_enum",
+			Line:   1,
+			Column: 21,
+		},
+	}, {
+		desc:         "invalid map key",
+		inputMessage: &pb3.Maps{},
+		inputText:    `{"int32ToStr": {"x": "y"}}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.InvalidValueError,
+			Path:   "/int32ToStr/x",
+			Field:  "pb3.Maps.int32_to_str",
+			Line:   1,
+			Column: 17,
+		},
+	}, {
+		desc:         "invalid well-known type value",
+		inputMessage: &pb2.KnownTypes{},
+		inputText:    `{"optDuration": "1h"}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.InvalidValueError,
+			Path:   "/optDuration",
+			Field:  "pb2.KnownTypes.opt_duration",
+			Line:   1,
+			Column: 17,
+		},
+	}, {
+		desc:         "duplicate field",
+		inputMessage: &pb3.Nested{},
+		inputText:    `{"sString": "a", "s_string": "b"}`,
+		want: protojson.UnmarshalError{
+			Kind:   protojson.OtherError,
+			Path:   "/s_string",
+			Field:  "pb3.Nested.s_string",
+			Line:   1,
+			Column: 18,
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			err := tt.umo.Unmarshal([]byte(tt.inputText), tt.inputMessage)
+			got, ok := err.(*protojson.UnmarshalError)
+			if !ok {
+				t.Fatalf("Unmarshal() error got %T (%v), want *protojson.UnmarshalError", err, err)
+			}
+			if got.Kind != tt.want.Kind || got.Path != tt.want.Path || got.Field != tt.want.Field ||
+				got.Line != tt.want.Line || got.Column != tt.want.Column {
+				t.Errorf("Unmarshal() error got {%v %q %q %d:%d}, want {%v %q %q %d:%d}",
+					got.Kind, got.Path, got.Field, got.Line, got.Column,
+					tt.want.Kind, tt.want.Path, tt.want.Field, tt.want.Line, tt.want.Column)
+			}
+		})
+	}
+}
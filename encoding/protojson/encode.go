@@ -6,16 +6,15 @@ package protojson
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"sort"
 
 	"google.golang.org/protobuf/internal/encoding/json"
 	"google.golang.org/protobuf/internal/encoding/messageset"
 	"google.golang.org/protobuf/internal/errors"
-	"google.golang.org/protobuf/internal/flags"
 	"google.golang.org/protobuf/internal/pragma"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/reflect/protoreflect"
 	pref "google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 )
@@ -65,7 +64,8 @@ type MarshalOptions struct {
 	UseEnumNumbers bool
 
 	// EmitUnpopulated specifies whether to emit unpopulated fields. It does not
-	// emit unpopulated oneof fields or unpopulated extension fields.
+	// emit unpopulated oneof fields or unpopulated extension fields, unless
+	// EmitUnpopulatedOneofs or EmitUnpopulatedExtensions is set.
 	// The JSON value emitted for unpopulated fields are as follows:
 	//  ╔═══════╤════════════════════════════╗
 	//  ║ JSON  │ Protobuf field             ║
@@ -80,20 +80,160 @@ type MarshalOptions struct {
 	//  ╚═══════╧════════════════════════════╝
 	EmitUnpopulated bool
 
-	// UseRawNumeric { "x" : 1 } instead of { "x" : "1" }
+	// EmitUnpopulatedFields overrides EmitUnpopulated for individual fields,
+	// keyed by full name, such as "pkg.Message.field_name". A field mapped
+	// to true is emitted when unpopulated and a field mapped to false is not.
+	EmitUnpopulatedFields map[pref.FullName]bool
+
+	// EmitUnpopulatedPaths overrides EmitUnpopulated for the fields at the
+	// given paths, taking precedence over EmitUnpopulatedFields. A path is
+	// a dot-separated list of proto field names from the message passed to
+	// Marshal, with extension fields written as their full name in brackets,
+	// such as "field_name.[pkg.extension_name]". The elements of list fields
+	// and the values of map fields have the path of their field.
+	EmitUnpopulatedPaths map[string]bool
+
+	// EmitUnpopulatedOneofs specifies whether EmitUnpopulated also applies to
+	// oneof fields. The oneof fields to be emitted are written as null if no
+	// field of their oneof is populated. In styles other than OneofFlattened,
+	// the oneof itself is written as null if any of its fields is to be
+	// emitted. EmitUnpopulatedFields and EmitUnpopulatedPaths apply to oneof
+	// fields regardless.
+	EmitUnpopulatedOneofs bool
+
+	// EmitUnpopulatedExtensions specifies whether EmitUnpopulated,
+	// EmitUnpopulatedFields and EmitUnpopulatedPaths apply to the extension
+	// fields of messages. The extension fields of a message are looked up
+	// with Resolver, which must provide a RangeExtensionsByMessage method as
+	// protoregistry.Types does.
+	EmitUnpopulatedExtensions bool
+
+	// Canonical specifies whether to produce output in the form defined by
+	// the JSON Canonicalization Scheme (RFC 8785): without whitespace, with
+	// object members sorted by name and with numbers formatted as ECMAScript
+	// does. Multiline and Indent are ignored. Since RFC 8785 treats all numbers
+	// as double precision values, 64-bit integers should remain quoted,
+	// which is the default unless UseRawNumeric is set.
+	Canonical bool
+
+	// UseRawNumeric specifies whether to write 64-bit integer values as JSON
+	// numbers, { "x" : 1 }, instead of JSON strings, { "x" : "1" }. This
+	// applies to fields of all 64-bit integer kinds and to the
+	// google.protobuf.Int64Value and google.protobuf.UInt64Value wrappers.
+	// Map keys are always written as JSON strings.
 	UseRawNumeric bool
 
+	// QuoteImpreciseIntegers specifies whether to still write 64-bit integer
+	// values as JSON strings when UseRawNumeric is set if their magnitude
+	// exceeds 2^53. Such values cannot be represented exactly by consumers
+	// that decode JSON numbers as IEEE 754 double precision values, as
+	// JavaScript does.
+	QuoteImpreciseIntegers bool
+
+	// FloatFormat specifies how float and double values are formatted.
+	// It defaults to FloatShortest. It is ignored if Canonical is set.
+	FloatFormat FloatFormat
+
+	// FloatPrecision is the number of digits after the decimal point for
+	// FloatFixed, or the number of significant digits for FloatSignificant.
+	FloatPrecision int
+
+	// BytesEncoding specifies how bytes values are encoded as JSON strings.
+	// It defaults to BytesStdBase64.
+	BytesEncoding BytesEncoding
+
 	// UseSpecialEmpty "Z" instead of { "Z" : {} }
+	//
+	// Deprecated: Use OneofEmptyAsString, which this is equivalent to.
+	// Unmarshal only accepts the resulting strings if
+	// UnmarshalOptions.OneofEmptyAsString is set.
 	UseSpecialEmpty bool
 
+	// OneofStyle specifies the JSON representation of populated oneofs.
+	// It defaults to the standard OneofFlattened.
+	OneofStyle OneofStyle
+
+	// OneofTagKey is the name of the discriminator member written for
+	// oneofs in the OneofInternallyTagged style. It defaults to "type".
+	OneofTagKey string
+
+	// OneofEmptyAsString specifies whether a oneof whose populated field is a
+	// message type without fields, such as google.protobuf.Empty, is written
+	// as a JSON string naming the field, in place of the object that would
+	// otherwise represent the oneof. In the OneofFlattened style, this only
+	// applies to messages that consist of a single oneof, which are then
+	// written as a string in their entirety.
+	OneofEmptyAsString bool
+
 	// Resolver is used for looking up types when expanding google.protobuf.Any
 	// messages. If nil, this defaults to using protoregistry.GlobalTypes.
 	Resolver interface {
 		protoregistry.ExtensionTypeResolver
 		protoregistry.MessageTypeResolver
 	}
+
+	// AnyFallbackResolver, if non-nil, is used for looking up the type of
+	// google.protobuf.Any messages whose type URL Resolver fails to resolve,
+	// for example by loading a descriptor set on demand.
+	AnyFallbackResolver func(typeURL string) (pref.MessageType, error)
+
+	// EmitUnresolvableAny specifies whether to write a google.protobuf.Any
+	// message whose type cannot be resolved as a JSON object with an "@type"
+	// member for the type URL and a "value" member holding the base64
+	// encoding of the serialized message, instead of returning an error.
+	// The output is accepted by UnmarshalOptions.AllowUnresolvableAny.
+	EmitUnresolvableAny bool
+
+	// UnknownStructField names a singular google.protobuf.Struct field whose
+	// entries are written as members of the enclosing JSON object instead of
+	// as a nested object. It is the counterpart of
+	// UnmarshalOptions.UnknownStructField.
+	UnknownStructField pref.Name
+
+	// UnknownMembers provides additional JSON object members to write for the
+	// messages it contains, as recorded by UnmarshalOptions.UnknownMembers.
+	UnknownMembers UnknownMembers
+
+	// Marshalers provides custom JSON representations for message types,
+	// keyed by message full name. The function is called wherever a message of
+	// that type is marshaled, including within lists, maps and
+	// google.protobuf.Any messages, and must return a single valid JSON value.
+	// A custom marshaler takes precedence over the built-in representation of
+	// well-known types.
+	Marshalers map[pref.FullName]func(proto.Message) ([]byte, error)
 }
 
+// FloatFormat specifies the formatting of float and double values.
+type FloatFormat int
+
+const (
+	// FloatShortest formats values with the fewest digits that represent
+	// them exactly, such as 1.5 or 1e+21.
+	FloatShortest FloatFormat = iota
+	// FloatFixed formats values with a fixed number of digits after the
+	// decimal point and without an exponent, such as 1.50 for a precision
+	// of 2.
+	FloatFixed
+	// FloatSignificant formats values with a fixed number of significant
+	// digits, using an exponent for large or small magnitudes, as the %g
+	// verb of package fmt does.
+	FloatSignificant
+)
+
+// BytesEncoding specifies the encoding of bytes values as JSON strings.
+type BytesEncoding int
+
+const (
+	// BytesStdBase64 is the standard base64 encoding with padding, as
+	// defined in RFC 4648, section 4.
+	BytesStdBase64 BytesEncoding = iota
+	// BytesURLBase64 is the URL-safe base64 encoding without padding, as
+	// defined in RFC 4648, section 5.
+	BytesURLBase64
+	// BytesHex is the lowercase hexadecimal encoding.
+	BytesHex
+)
+
 // Format formats the message as a string.
 // This method is only intended for human consumption and ignores errors.
 // Do not depend on the output being stable. It may change over time across
@@ -111,6 +251,13 @@ func (o MarshalOptions) Format(m proto.Message) string {
 // MarshalOptions. Do not depend on the output being stable. It may change over
 // time across different versions of the program.
 func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
+	if o.UseSpecialEmpty {
+		o.OneofEmptyAsString = true
+	}
+	if o.Canonical {
+		o.Multiline = false
+		o.Indent = ""
+	}
 	if o.Multiline && o.Indent == "" {
 		o.Indent = defaultIndent
 	}
@@ -123,65 +270,99 @@ func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
 		return nil, err
 	}
 
-	enc := encoder{internalEnc, o}
+	enc := encoder{internalEnc, o, nil}
+	if len(o.EmitUnpopulatedPaths) > 0 {
+		enc.path = new(encodePath)
+	}
 	if err := enc.marshalMessage(m.ProtoReflect()); err != nil {
 		return nil, err
 	}
+	b := enc.Bytes()
+	if o.Canonical {
+		if b, err = json.Canonicalize(b); err != nil {
+			return nil, err
+		}
+	}
 	if o.AllowPartial {
-		return enc.Bytes(), nil
+		return b, nil
 	}
-	return enc.Bytes(), proto.CheckInitialized(m)
+	return b, proto.CheckInitialized(m)
 }
 
 type encoder struct {
 	*json.Encoder
 	opts MarshalOptions
+	path *encodePath // nil if EmitUnpopulatedPaths is empty
 }
 
-// marshalMessage marshals the given protoreflect.Message.
-func (e encoder) marshalMessage(m pref.Message) error {
-	if e.opts.UseSpecialEmpty {
-		fieldDescs := m.Descriptor().Fields()
-		var fdf protoreflect.FieldDescriptor
-		i := 0
-		fdlen := fieldDescs.Len()
-		oneoneof := false
-
-		for i < fdlen {
-			fd := fieldDescs.Get(i)
-			oneofName := ""
-			if od := fd.ContainingOneof(); od != nil {
-				oneoneof = true
-				if oneofName == "" {
-					oneofName = string(od.Name())
-				} else if oneofName != string(od.Name()) {
-					oneoneof = false
-					break
-				}
-				fd = m.WhichOneof(od)
-				i += od.Fields().Len()
-				if fd == nil {
-					continue
-				}
-				fdf = fd
-			} else {
-				break
-			}
-		}
-		if oneoneof && fdlen == i && fdf.Message() != nil && fdf.Message().FullName() == "google.protobuf.Empty" {
-			name := fdf.JSONName()
-			if e.opts.UseProtoNames {
-				name = string(fdf.Name())
-			}
-			e.WriteString(name)
-			return nil
+// encodePath tracks the field path of the value being encoded.
+type encodePath struct {
+	paths []string
+}
+
+// push descends into the field fd.
+func (e encoder) push(fd pref.FieldDescriptor) {
+	if e.path != nil {
+		e.path.paths = append(e.path.paths, e.fieldPath(fd))
+	}
+}
+
+// pop ascends from the field most recently pushed.
+func (e encoder) pop() {
+	if e.path != nil {
+		e.path.paths = e.path.paths[:len(e.path.paths)-1]
+	}
+}
+
+// fieldPath returns the path of the field fd of the message being encoded.
+func (e encoder) fieldPath(fd pref.FieldDescriptor) string {
+	var prefix string
+	if n := len(e.path.paths); n > 0 {
+		prefix = e.path.paths[n-1]
+	}
+	return patchPath(prefix, fd)
+}
+
+// emitUnpopulated reports whether to emit the unpopulated field fd of the
+// message being encoded.
+func (e encoder) emitUnpopulated(fd pref.FieldDescriptor) bool {
+	if e.path != nil {
+		if emit, ok := e.opts.EmitUnpopulatedPaths[e.fieldPath(fd)]; ok {
+			return emit
 		}
 	}
+	if emit, ok := e.opts.EmitUnpopulatedFields[fd.FullName()]; ok {
+		return emit
+	}
+	switch {
+	case fd.IsExtension():
+		return e.opts.EmitUnpopulated && e.opts.EmitUnpopulatedExtensions
+	case fd.ContainingOneof() != nil && !fd.ContainingOneof().IsSynthetic():
+		return e.opts.EmitUnpopulated && e.opts.EmitUnpopulatedOneofs
+	}
+	return e.opts.EmitUnpopulated
+}
+
+// unpopulatedValue returns the value to emit for the unpopulated field fd of
+// m, which is the invalid value for fields emitted as null.
+func unpopulatedValue(m pref.Message, fd pref.FieldDescriptor) pref.Value {
+	if fd.Cardinality() != pref.Repeated && fd.HasPresence() {
+		// Use invalid value to emit null.
+		return pref.Value{}
+	}
+	return m.Get(fd)
+}
 
-	if isCustomType(m.Descriptor().FullName()) {
+// marshalMessage marshals the given protoreflect.Message.
+func (e encoder) marshalMessage(m pref.Message) error {
+	if e.isCustomType(m.Descriptor().FullName()) {
 		return e.marshalCustomType(m)
 	}
 
+	if ok, err := e.marshalEmptyVariant(m); ok {
+		return err
+	}
+
 	e.StartObject()
 	defer e.EndObject()
 	if err := e.marshalFields(m); err != nil {
@@ -191,22 +372,105 @@ func (e encoder) marshalMessage(m pref.Message) error {
 	return nil
 }
 
+// isCustomType returns true if the named message type has either a custom
+// marshaler or a special JSON representation as a well-known type.
+func (e encoder) isCustomType(name pref.FullName) bool {
+	_, ok := e.opts.Marshalers[name]
+	return ok || isCustomType(name)
+}
+
+// marshalCustomMarshaler marshals the given message using the custom
+// marshaler fn and writes out the resulting JSON value.
+func (e encoder) marshalCustomMarshaler(m pref.Message, fn func(proto.Message) ([]byte, error)) error {
+	name := m.Descriptor().FullName()
+	b, err := fn(m.Interface())
+	if err != nil {
+		return errors.New("%s: custom marshaler error: %v", name, err)
+	}
+	if err := e.writeJSONValue(b); err != nil {
+		return errors.New("%s: custom marshaler returned invalid JSON: %v", name, err)
+	}
+	return nil
+}
+
+// writeJSONValue writes out the given JSON value, reformatting it to be
+// consistent with the rest of the output.
+func (e encoder) writeJSONValue(b []byte) error {
+	dec := json.NewDecoder(b)
+	for n := 0; ; n++ {
+		tok, err := dec.Read()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind() {
+		case json.EOF:
+			if n == 0 {
+				return errors.New("empty value")
+			}
+			return nil
+		case json.Null:
+			e.WriteNull()
+		case json.Bool:
+			e.WriteBool(tok.Bool())
+		case json.Number:
+			if v, ok := tok.Int(64); ok {
+				e.WriteInt(v)
+			} else if v, ok := tok.Uint(64); ok {
+				e.WriteUint(v)
+			} else {
+				v, _ := tok.Float(64)
+				e.WriteFloat(v, 64)
+			}
+		case json.String:
+			if err := e.WriteString(tok.ParsedString()); err != nil {
+				return err
+			}
+		case json.Name:
+			if err := e.WriteName(tok.Name()); err != nil {
+				return err
+			}
+		case json.ObjectOpen:
+			e.StartObject()
+		case json.ObjectClose:
+			e.EndObject()
+		case json.ArrayOpen:
+			e.StartArray()
+		case json.ArrayClose:
+			e.EndArray()
+		default:
+			return errors.New("unexpected token %s", tok.RawString())
+		}
+	}
+}
+
 // marshalFields marshals the fields in the given protoreflect.Message.
 func (e encoder) marshalFields(m pref.Message) error {
 	messageDesc := m.Descriptor()
-	if !flags.ProtoLegacy && messageset.IsMessageSet(messageDesc) {
-		return errors.New("no support for proto1 MessageSets")
-	}
-
 	// Marshal out known fields.
 	fieldDescs := messageDesc.Fields()
+	unknownFd := unknownStructField(messageDesc, e.opts.UnknownStructField)
 	for i := 0; i < fieldDescs.Len(); {
 		fd := fieldDescs.Get(i)
-		if od := fd.ContainingOneof(); od != nil {
+		if fd == unknownFd {
+			i++
+			continue // written out below
+		}
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
 			fd = m.WhichOneof(od)
 			i += od.Fields().Len()
 			if fd == nil {
-				continue // unpopulated oneofs are not affected by EmitUnpopulated
+				if err := e.marshalUnsetOneof(od); err != nil {
+					return err
+				}
+				continue
+			}
+			if e.opts.OneofStyle != OneofFlattened {
+				e.push(fd)
+				if err := e.marshalOneof(m, od, fd); err != nil {
+					return err
+				}
+				e.pop()
+				continue
 			}
 		} else {
 			i++
@@ -214,37 +478,80 @@ func (e encoder) marshalFields(m pref.Message) error {
 
 		val := m.Get(fd)
 		if !m.Has(fd) {
-			if !e.opts.EmitUnpopulated {
+			if !e.emitUnpopulated(fd) {
 				continue
 			}
-			isProto2Scalar := fd.Syntax() == pref.Proto2 && fd.Default().IsValid()
-			isSingularMessage := fd.Cardinality() != pref.Repeated && fd.Message() != nil
-			if isProto2Scalar || isSingularMessage {
-				// Use invalid value to emit null.
-				val = pref.Value{}
-			}
+			val = unpopulatedValue(m, fd)
 		}
 
-		name := fd.JSONName()
-		if e.opts.UseProtoNames {
-			name = string(fd.Name())
-			// Use type name for group field name.
-			if fd.Kind() == pref.GroupKind {
-				name = string(fd.Message().Name())
-			}
-		}
-		if err := e.WriteName(name); err != nil {
+		if err := e.WriteName(e.fieldName(fd)); err != nil {
 			return err
 		}
+		e.push(fd)
 		if err := e.marshalValue(val, fd); err != nil {
 			return err
 		}
+		e.pop()
 	}
 
 	// Marshal out extensions.
 	if err := e.marshalExtensions(m); err != nil {
 		return err
 	}
+
+	// Marshal out preserved unknown members.
+	return e.marshalUnknown(m, unknownFd)
+}
+
+// fieldName returns the JSON member name for the field fd.
+func (e encoder) fieldName(fd pref.FieldDescriptor) string {
+	if !e.opts.UseProtoNames {
+		return fd.JSONName()
+	}
+	// Use type name for group field name.
+	if fd.Kind() == pref.GroupKind {
+		return string(fd.Message().Name())
+	}
+	return string(fd.Name())
+}
+
+// marshalUnknown writes out the entries of the Struct field fd, if set, and
+// the members recorded for m in UnknownMembers, sorted by name.
+func (e encoder) marshalUnknown(m pref.Message, fd pref.FieldDescriptor) error {
+	if fd != nil && m.Has(fd) {
+		st := m.Get(fd).Message()
+		mmap := st.Get(st.Descriptor().Fields().ByNumber(1)).Map()
+		var names []string
+		mmap.Range(func(k pref.MapKey, _ pref.Value) bool {
+			names = append(names, k.String())
+			return true
+		})
+		sort.Strings(names)
+		for _, name := range names {
+			if err := e.WriteName(name); err != nil {
+				return err
+			}
+			val := mmap.Get(pref.ValueOfString(name).MapKey())
+			if err := e.marshalMessage(val.Message()); err != nil {
+				return err
+			}
+		}
+	}
+
+	members := e.opts.UnknownMembers[m.Interface()]
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := e.WriteName(name); err != nil {
+			return err
+		}
+		if err := e.writeJSONValue(members[name]); err != nil {
+			return errors.New("invalid unknown member %q: %v", name, err)
+		}
+	}
 	return nil
 }
 
@@ -285,22 +592,31 @@ func (e encoder) marshalSingular(val pref.Value, fd pref.FieldDescriptor) error
 
 	case pref.Int64Kind, pref.Sint64Kind, pref.Uint64Kind,
 		pref.Sfixed64Kind, pref.Fixed64Kind:
-		if e.opts.UseRawNumeric {
-			e.WriteInt(val.Int())
+		if e.useRawNumeric(val, kind) {
+			if kind == pref.Uint64Kind || kind == pref.Fixed64Kind {
+				e.WriteUint(val.Uint())
+			} else {
+				e.WriteInt(val.Int())
+			}
 		} else {
 			// 64-bit integers are written out as JSON string.
 			e.WriteString(val.String())
 		}
 	case pref.FloatKind:
-		// Encoder.WriteFloat handles the special numbers NaN and infinites.
-		e.WriteFloat(val.Float(), 32)
+		e.writeFloat(val.Float(), 32)
 
 	case pref.DoubleKind:
-		// Encoder.WriteFloat handles the special numbers NaN and infinites.
-		e.WriteFloat(val.Float(), 64)
+		e.writeFloat(val.Float(), 64)
 
 	case pref.BytesKind:
-		e.WriteString(base64.StdEncoding.EncodeToString(val.Bytes()))
+		switch e.opts.BytesEncoding {
+		case BytesURLBase64:
+			e.WriteString(base64.RawURLEncoding.EncodeToString(val.Bytes()))
+		case BytesHex:
+			e.WriteString(hex.EncodeToString(val.Bytes()))
+		default:
+			e.WriteString(base64.StdEncoding.EncodeToString(val.Bytes()))
+		}
 
 	case pref.EnumKind:
 		if fd.Enum().FullName() == "google.protobuf.NullValue" {
@@ -325,6 +641,39 @@ func (e encoder) marshalSingular(val pref.Value, fd pref.FieldDescriptor) error
 	return nil
 }
 
+// writeFloat writes out the given float according to the FloatFormat.
+// The Encoder handles the special numbers NaN and infinites.
+func (e encoder) writeFloat(n float64, bitSize int) {
+	switch e.opts.FloatFormat {
+	case FloatFixed:
+		e.WriteFloatPrec(n, 'f', e.opts.FloatPrecision, bitSize)
+	case FloatSignificant:
+		e.WriteFloatPrec(n, 'g', e.opts.FloatPrecision, bitSize)
+	default:
+		e.WriteFloat(n, bitSize)
+	}
+}
+
+// maxExactInteger is the largest integer magnitude up to which all integers
+// are exactly representable as IEEE 754 double precision values.
+const maxExactInteger = 1 << 53
+
+// useRawNumeric reports whether the given 64-bit integer value of the given
+// kind is to be written out as a JSON number.
+func (e encoder) useRawNumeric(val pref.Value, kind pref.Kind) bool {
+	if !e.opts.UseRawNumeric {
+		return false
+	}
+	if !e.opts.QuoteImpreciseIntegers {
+		return true
+	}
+	if kind == pref.Uint64Kind || kind == pref.Fixed64Kind {
+		return val.Uint() <= maxExactInteger
+	}
+	n := val.Int()
+	return -maxExactInteger <= n && n <= maxExactInteger
+}
+
 // marshalList marshals the given protoreflect.List.
 func (e encoder) marshalList(list pref.List, fd pref.FieldDescriptor) error {
 	e.StartArray()
@@ -395,11 +744,7 @@ func (e encoder) marshalExtensions(m pref.Message) error {
 
 	// Get a sorted list based on field key first.
 	var entries []entry
-	m.Range(func(fd pref.FieldDescriptor, v pref.Value) bool {
-		if !fd.IsExtension() {
-			return true
-		}
-
+	add := func(fd pref.FieldDescriptor, v pref.Value) {
 		// For MessageSet extensions, the name used is the parent message.
 		name := fd.FullName()
 		if messageset.IsMessageSetExtension(fd) {
@@ -412,8 +757,21 @@ func (e encoder) marshalExtensions(m pref.Message) error {
 			value: v,
 			desc:  fd,
 		})
+	}
+	m.Range(func(fd pref.FieldDescriptor, v pref.Value) bool {
+		if fd.IsExtension() {
+			add(fd, v)
+		}
 		return true
 	})
+	if r, ok := e.opts.Resolver.(extensionRanger); ok && e.opts.EmitUnpopulatedExtensions {
+		r.RangeExtensionsByMessage(m.Descriptor().FullName(), func(xt pref.ExtensionType) bool {
+			if xd := xt.TypeDescriptor(); !m.Has(xd) && e.emitUnpopulated(xd) {
+				add(xd, unpopulatedValue(m, xd))
+			}
+			return true
+		})
+	}
 
 	// Sort extensions lexicographically.
 	sort.Slice(entries, func(i, j int) bool {
@@ -428,9 +786,17 @@ func (e encoder) marshalExtensions(m pref.Message) error {
 		if err := e.WriteName("[" + entry.key + "]"); err != nil {
 			return err
 		}
+		e.push(entry.desc)
 		if err := e.marshalValue(entry.value, entry.desc); err != nil {
 			return err
 		}
+		e.pop()
 	}
 	return nil
 }
+
+// extensionRanger is implemented by resolvers that can list the extensions of
+// a message, such as protoregistry.Types.
+type extensionRanger interface {
+	RangeExtensionsByMessage(pref.FullName, func(pref.ExtensionType) bool)
+}
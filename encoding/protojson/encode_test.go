@@ -7,6 +7,7 @@ package protojson_test
 import (
 	"bytes"
 	"math"
+	"strconv"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -14,11 +15,13 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/internal/detrand"
 	"google.golang.org/protobuf/internal/encoding/pack"
-	"google.golang.org/protobuf/internal/flags"
+	"google.golang.org/protobuf/internal/errors"
 	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
 	preg "google.golang.org/protobuf/reflect/protoregistry"
 
 	fieldmaskpb "google.golang.org/protobuf/internal/testprotos/fieldmaskpb"
+	test3pb "google.golang.org/protobuf/internal/testprotos/test3"
 	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
 	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -115,6 +118,50 @@ func TestMarshal(t *testing.T) {
   "optDouble": 1.234,
   "optBytes": "6LC35q2M",
   "optString": "谷歌"
+}`,
+	}, {
+		desc:  "proto3 optional not set",
+		input: &test3pb.TestProto3Optional{},
+		want:  "{}",
+	}, {
+		desc: "proto3 optional set to zero values",
+		input: &test3pb.TestProto3Optional{
+			OptionalInt32:         proto.Int32(0),
+			OptionalInt64:         proto.Int64(0),
+			OptionalUint32:        proto.Uint32(0),
+			OptionalUint64:        proto.Uint64(0),
+			OptionalSint32:        proto.Int32(0),
+			OptionalSint64:        proto.Int64(0),
+			OptionalFixed32:       proto.Uint32(0),
+			OptionalFixed64:       proto.Uint64(0),
+			OptionalSfixed32:      proto.Int32(0),
+			OptionalSfixed64:      proto.Int64(0),
+			OptionalFloat:         proto.Float32(0),
+			OptionalDouble:        proto.Float64(0),
+			OptionalBool:          proto.Bool(false),
+			OptionalString:        proto.String(""),
+			OptionalBytes:         []byte{},
+			OptionalNestedMessage: &test3pb.TestAllTypes_NestedMessage{},
+			OptionalNestedEnum:    test3pb.TestAllTypes_FOO.Enum(),
+		},
+		want: `{
+  "optionalInt32": 0,
+  "optionalInt64": "0",
+  "optionalUint32": 0,
+  "optionalUint64": "0",
+  "optionalSint32": 0,
+  "optionalSint64": "0",
+  "optionalFixed32": 0,
+  "optionalFixed64": "0",
+  "optionalSfixed32": 0,
+  "optionalSfixed64": "0",
+  "optionalFloat": 0,
+  "optionalDouble": 0,
+  "optionalBool": false,
+  "optionalString": "",
+  "optionalBytes": "",
+  "optionalNestedMessage": {},
+  "optionalNestedEnum": "FOO"
 }`,
 	}, {
 		desc: "string",
@@ -1038,7 +1085,6 @@ func TestMarshal(t *testing.T) {
     "optString": "not a messageset extension"
   }
 }`,
-		skip: !flags.ProtoLegacy,
 	}, {
 		desc: "not real MessageSet 1",
 		input: func() proto.Message {
@@ -1053,7 +1099,6 @@ func TestMarshal(t *testing.T) {
     "optString": "not a messageset extension"
   }
 }`,
-		skip: !flags.ProtoLegacy,
 	}, {
 		desc: "not real MessageSet 2",
 		input: func() proto.Message {
@@ -1068,7 +1113,6 @@ func TestMarshal(t *testing.T) {
     "optString": "another not a messageset extension"
   }
 }`,
-		skip: !flags.ProtoLegacy,
 	}, {
 		desc:  "BoolValue empty",
 		input: &wrapperspb.BoolValue{},
@@ -1526,6 +1570,30 @@ func TestMarshal(t *testing.T) {
 		mo:      protojson.MarshalOptions{Resolver: new(preg.Types)},
 		input:   &anypb.Any{TypeUrl: "foo/pb2.Nested"},
 		wantErr: true,
+	}, {
+		desc: "Any without registered type and EmitUnresolvableAny",
+		mo: protojson.MarshalOptions{
+			Resolver:            new(preg.Types),
+			EmitUnresolvableAny: true,
+		},
+		input: &anypb.Any{TypeUrl: "foo/pb2.Nested", Value: []byte("\x0a\x01x")},
+		want: `{
+  "@type": "foo/pb2.Nested",
+  "value": "CgF4"
+}`,
+	}, {
+		desc: "Any with AnyFallbackResolver",
+		mo: protojson.MarshalOptions{
+			Resolver: new(preg.Types),
+			AnyFallbackResolver: func(url string) (pref.MessageType, error) {
+				return preg.GlobalTypes.FindMessageByURL(url)
+			},
+		},
+		input: &anypb.Any{TypeUrl: "foo/pb2.Nested", Value: []byte("\x0a\x01x")},
+		want: `{
+  "@type": "foo/pb2.Nested",
+  "optString": "x"
+}`,
 	}, {
 		desc: "Any with missing required",
 		input: func() proto.Message {
@@ -1883,6 +1951,29 @@ func TestMarshal(t *testing.T) {
   "sDouble": 0,
   "sBytes": "",
   "sString": ""
+}`,
+	}, {
+		desc:  "EmitUnpopulated: proto3 optional",
+		mo:    protojson.MarshalOptions{EmitUnpopulated: true},
+		input: &test3pb.TestProto3Optional{},
+		want: `{
+  "optionalInt32": null,
+  "optionalInt64": null,
+  "optionalUint32": null,
+  "optionalUint64": null,
+  "optionalSint32": null,
+  "optionalSint64": null,
+  "optionalFixed32": null,
+  "optionalFixed64": null,
+  "optionalSfixed32": null,
+  "optionalSfixed64": null,
+  "optionalFloat": null,
+  "optionalDouble": null,
+  "optionalBool": null,
+  "optionalString": null,
+  "optionalBytes": null,
+  "optionalNestedMessage": null,
+  "optionalNestedEnum": null
 }`,
 	}, {
 		desc:  "EmitUnpopulated: proto2 enum",
@@ -1950,6 +2041,157 @@ func TestMarshal(t *testing.T) {
     "sString": "",
     "sNested": null
   }
+}`,
+	}, {
+		desc: "EmitUnpopulatedFields",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulatedFields: map[pref.FullName]bool{
+				"pb2.Nests.opt_nested":  true,
+				"pb2.Nested.opt_string": true,
+			},
+		},
+		input: &pb2.Nests{
+			RptNested: []*pb2.Nested{{}},
+		},
+		want: `{
+  "optNested": null,
+  "rptNested": [
+    {
+      "optString": null
+    }
+  ]
+}`,
+	}, {
+		desc: "EmitUnpopulatedFields overrides EmitUnpopulated",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulated: true,
+			EmitUnpopulatedFields: map[pref.FullName]bool{
+				"pb2.Nests.optgroup":    false,
+				"pb2.Nests.rpt_nested":  false,
+				"pb2.Nests.rptgroup":    false,
+				"pb2.Nested.opt_nested": false,
+			},
+		},
+		input: &pb2.Nests{OptNested: &pb2.Nested{}},
+		want: `{
+  "optNested": {
+    "optString": null
+  }
+}`,
+	}, {
+		desc: "EmitUnpopulatedPaths",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulatedPaths: map[string]bool{
+				"s_nested.s_nested":          true,
+				"s_nested.s_nested.s_string": true,
+			},
+		},
+		input: &pb3.Nests{
+			SNested: &pb3.Nested{SNested: &pb3.Nested{}},
+		},
+		want: `{
+  "sNested": {
+    "sNested": {
+      "sString": ""
+    }
+  }
+}`,
+	}, {
+		desc: "EmitUnpopulatedPaths overrides EmitUnpopulatedFields",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulated: true,
+			EmitUnpopulatedFields: map[pref.FullName]bool{
+				"pb3.Nested.s_string": false,
+			},
+			EmitUnpopulatedPaths: map[string]bool{
+				"s_nested.s_string": true,
+				"s_nested.s_nested": false,
+			},
+		},
+		input: &pb3.Nests{SNested: &pb3.Nested{}},
+		want: `{
+  "sNested": {
+    "sString": ""
+  }
+}`,
+	}, {
+		desc:  "EmitUnpopulated: oneof",
+		mo:    protojson.MarshalOptions{EmitUnpopulated: true},
+		input: &pb3.Oneofs{},
+		want:  `{}`,
+	}, {
+		desc: "EmitUnpopulatedOneofs",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulated:       true,
+			EmitUnpopulatedOneofs: true,
+		},
+		input: &pb3.Oneofs{},
+		want: `{
+  "oneofEnum": null,
+  "oneofString": null,
+  "oneofNested": null
+}`,
+	}, {
+		desc: "EmitUnpopulatedOneofs with populated oneof",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulated:       true,
+			EmitUnpopulatedOneofs: true,
+		},
+		input: &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{}},
+		want: `{
+  "oneofString": ""
+}`,
+	}, {
+		desc: "EmitUnpopulatedFields with oneof field",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulatedFields: map[pref.FullName]bool{
+				"pb3.Oneofs.oneof_nested": true,
+			},
+		},
+		input: &pb3.Oneofs{},
+		want: `{
+  "oneofNested": null
+}`,
+	}, {
+		desc: "EmitUnpopulatedOneofs with OneofExternallyTagged",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulated:       true,
+			EmitUnpopulatedOneofs: true,
+			OneofStyle:            protojson.OneofExternallyTagged,
+		},
+		input: &pb3.Oneofs{},
+		want: `{
+  "union": null
+}`,
+	}, {
+		desc: "EmitUnpopulatedExtensions",
+		mo: protojson.MarshalOptions{
+			EmitUnpopulated:           true,
+			EmitUnpopulatedExtensions: true,
+			EmitUnpopulatedFields: map[pref.FullName]bool{
+				"pb2.opt_ext_string": false,
+			},
+			Resolver: func() *preg.Types {
+				r := new(preg.Types)
+				for _, xt := range []pref.ExtensionType{pb2.E_OptExtBool, pb2.E_OptExtString, pb2.E_RptExtEnum} {
+					if err := r.RegisterExtension(xt); err != nil {
+						panic(err)
+					}
+				}
+				return r
+			}(),
+		},
+		input: func() proto.Message {
+			m := &pb2.Extensions{}
+			proto.SetExtension(m, pb2.E_OptExtBool, true)
+			return m
+		}(),
+		want: `{
+  "optString": null,
+  "optBool": null,
+  "optInt32": null,
+  "[pb2.opt_ext_bool]": true,
+  "[pb2.rpt_ext_enum]": []
 }`,
 	}, {
 		desc: "EmitUnpopulated: proto2 required fields",
@@ -2163,6 +2405,275 @@ func TestMarshal(t *testing.T) {
       ]
     }
   ]
+}`,
+	}, {
+		desc: "custom marshaler",
+		mo: protojson.MarshalOptions{
+			Marshalers: map[pref.FullName]func(proto.Message) ([]byte, error){
+				"pb2.Nested": func(m proto.Message) ([]byte, error) {
+					return []byte(strconv.Quote(m.(*pb2.Nested).GetOptString())), nil
+				},
+			},
+		},
+		input: &pb2.Nests{
+			OptNested: &pb2.Nested{OptString: proto.String("one")},
+			RptNested: []*pb2.Nested{
+				{OptString: proto.String("two")},
+				{OptString: proto.String("three")},
+			},
+		},
+		want: `{
+  "optNested": "one",
+  "rptNested": [
+    "two",
+    "three"
+  ]
+}`,
+	}, {
+		desc: "custom marshaler in map",
+		mo: protojson.MarshalOptions{
+			Marshalers: map[pref.FullName]func(proto.Message) ([]byte, error){
+				"pb3.Nested": func(m proto.Message) ([]byte, error) {
+					return []byte(`[` + strconv.Quote(m.(*pb3.Nested).GetSString()) + `, {"x":1}]`), nil
+				},
+			},
+		},
+		input: &pb3.Maps{
+			StrToNested: map[string]*pb3.Nested{
+				"nested": {SString: "nested value"},
+			},
+		},
+		want: `{
+  "strToNested": {
+    "nested": [
+      "nested value",
+      {
+        "x": 1
+      }
+    ]
+  }
+}`,
+	}, {
+		desc: "custom marshaler overrides well-known type",
+		mo: protojson.MarshalOptions{
+			Marshalers: map[pref.FullName]func(proto.Message) ([]byte, error){
+				"google.protobuf.Duration": func(m proto.Message) ([]byte, error) {
+					return []byte(strconv.FormatInt(m.(*durationpb.Duration).GetSeconds(), 10)), nil
+				},
+			},
+		},
+		input: &pb2.KnownTypes{OptDuration: &durationpb.Duration{Seconds: 60}},
+		want: `{
+  "optDuration": 60
+}`,
+	}, {
+		desc: "custom marshaler in Any",
+		mo: protojson.MarshalOptions{
+			Marshalers: map[pref.FullName]func(proto.Message) ([]byte, error){
+				"pb2.Nested": func(m proto.Message) ([]byte, error) {
+					return []byte(strconv.Quote(m.(*pb2.Nested).GetOptString())), nil
+				},
+			},
+		},
+		input: func() proto.Message {
+			b, err := proto.Marshal(&pb2.Nested{OptString: proto.String("embedded")})
+			if err != nil {
+				t.Fatalf("error in binary marshaling message for Any.value: %v", err)
+			}
+			return &anypb.Any{TypeUrl: "pb2.Nested", Value: b}
+		}(),
+		want: `{
+  "@type": "pb2.Nested",
+  "value": "embedded"
+}`,
+	}, {
+		desc: "custom marshaler returns error",
+		mo: protojson.MarshalOptions{
+			Marshalers: map[pref.FullName]func(proto.Message) ([]byte, error){
+				"pb2.Nested": func(proto.Message) ([]byte, error) {
+					return nil, errors.New("failed")
+				},
+			},
+		},
+		input:   &pb2.Nests{OptNested: &pb2.Nested{}},
+		wantErr: true,
+	}, {
+		desc: "custom marshaler returns invalid JSON",
+		mo: protojson.MarshalOptions{
+			Marshalers: map[pref.FullName]func(proto.Message) ([]byte, error){
+				"pb2.Nested": func(proto.Message) ([]byte, error) {
+					return []byte(`{"x":`), nil
+				},
+			},
+		},
+		input:   &pb2.Nests{OptNested: &pb2.Nested{}},
+		wantErr: true,
+	}, {
+		desc: "UnknownStructField writes entries as members",
+		mo:   protojson.MarshalOptions{UnknownStructField: "opt_struct"},
+		input: &pb2.KnownTypes{
+			OptBool: &wrapperspb.BoolValue{Value: true},
+			OptStruct: &structpb.Struct{
+				Fields: map[string]*structpb.Value{
+					"zNew": {Kind: &structpb.Value_NumberValue{NumberValue: 1}},
+					"aNew": {Kind: &structpb.Value_StringValue{StringValue: "hello"}},
+				},
+			},
+		},
+		want: `{
+  "optBool": true,
+  "aNew": "hello",
+  "zNew": 1
+}`,
+	}, {
+		desc: "UnknownStructField ignored for non-Struct field",
+		mo:   protojson.MarshalOptions{UnknownStructField: "opt_bool"},
+		input: &pb2.KnownTypes{
+			OptBool: &wrapperspb.BoolValue{Value: true},
+		},
+		want: `{
+  "optBool": true
+}`,
+	}, {
+		desc: "Canonical sorts keys and omits whitespace",
+		mo:   protojson.MarshalOptions{Canonical: true},
+		input: &pb3.Maps{
+			Int32ToStr: map[int32]string{
+				10:  "ten",
+				-1:  "minus one",
+				2:   "two",
+				100: "hundred",
+			},
+			StrToNested: map[string]*pb3.Nested{
+				"b": {SString: "B"},
+				"a": {},
+			},
+		},
+		want: `{"int32ToStr":{"-1":"minus one","10":"ten","100":"hundred","2":"two"},"strToNested":{"a":{},"b":{"sString":"B"}}}`,
+	}, {
+		desc: "Canonical formats numbers as ECMAScript",
+		mo:   protojson.MarshalOptions{Canonical: true},
+		input: &pb2.Scalars{
+			OptInt64:  proto.Int64(1 << 60),
+			OptFloat:  proto.Float32(1.5),
+			OptDouble: proto.Float64(1e21),
+			OptUint32: proto.Uint32(0xffffffff),
+			OptString: proto.String("\u00e9\u2028<>"),
+		},
+		want: `{"optDouble":1e+21,"optFloat":1.5,"optInt64":"1152921504606846976","optString":"é` + "\u2028" + `<>","optUint32":4294967295}`,
+	}, {
+		desc: "UseRawNumeric",
+		mo:   protojson.MarshalOptions{UseRawNumeric: true},
+		input: &pb2.Scalars{
+			OptInt64:    proto.Int64(math.MinInt64),
+			OptUint64:   proto.Uint64(math.MaxUint64),
+			OptSint64:   proto.Int64(-1),
+			OptFixed64:  proto.Uint64(1 << 63),
+			OptSfixed64: proto.Int64(math.MaxInt64),
+		},
+		want: `{
+  "optInt64": -9223372036854775808,
+  "optUint64": 18446744073709551615,
+  "optSint64": -1,
+  "optFixed64": 9223372036854775808,
+  "optSfixed64": 9223372036854775807
+}`,
+	}, {
+		desc: "UseRawNumeric with QuoteImpreciseIntegers",
+		mo:   protojson.MarshalOptions{UseRawNumeric: true, QuoteImpreciseIntegers: true},
+		input: &pb2.Scalars{
+			OptInt64:    proto.Int64(-1 << 53),
+			OptUint64:   proto.Uint64(1<<53 + 1),
+			OptSint64:   proto.Int64(-1<<53 - 1),
+			OptFixed64:  proto.Uint64(1 << 53),
+			OptSfixed64: proto.Int64(math.MaxInt64),
+		},
+		want: `{
+  "optInt64": -9007199254740992,
+  "optUint64": "9007199254740993",
+  "optSint64": "-9007199254740993",
+  "optFixed64": 9007199254740992,
+  "optSfixed64": "9223372036854775807"
+}`,
+	}, {
+		desc: "UseRawNumeric in wrappers",
+		mo:   protojson.MarshalOptions{UseRawNumeric: true},
+		input: &pb2.KnownTypes{
+			OptInt64:  &wrapperspb.Int64Value{Value: -42},
+			OptUint64: &wrapperspb.UInt64Value{Value: math.MaxUint64},
+		},
+		want: `{
+  "optInt64": -42,
+  "optUint64": 18446744073709551615
+}`,
+	}, {
+		desc: "UseRawNumeric does not affect map keys",
+		mo:   protojson.MarshalOptions{UseRawNumeric: true},
+		input: &pb3.Maps{
+			Uint64ToEnum: map[uint64]pb3.Enum{
+				math.MaxUint64: pb3.Enum_TEN,
+			},
+		},
+		want: `{
+  "uint64ToEnum": {
+    "18446744073709551615": "TEN"
+  }
+}`,
+	}, {
+		desc: "FloatFixed",
+		mo:   protojson.MarshalOptions{FloatFormat: protojson.FloatFixed, FloatPrecision: 2},
+		input: &pb3.Scalars{
+			SFloat:  1.5,
+			SDouble: -1e21,
+		},
+		want: `{
+  "sFloat": 1.50,
+  "sDouble": -1000000000000000000000.00
+}`,
+	}, {
+		desc: "FloatSignificant",
+		mo:   protojson.MarshalOptions{FloatFormat: protojson.FloatSignificant, FloatPrecision: 3},
+		input: &pb3.Scalars{
+			SFloat:  3.14159,
+			SDouble: 1234567,
+		},
+		want: `{
+  "sFloat": 3.14,
+  "sDouble": 1.23e+06
+}`,
+	}, {
+		desc: "FloatFixed with special values",
+		mo:   protojson.MarshalOptions{FloatFormat: protojson.FloatFixed, FloatPrecision: 2},
+		input: &pb2.Scalars{
+			OptFloat:  proto.Float32(float32(math.NaN())),
+			OptDouble: proto.Float64(math.Inf(-1)),
+		},
+		want: `{
+  "optFloat": "NaN",
+  "optDouble": "-Infinity"
+}`,
+	}, {
+		desc: "BytesURLBase64",
+		mo:   protojson.MarshalOptions{BytesEncoding: protojson.BytesURLBase64},
+		input: &pb3.Scalars{
+			SBytes: []byte{0xfb, 0xff, 0x01},
+		},
+		want: `{
+  "sBytes": "-_8B"
+}`,
+	}, {
+		desc:  "BytesURLBase64 without padding",
+		mo:    protojson.MarshalOptions{BytesEncoding: protojson.BytesURLBase64},
+		input: &wrapperspb.BytesValue{Value: []byte("hello")},
+		want:  `"aGVsbG8"`,
+	}, {
+		desc: "BytesHex",
+		mo:   protojson.MarshalOptions{BytesEncoding: protojson.BytesHex},
+		input: &pb3.Scalars{
+			SBytes: []byte{0xde, 0xad, 0xbe, 0xef},
+		},
+		want: `{
+  "sBytes": "deadbeef"
 }`,
 	}}
 
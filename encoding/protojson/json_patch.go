@@ -0,0 +1,527 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protojson
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/internal/encoding/json"
+	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ApplyPatch applies the given JSON Patch document to m using default options.
+// See UnmarshalOptions.ApplyPatch.
+func ApplyPatch(patch []byte, m proto.Message) error {
+	return UnmarshalOptions{}.ApplyPatch(patch, m)
+}
+
+// ApplyPatch applies the given JSON Patch document (RFC 6902) to m.
+//
+// The JSON pointers in the "path" and "from" members of operations are
+// resolved through the descriptor of m: tokens name the fields of a message by
+// JSON or proto name, or its extensions as "[full.name]", index the elements
+// of repeated fields, where "-" refers past the last element, and select the
+// entries of map fields by key. Every field of a message is considered to
+// exist, with unpopulated fields having their default value, such that
+// "remove" clears a field. Pointers may only traverse populated message
+// fields, list elements and map entries of message types that are represented
+// as JSON objects of their fields.
+//
+// Operation values are decoded according to the type of their target location
+// as by Unmarshal, such that values not valid for the schema are rejected.
+// Values of "move" and "copy" operations are encoded in JSON and decoded at
+// their target location, which must therefore be of a compatible type.
+// The "test" operation compares the decoded value with the target value.
+//
+// The operations are applied atomically: if ApplyPatch returns an error,
+// m is left unchanged.
+func (o UnmarshalOptions) ApplyPatch(patch []byte, m proto.Message) error {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	newDecoder := json.NewDecoder
+	if o.RelaxedSyntax {
+		newDecoder = json.NewRelaxedDecoder
+	}
+	dec := decoder{newDecoder(patch), o, new(decodePath)}
+	ops, err := dec.readPatchOps()
+	if err != nil {
+		return err
+	}
+
+	// Apply the operations to a copy so that m is unchanged on failure.
+	target := proto.Clone(m)
+	for i, op := range ops {
+		if err := dec.applyPatchOp(target.ProtoReflect(), op); err != nil {
+			if e, ok := err.(*UnmarshalError); ok {
+				err = e.err
+			}
+			return errors.New("patch operation %d (%s %s): %v", i, op.op, op.path, err)
+		}
+	}
+	if !o.AllowPartial {
+		if err := proto.CheckInitialized(target); err != nil {
+			return err
+		}
+	}
+
+	proto.Reset(m)
+	proto.Merge(m, target)
+	return nil
+}
+
+// patchOp is a JSON Patch operation.
+type patchOp struct {
+	op, path, from string
+
+	// value is a decoder positioned at the "value" member, or nil if absent.
+	value *decoder
+}
+
+// readPatchOps reads the array of JSON Patch operations.
+func (d decoder) readPatchOps() ([]patchOp, error) {
+	tok, err := d.Read()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Kind() != json.ArrayOpen {
+		return nil, d.unexpectedTokenError(tok)
+	}
+
+	var ops []patchOp
+	for {
+		start, err := d.Read()
+		if err != nil {
+			return nil, err
+		}
+		switch start.Kind() {
+		case json.ArrayClose:
+			// Check for EOF.
+			tok, err := d.Read()
+			if err != nil {
+				return nil, err
+			}
+			if tok.Kind() != json.EOF {
+				return nil, d.syntaxError(tok.Pos(), "unexpected token %s", tok.RawString())
+			}
+			return ops, nil
+		case json.ObjectOpen:
+			// Continue below.
+		default:
+			return nil, d.unexpectedTokenError(start)
+		}
+
+		var op patchOp
+		var hasPath, hasFrom bool
+		seen := make(map[string]bool)
+	Loop:
+		for {
+			tok, err := d.Read()
+			if err != nil {
+				return nil, err
+			}
+			switch tok.Kind() {
+			case json.ObjectClose:
+				break Loop
+			case json.Name:
+				// Continue below.
+			default:
+				return nil, d.unexpectedTokenError(tok)
+			}
+
+			name := tok.Name()
+			if seen[name] {
+				return nil, d.newError(tok.Pos(), "duplicate member %v", tok.RawString())
+			}
+			seen[name] = true
+			switch name {
+			case "op", "path", "from":
+				val, err := d.Read()
+				if err != nil {
+					return nil, err
+				}
+				if val.Kind() != json.String {
+					return nil, d.unexpectedTokenError(val)
+				}
+				switch name {
+				case "op":
+					op.op = val.ParsedString()
+				case "path":
+					op.path, hasPath = val.ParsedString(), true
+				case "from":
+					op.from, hasFrom = val.ParsedString(), true
+				}
+			case "value":
+				op.value = &decoder{d.Clone(), d.opts, new(decodePath)}
+				fallthrough
+			default:
+				// Members other than those of the operation are ignored.
+				if err := d.skipJSONValue(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		switch op.op {
+		case "add", "replace", "test":
+			if op.value == nil {
+				return nil, d.newError(start.Pos(), "%s operation is missing value", op.op)
+			}
+		case "move", "copy":
+			if !hasFrom {
+				return nil, d.newError(start.Pos(), "%s operation is missing from", op.op)
+			}
+		case "remove":
+		case "":
+			return nil, d.newError(start.Pos(), "operation is missing op")
+		default:
+			return nil, d.newError(start.Pos(), "invalid operation %q", op.op)
+		}
+		if !hasPath {
+			return nil, d.newError(start.Pos(), "%s operation is missing path", op.op)
+		}
+		ops = append(ops, op)
+	}
+}
+
+// applyPatchOp applies the operation op to m.
+func (d decoder) applyPatchOp(m pref.Message, op patchOp) error {
+	loc, err := d.resolvePointer(m, op.path)
+	if err != nil {
+		return err
+	}
+
+	switch op.op {
+	case "add":
+		return op.value.setPatchValue(loc, true)
+
+	case "remove":
+		return loc.remove()
+
+	case "replace":
+		if !loc.exists() {
+			return errors.New("path does not exist")
+		}
+		return op.value.setPatchValue(loc, false)
+
+	case "move", "copy":
+		if op.op == "move" && op.from == op.path {
+			return nil
+		}
+		if op.op == "move" && strings.HasPrefix(op.path, op.from+"/") {
+			return errors.New("cannot move %s into itself", op.from)
+		}
+		from, err := d.resolvePointer(m, op.from)
+		if err != nil {
+			return err
+		}
+		b, err := d.marshalPatchValue(from)
+		if err != nil {
+			return err
+		}
+		if op.op == "move" {
+			if err := from.remove(); err != nil {
+				return err
+			}
+			// Resolve the path again since the removal may have shifted
+			// list elements.
+			if loc, err = d.resolvePointer(m, op.path); err != nil {
+				return err
+			}
+		}
+		value := decoder{json.NewDecoder(b), d.opts, new(decodePath)}
+		return value.setPatchValue(loc, true)
+
+	case "test":
+		if !loc.exists() {
+			return errors.New("path does not exist")
+		}
+		// Decode the value into a new message to compare with the target.
+		want := m.New()
+		wantLoc := patchLoc{m: want, fd: loc.fd}
+		if loc.list != nil {
+			wantLoc.list, wantLoc.index = want.Mutable(loc.fd).List(), 0
+		}
+		if loc.mmap != nil {
+			wantLoc.mmap, wantLoc.key = want.Mutable(loc.fd).Map(), loc.key
+		}
+		if err := op.value.setPatchValue(wantLoc, true); err != nil {
+			return err
+		}
+		if !equalPatchValue(loc.fd, loc.get(), wantLoc.get()) {
+			return errors.New("test failed")
+		}
+		return nil
+	}
+	return nil
+}
+
+// patchLoc is a location in a message addressed by a JSON pointer.
+type patchLoc struct {
+	m  pref.Message // message containing the field
+	fd pref.FieldDescriptor
+
+	list  pref.List // if non-nil, the location is the element at index
+	index int
+
+	mmap pref.Map // if non-nil, the location is the entry for key
+	key  pref.MapKey
+}
+
+// resolvePointer resolves the given JSON pointer in m.
+func (d decoder) resolvePointer(m pref.Message, ptr string) (patchLoc, error) {
+	if ptr == "" {
+		return patchLoc{}, errors.New("cannot operate on the root message")
+	}
+	if ptr[0] != '/' {
+		return patchLoc{}, errors.New("invalid JSON pointer %q", ptr)
+	}
+	toks := strings.Split(ptr[1:], "/")
+	for i, tok := range toks {
+		toks[i] = strings.Replace(strings.Replace(tok, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	for i := 0; ; {
+		fd, err := d.findField(m.Descriptor(), toks[i], 0)
+		if err != nil {
+			return patchLoc{}, err
+		}
+		if fd == nil {
+			return patchLoc{}, errors.New("unknown field %q in %v", toks[i], m.Descriptor().FullName())
+		}
+		i++
+		loc := patchLoc{m: m, fd: fd}
+		if i < len(toks) {
+			switch {
+			case fd.IsList():
+				loc.list = m.Mutable(fd).List()
+				if toks[i] == "-" {
+					loc.index = loc.list.Len()
+				} else if n, err := strconv.ParseUint(toks[i], 10, 31); err == nil && (toks[i] == "0" || toks[i][0] != '0') && int(n) <= loc.list.Len() {
+					loc.index = int(n)
+				} else {
+					return patchLoc{}, errors.New("invalid index %q for %v", toks[i], fd.FullName())
+				}
+				i++
+			case fd.IsMap():
+				key, ok := parseMapKey(toks[i], fd.MapKey())
+				if !ok {
+					return patchLoc{}, errors.New("invalid key %q for %v", toks[i], fd.FullName())
+				}
+				loc.mmap, loc.key = m.Mutable(fd).Map(), key
+				i++
+			}
+		}
+		if i == len(toks) {
+			return loc, nil
+		}
+
+		// Descend into the message at the location.
+		md := fd.Message()
+		if fd.IsMap() {
+			md = fd.MapValue().Message()
+		}
+		if md == nil || d.isCustomType(md.FullName()) || fd.IsList() && loc.list == nil || fd.IsMap() && loc.mmap == nil {
+			return patchLoc{}, errors.New("cannot address into %v", fd.FullName())
+		}
+		if !loc.exists() || loc.list == nil && loc.mmap == nil && !m.Has(fd) {
+			return patchLoc{}, errors.New("path %s does not exist", "/"+strings.Join(toks[:i], "/"))
+		}
+		switch {
+		case loc.list != nil:
+			m = loc.list.Get(loc.index).Message()
+		case loc.mmap != nil:
+			m = loc.mmap.Mutable(loc.key).Message()
+		default:
+			m = m.Mutable(fd).Message()
+		}
+	}
+}
+
+// exists reports whether the location exists. Fields always exist, list
+// elements exist for every index below the length of the list and map
+// entries exist if present. The end of a list, addressed by "-" or by the
+// length of the list, is only a valid location for an "add" operation.
+func (l patchLoc) exists() bool {
+	switch {
+	case l.list != nil:
+		return l.index < l.list.Len()
+	case l.mmap != nil:
+		return l.mmap.Has(l.key)
+	}
+	return true
+}
+
+// get returns the value at the location, which must exist.
+func (l patchLoc) get() pref.Value {
+	switch {
+	case l.list != nil:
+		return l.list.Get(l.index)
+	case l.mmap != nil:
+		return l.mmap.Get(l.key)
+	}
+	return l.m.Get(l.fd)
+}
+
+// remove removes the value at the location.
+func (l patchLoc) remove() error {
+	switch {
+	case l.list != nil:
+		n := l.list.Len()
+		if l.index >= n {
+			return errors.New("path does not exist")
+		}
+		for i := l.index; i < n-1; i++ {
+			l.list.Set(i, l.list.Get(i+1))
+		}
+		l.list.Truncate(n - 1)
+	case l.mmap != nil:
+		if !l.mmap.Has(l.key) {
+			return errors.New("path does not exist")
+		}
+		l.mmap.Clear(l.key)
+	default:
+		l.m.Clear(l.fd)
+	}
+	return nil
+}
+
+// setPatchValue decodes the JSON value that d is positioned at and stores it
+// at the location. For list elements, the value is inserted if insert is set
+// and otherwise replaces the element.
+func (d decoder) setPatchValue(l patchLoc, insert bool) error {
+	switch {
+	case l.list != nil:
+		val, err := d.unmarshalPatchElement(l.fd, l.list.NewElement)
+		if err != nil {
+			return err
+		}
+		n := l.list.Len()
+		if !insert {
+			l.list.Set(l.index, val)
+			return nil
+		}
+		l.list.Append(val)
+		for i := n; i > l.index; i-- {
+			l.list.Set(i, l.list.Get(i-1))
+		}
+		l.list.Set(l.index, val)
+
+	case l.mmap != nil:
+		val, err := d.unmarshalPatchElement(l.fd.MapValue(), l.mmap.NewValue)
+		if err != nil {
+			return err
+		}
+		l.mmap.Set(l.key, val)
+
+	default:
+		l.m.Clear(l.fd)
+		tok, err := d.Peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case tok.Kind() == json.Null && !isKnownValue(l.fd) && !isNullValue(l.fd):
+			d.Read()
+		case l.fd.IsList():
+			return d.unmarshalList(l.m.Mutable(l.fd).List(), l.fd)
+		case l.fd.IsMap():
+			return d.unmarshalMap(l.m.Mutable(l.fd).Map(), l.fd)
+		default:
+			return d.unmarshalSingular(l.m, l.fd)
+		}
+	}
+	return nil
+}
+
+// unmarshalPatchElement decodes a list element or map value of type fd.
+func (d decoder) unmarshalPatchElement(fd pref.FieldDescriptor, newValue func() pref.Value) (pref.Value, error) {
+	if fd.Message() == nil {
+		return d.unmarshalScalar(fd)
+	}
+	val := newValue()
+	if err := d.unmarshalMessage(val.Message(), ""); err != nil {
+		return pref.Value{}, err
+	}
+	return val, nil
+}
+
+// marshalPatchValue returns the JSON encoding of the value at the location,
+// which must exist.
+func (d decoder) marshalPatchValue(l patchLoc) ([]byte, error) {
+	if !l.exists() {
+		return nil, errors.New("from path does not exist")
+	}
+	internalEnc, err := json.NewEncoder("")
+	if err != nil {
+		return nil, err
+	}
+	e := encoder{internalEnc, MarshalOptions{
+		Resolver:            d.opts.Resolver,
+		AnyFallbackResolver: d.opts.AnyFallbackResolver,
+		EmitUnresolvableAny: d.opts.AllowUnresolvableAny,
+	}, nil}
+	switch {
+	case l.list != nil:
+		err = e.marshalSingular(l.get(), l.fd)
+	case l.mmap != nil:
+		err = e.marshalSingular(l.get(), l.fd.MapValue())
+	case l.fd.Message() != nil && !l.fd.IsList() && !l.fd.IsMap() && !l.m.Has(l.fd):
+		e.WriteNull()
+	default:
+		err = e.marshalValue(l.get(), l.fd)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+// equalPatchValue reports whether the values x and y of the field fd are
+// equal.
+func equalPatchValue(fd pref.FieldDescriptor, x, y pref.Value) bool {
+	switch {
+	case fd.IsList() && x.Interface() != nil:
+		if _, ok := x.Interface().(pref.List); !ok {
+			break // list element
+		}
+		lx, ly := x.List(), y.List()
+		if lx.Len() != ly.Len() {
+			return false
+		}
+		for i := 0; i < lx.Len(); i++ {
+			if !equalPatchValue(fd, lx.Get(i), ly.Get(i)) {
+				return false
+			}
+		}
+		return true
+	case fd.IsMap():
+		if _, ok := x.Interface().(pref.Map); !ok {
+			return equalPatchValue(fd.MapValue(), x, y)
+		}
+		mx, my := x.Map(), y.Map()
+		if mx.Len() != my.Len() {
+			return false
+		}
+		equal := true
+		mx.Range(func(k pref.MapKey, v pref.Value) bool {
+			equal = my.Has(k) && equalPatchValue(fd.MapValue(), v, my.Get(k))
+			return equal
+		})
+		return equal
+	}
+	switch v := x.Interface().(type) {
+	case pref.Message:
+		return proto.Equal(v.Interface(), y.Message().Interface())
+	case []byte:
+		return string(v) == string(y.Bytes())
+	}
+	return x.Interface() == y.Interface()
+}
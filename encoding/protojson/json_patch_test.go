@@ -0,0 +1,200 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protojson_test
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
+	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
+)
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		desc    string
+		umo     protojson.UnmarshalOptions
+		input   proto.Message
+		patch   string
+		want    proto.Message
+		wantErr string // Expected error substring.
+	}{{
+		desc:  "empty patch",
+		input: &pb3.Nested{SString: "a"},
+		patch: `[]`,
+		want:  &pb3.Nested{SString: "a"},
+	}, {
+		desc:  "add and replace scalar fields",
+		input: &pb3.Scalars{SInt32: 1},
+		patch: `[
+  {"op": "add", "path": "/sString", "value": "a"},
+  {"op": "replace", "path": "/s_int32", "value": 2}
+]`,
+		want: &pb3.Scalars{SInt32: 2, SString: "a"},
+	}, {
+		desc:  "remove clears field",
+		input: &pb3.Scalars{SInt32: 1, SString: "a"},
+		patch: `[{"op": "remove", "path": "/sString"}]`,
+		want:  &pb3.Scalars{SInt32: 1},
+	}, {
+		desc:  "nested message field",
+		input: &pb3.Nested{SNested: &pb3.Nested{SString: "a"}},
+		patch: `[{"op": "replace", "path": "/sNested/sString", "value": "b"}]`,
+		want:  &pb3.Nested{SNested: &pb3.Nested{SString: "b"}},
+	}, {
+		desc:  "add message field",
+		input: &pb3.Nested{},
+		patch: `[{"op": "add", "path": "/sNested", "value": {"sString": "a"}}]`,
+		want:  &pb3.Nested{SNested: &pb3.Nested{SString: "a"}},
+	}, {
+		desc:  "list insert, append and remove",
+		input: &pb2.Repeats{RptString: []string{"a", "c"}},
+		patch: `[
+  {"op": "add", "path": "/rptString/1", "value": "b"},
+  {"op": "add", "path": "/rptString/-", "value": "d"},
+  {"op": "remove", "path": "/rptString/0"}
+]`,
+		want: &pb2.Repeats{RptString: []string{"b", "c", "d"}},
+	}, {
+		desc: "list element of message type",
+		input: &pb2.Nests{
+			RptNested: []*pb2.Nested{{OptString: proto.String("a")}, {}},
+		},
+		patch: `[
+  {"op": "replace", "path": "/rptNested/1", "value": {"optString": "b"}},
+  {"op": "add", "path": "/rptNested/0/optNested", "value": {}}
+]`,
+		want: &pb2.Nests{
+			RptNested: []*pb2.Nested{
+				{OptString: proto.String("a"), OptNested: &pb2.Nested{}},
+				{OptString: proto.String("b")},
+			},
+		},
+	}, {
+		desc: "map entries",
+		input: &pb3.Maps{
+			Int32ToStr:  map[int32]string{1: "one", 2: "two"},
+			StrToNested: map[string]*pb3.Nested{"a/b": {SString: "a"}},
+		},
+		patch: `[
+  {"op": "add", "path": "/int32ToStr/3", "value": "three"},
+  {"op": "remove", "path": "/int32ToStr/1"},
+  {"op": "replace", "path": "/strToNested/a~1b/sString", "value": "b"}
+]`,
+		want: &pb3.Maps{
+			Int32ToStr:  map[int32]string{2: "two", 3: "three"},
+			StrToNested: map[string]*pb3.Nested{"a/b": {SString: "b"}},
+		},
+	}, {
+		desc:  "move and copy",
+		input: &pb2.Repeats{RptString: []string{"a", "b"}},
+		patch: `[
+  {"op": "move", "from": "/rptString/0", "path": "/rptString/-"},
+  {"op": "copy", "from": "/rptString", "path": "/rpt_string"}
+]`,
+		want: &pb2.Repeats{RptString: []string{"b", "a"}},
+	}, {
+		desc:  "move between fields",
+		input: &pb3.Nested{SNested: &pb3.Nested{SString: "a"}},
+		patch: `[{"op": "move", "from": "/sNested/sString", "path": "/sString"}]`,
+		want:  &pb3.Nested{SString: "a", SNested: &pb3.Nested{}},
+	}, {
+		desc:  "test succeeds",
+		input: &pb3.Nested{SString: "a", SNested: &pb3.Nested{}},
+		patch: `[
+  {"op": "test", "path": "/sString", "value": "a"},
+  {"op": "test", "path": "/sNested", "value": {}},
+  {"op": "add", "path": "/sString", "value": "b"}
+]`,
+		want: &pb3.Nested{SString: "b", SNested: &pb3.Nested{}},
+	}, {
+		desc:    "test fails",
+		input:   &pb3.Nested{SString: "a"},
+		patch:   `[{"op": "add", "path": "/sString", "value": "b"}, {"op": "test", "path": "/sString", "value": "a"}]`,
+		wantErr: `patch operation 1 (test /sString): test failed`,
+	}, {
+		desc:    "invalid value for schema",
+		input:   &pb3.Nested{},
+		patch:   `[{"op": "add", "path": "/sString", "value": 1}]`,
+		wantErr: `invalid value for string type: 1`,
+	}, {
+		desc:    "unknown field",
+		input:   &pb3.Nested{},
+		patch:   `[{"op": "add", "path": "/unknown", "value": 1}]`,
+		wantErr: `unknown field "unknown"`,
+	}, {
+		desc:    "index out of range",
+		input:   &pb2.Repeats{RptString: []string{"a"}},
+		patch:   `[{"op": "add", "path": "/rptString/2", "value": "b"}]`,
+		wantErr: `invalid index "2"`,
+	}, {
+		desc:    "replace missing map entry",
+		input:   &pb3.Maps{},
+		patch:   `[{"op": "replace", "path": "/int32ToStr/1", "value": "one"}]`,
+		wantErr: `path does not exist`,
+	}, {
+		desc:    "test end of list",
+		input:   &pb2.Repeats{RptString: []string{"a"}},
+		patch:   `[{"op": "test", "path": "/rptString/-", "value": "a"}]`,
+		wantErr: `path does not exist`,
+	}, {
+		desc:    "test first element of empty list",
+		input:   &pb2.Repeats{},
+		patch:   `[{"op": "test", "path": "/rptString/0", "value": "a"}]`,
+		wantErr: `path does not exist`,
+	}, {
+		desc:    "move into itself",
+		input:   &pb3.Nested{SNested: &pb3.Nested{}},
+		patch:   `[{"op": "move", "from": "/sNested", "path": "/sNested/sNested"}]`,
+		wantErr: `cannot move /sNested into itself`,
+	}, {
+		desc:    "missing value",
+		input:   &pb3.Nested{},
+		patch:   `[{"op": "add", "path": "/sString"}]`,
+		wantErr: `add operation is missing value`,
+	}, {
+		desc:    "invalid operation",
+		input:   &pb3.Nested{},
+		patch:   `[{"op": "merge", "path": "/sString", "value": "a"}]`,
+		wantErr: `invalid operation "merge"`,
+	}, {
+		desc:    "missing required field",
+		input:   &pb2.PartialRequired{ReqString: proto.String("a")},
+		patch:   `[{"op": "remove", "path": "/reqString"}]`,
+		wantErr: `required field pb2.PartialRequired.req_string not set`,
+	}, {
+		desc:  "AllowPartial",
+		umo:   protojson.UnmarshalOptions{AllowPartial: true},
+		input: &pb2.PartialRequired{ReqString: proto.String("a")},
+		patch: `[{"op": "remove", "path": "/reqString"}]`,
+		want:  &pb2.PartialRequired{},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			original := proto.Clone(tt.input)
+			err := tt.umo.ApplyPatch([]byte(tt.patch), tt.input)
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ApplyPatch() error got %v, want %q", err, tt.wantErr)
+				}
+				if !proto.Equal(tt.input, original) {
+					t.Errorf("ApplyPatch() modified message on error\n<got>\n%v\n<want>\n%v", tt.input, original)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("ApplyPatch() got nil error, want error %q", tt.wantErr)
+			}
+			if !proto.Equal(tt.input, tt.want) {
+				t.Errorf("ApplyPatch()\n<got>\n%v\n<want>\n%v", tt.input, tt.want)
+			}
+		})
+	}
+}
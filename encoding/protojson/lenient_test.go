@@ -0,0 +1,134 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protojson_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/internal/encoding/wire"
+	"google.golang.org/protobuf/proto"
+
+	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
+)
+
+func TestLenientMatching(t *testing.T) {
+	tests := []struct {
+		desc        string
+		umo         protojson.UnmarshalOptions
+		input       string
+		want        string // Expected message in text format.
+		wantUnknown []byte
+		wantErr     string // Expected error substring.
+	}{{
+		desc:    "case-folded enum name rejected by default",
+		input:   `{"color": "color_red"}`,
+		wantErr: `invalid value for enum type: "color_red"`,
+	}, {
+		desc:  "CaseInsensitiveEnums",
+		umo:   protojson.UnmarshalOptions{CaseInsensitiveEnums: true},
+		input: `{"color": "color_red", "colors": ["Color_Dark_Blue", "COLOR_RED"]}`,
+		want:  `color: COLOR_RED colors: [COLOR_DARK_BLUE, COLOR_RED]`,
+	}, {
+		desc:    "CaseInsensitiveEnums with conflicting values",
+		umo:     protojson.UnmarshalOptions{CaseInsensitiveEnums: true},
+		input:   `{"mode": "On"}`,
+		wantErr: `enum value "On" of pb2.Mode is ambiguous: matches ON and on`,
+	}, {
+		desc:  "CaseInsensitiveEnums prefers exact match",
+		umo:   protojson.UnmarshalOptions{CaseInsensitiveEnums: true},
+		input: `{"mode": "on"}`,
+		want:  `mode: on`,
+	}, {
+		desc:  "TrimEnumPrefix",
+		umo:   protojson.UnmarshalOptions{TrimEnumPrefix: true},
+		input: `{"color": "DARK_BLUE", "byName": {"a": "RED", "b": "COLOR_UNSPECIFIED"}}`,
+		want:  `color: COLOR_DARK_BLUE by_name: {key: "a" value: COLOR_RED} by_name: {key: "b" value: COLOR_UNSPECIFIED}`,
+	}, {
+		desc:    "TrimEnumPrefix is case-sensitive",
+		umo:     protojson.UnmarshalOptions{TrimEnumPrefix: true},
+		input:   `{"color": "red"}`,
+		wantErr: `invalid value for enum type: "red"`,
+	}, {
+		desc:  "TrimEnumPrefix and CaseInsensitiveEnums",
+		umo:   protojson.UnmarshalOptions{TrimEnumPrefix: true, CaseInsensitiveEnums: true},
+		input: `{"color": "dark_blue"}`,
+		want:  `color: COLOR_DARK_BLUE`,
+	}, {
+		desc:  "undefined numeric value of closed enum",
+		input: `{"color": 5}`,
+		want:  `color: 5`,
+	}, {
+		desc:  "ClosedEnumUnknownFields",
+		umo:   protojson.UnmarshalOptions{ClosedEnumUnknownFields: true},
+		input: `{"color": 5, "colors": [1, 6, 2], "byName": {"a": 1, "b": 7}, "size": 3}`,
+		want:  `colors: [COLOR_RED, COLOR_DARK_BLUE] by_name: {key: "a" value: COLOR_RED} size: 3`,
+		wantUnknown: func() []byte {
+			var b []byte
+			b = wire.AppendTag(b, 1, wire.VarintType)
+			b = wire.AppendVarint(b, 5)
+			b = wire.AppendTag(b, 2, wire.VarintType)
+			b = wire.AppendVarint(b, 6)
+			var entry []byte
+			entry = wire.AppendTag(entry, 1, wire.BytesType)
+			entry = wire.AppendString(entry, "b")
+			entry = wire.AppendTag(entry, 2, wire.VarintType)
+			entry = wire.AppendVarint(entry, 7)
+			b = wire.AppendTag(b, 3, wire.BytesType)
+			b = wire.AppendBytes(b, entry)
+			return b
+		}(),
+	}, {
+		desc:    "case-folded field name rejected by default",
+		input:   `{"SIZE": 1}`,
+		wantErr: `unknown field "SIZE"`,
+	}, {
+		desc:  "CaseInsensitiveFieldNames",
+		umo:   protojson.UnmarshalOptions{CaseInsensitiveFieldNames: true},
+		input: `{"SIZE": 1, "Foo_Bar": "x", "foobar": "y"}`,
+		want:  `size: 1 foo_bar: "x" foobar: "y"`,
+	}, {
+		desc:    "CaseInsensitiveFieldNames with conflicting fields",
+		umo:     protojson.UnmarshalOptions{CaseInsensitiveFieldNames: true},
+		input:   `{"FooBar": "x"}`,
+		wantErr: `field name "FooBar" of pb2.Paint is ambiguous: matches foo_bar and foobar`,
+	}, {
+		desc:    "CaseInsensitiveFieldNames with duplicate field",
+		umo:     protojson.UnmarshalOptions{CaseInsensitiveFieldNames: true},
+		input:   `{"size": 1, "Size": 2}`,
+		wantErr: `duplicate field "Size"`,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got := &pb2.Paint{}
+			err := tt.umo.Unmarshal([]byte(tt.input), got)
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Unmarshal() error got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("Unmarshal() got nil error, want error %q", tt.wantErr)
+			}
+			want := &pb2.Paint{}
+			if err := prototext.Unmarshal([]byte(tt.want), want); err != nil {
+				t.Fatalf("prototext.Unmarshal() error: %v", err)
+			}
+			want.ProtoReflect().SetUnknown(tt.wantUnknown)
+			if !proto.Equal(got, want) {
+				t.Errorf("Unmarshal()\n<got>\n%v\n<want>\n%v", got, want)
+			}
+			if !bytes.Equal(got.ProtoReflect().GetUnknown(), tt.wantUnknown) {
+				t.Errorf("Unmarshal() unknown fields got %x, want %x", got.ProtoReflect().GetUnknown(), tt.wantUnknown)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protojson
+
+import (
+	"google.golang.org/protobuf/internal/encoding/json"
+	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/internal/fieldnum"
+	"google.golang.org/protobuf/internal/set"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ApplyMergePatch applies the given JSON Merge Patch document to m using
+// default options. See UnmarshalOptions.ApplyMergePatch.
+func ApplyMergePatch(patch []byte, m, mask proto.Message) error {
+	return UnmarshalOptions{}.ApplyMergePatch(patch, m, mask)
+}
+
+// ApplyMergePatch applies the given JSON Merge Patch document (RFC 7396) to m.
+// The members of the patch are interpreted according to the descriptor of m:
+//
+// • null clears a field, or deletes an entry of a map field.
+//
+// • An object patches a singular message field recursively, populating it if
+// unset, and patches the entries of a map field, where an existing entry of
+// message type is itself patched recursively.
+//
+// • An object patches a google.protobuf.Struct field, or a
+// google.protobuf.Value field holding a Struct, as the JSON object that it
+// represents: null deletes a member, an object patches a member recursively
+// and any other value replaces a member. A Value field holding anything else
+// is replaced by the patched empty object.
+//
+// • Any other value replaces the field, such that arrays replace all elements
+// of repeated fields and other well-known types with a special JSON
+// representation are replaced as a whole.
+//
+// Values are otherwise decoded as by Unmarshal. Oneofs are always addressed by
+// their fields, regardless of OneofStyle.
+//
+// If mask is non-nil, it must be a google.protobuf.FieldMask message, to
+// which the paths of the fields touched by the patch are appended, using
+// proto field names. Map fields are touched as a whole.
+// If ApplyMergePatch returns an error, m and mask are left unchanged.
+func (o UnmarshalOptions) ApplyMergePatch(patch []byte, m, mask proto.Message) error {
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+	var maskPaths pref.List
+	if mask != nil {
+		mr := mask.ProtoReflect()
+		if mr.Descriptor().FullName() != "google.protobuf.FieldMask" {
+			return errors.New("mask is %v, not google.protobuf.FieldMask", mr.Descriptor().FullName())
+		}
+		maskPaths = mr.Mutable(mr.Descriptor().Fields().ByNumber(1)).List()
+	}
+
+	newDecoder := json.NewDecoder
+	if o.RelaxedSyntax {
+		newDecoder = json.NewRelaxedDecoder
+	}
+	dec := decoder{newDecoder(patch), o, new(decodePath)}
+
+	// Apply the patch to a copy so that m is unchanged on failure.
+	target := proto.Clone(m)
+	tok, err := dec.Peek()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != json.ObjectOpen || dec.isCustomType(target.ProtoReflect().Descriptor().FullName()) {
+		return dec.newError(tok.Pos(), "merge patch for %v must be a JSON object", target.ProtoReflect().Descriptor().FullName())
+	}
+	var paths []string
+	if err := dec.applyMergePatch(target.ProtoReflect(), "", &paths); err != nil {
+		return err
+	}
+
+	// Check for EOF.
+	tok, err = dec.Read()
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != json.EOF {
+		return dec.syntaxError(tok.Pos(), "unexpected token %s", tok.RawString())
+	}
+	if !o.AllowPartial {
+		if err := proto.CheckInitialized(target); err != nil {
+			return err
+		}
+	}
+
+	proto.Reset(m)
+	proto.Merge(m, target)
+	if maskPaths != nil {
+		for _, p := range paths {
+			maskPaths.Append(pref.ValueOfString(p))
+		}
+	}
+	return nil
+}
+
+// applyMergePatch applies the merge patch object that is next in the input to
+// m, appending the paths of touched fields, relative to prefix, to paths.
+func (d decoder) applyMergePatch(m pref.Message, prefix string, paths *[]string) error {
+	d.Read() // Read json.ObjectOpen.
+
+	var seenNums set.Ints
+	messageDesc := m.Descriptor()
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind() {
+		default:
+			return d.unexpectedTokenError(tok)
+		case json.ObjectClose:
+			return nil
+		case json.Name:
+			// Continue below.
+		}
+
+		fd, err := d.findField(messageDesc, tok.Name(), tok.Pos())
+		if err != nil {
+			return err
+		}
+		d.push(tok.Name(), fd)
+		if fd == nil {
+			if d.opts.DiscardUnknown {
+				if err := d.skipJSONValue(); err != nil {
+					return err
+				}
+				d.pop()
+				continue
+			}
+			return d.unknownFieldError(tok)
+		}
+
+		// Do not allow duplicate fields.
+		num := uint64(fd.Number())
+		if seenNums.Has(num) {
+			return d.newError(tok.Pos(), "duplicate field %v", tok.RawString())
+		}
+		seenNums.Set(num)
+
+		path := patchPath(prefix, fd)
+		next, err := d.Peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case next.Kind() == json.Null:
+			d.Read()
+			m.Clear(fd)
+			*paths = append(*paths, path)
+
+		case next.Kind() == json.ObjectOpen && fd.IsMap():
+			if err := d.applyMapPatch(m.Mutable(fd).Map(), fd); err != nil {
+				return err
+			}
+			*paths = append(*paths, path)
+
+		case next.Kind() == json.ObjectOpen && d.isStructPatchable(fd):
+			if err := d.applyStructPatch(m.Mutable(fd).Message()); err != nil {
+				return err
+			}
+			*paths = append(*paths, path)
+
+		case next.Kind() == json.ObjectOpen && d.isPatchableMessage(fd):
+			n := len(*paths)
+			if err := d.applyMergePatch(m.Mutable(fd).Message(), path, paths); err != nil {
+				return err
+			}
+			if len(*paths) == n {
+				*paths = append(*paths, path)
+			}
+
+		default:
+			m.Clear(fd)
+			var err error
+			switch {
+			case fd.IsList():
+				err = d.unmarshalList(m.Mutable(fd).List(), fd)
+			case fd.IsMap():
+				err = d.unmarshalMap(m.Mutable(fd).Map(), fd)
+			default:
+				err = d.unmarshalSingular(m, fd)
+			}
+			if err != nil {
+				return err
+			}
+			*paths = append(*paths, path)
+		}
+		d.pop()
+	}
+}
+
+// applyMapPatch applies the merge patch object that is next in the input to
+// the entries of the map field fd.
+func (d decoder) applyMapPatch(mmap pref.Map, fd pref.FieldDescriptor) error {
+	d.Read() // Read json.ObjectOpen.
+
+	valDesc := fd.MapValue()
+	seen := make(map[interface{}]bool)
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind() {
+		default:
+			return d.unexpectedTokenError(tok)
+		case json.ObjectClose:
+			return nil
+		case json.Name:
+			// Continue below.
+		}
+
+		d.push(tok.Name(), fd)
+		key, err := d.unmarshalMapKey(tok, fd.MapKey())
+		if err != nil {
+			return err
+		}
+		if seen[key.Interface()] {
+			return d.newError(tok.Pos(), "duplicate map key %v", tok.RawString())
+		}
+		seen[key.Interface()] = true
+
+		next, err := d.Peek()
+		if err != nil {
+			return err
+		}
+		switch {
+		case next.Kind() == json.Null:
+			d.Read()
+			mmap.Clear(key)
+
+		case next.Kind() == json.ObjectOpen && d.isStructPatchable(valDesc) && mmap.Has(key):
+			if err := d.applyStructPatch(mmap.Mutable(key).Message()); err != nil {
+				return err
+			}
+
+		case next.Kind() == json.ObjectOpen && d.isPatchableMessage(valDesc) && mmap.Has(key):
+			// Paths within map entries are not recorded.
+			var paths []string
+			if err := d.applyMergePatch(mmap.Mutable(key).Message(), "", &paths); err != nil {
+				return err
+			}
+
+		case valDesc.Message() != nil:
+			val := mmap.NewValue()
+			if err := d.unmarshalMessage(val.Message(), ""); err != nil {
+				return err
+			}
+			mmap.Set(key, val)
+
+		default:
+			val, err := d.unmarshalScalar(valDesc)
+			if err != nil {
+				return err
+			}
+			mmap.Set(key, val)
+		}
+		d.pop()
+	}
+}
+
+// isPatchableMessage reports whether fd is a singular message field whose
+// JSON representation is an object of its fields, such that a merge patch
+// object applies to its fields.
+func (d decoder) isPatchableMessage(fd pref.FieldDescriptor) bool {
+	return fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !d.isCustomType(fd.Message().FullName())
+}
+
+// isStructPatchable reports whether fd is a singular google.protobuf.Struct or
+// google.protobuf.Value field without a custom unmarshaler, such that a merge
+// patch object applies to the members of the JSON object that it represents.
+func (d decoder) isStructPatchable(fd pref.FieldDescriptor) bool {
+	if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+		return false
+	}
+	switch name := fd.Message().FullName(); name {
+	case "google.protobuf.Struct", "google.protobuf.Value":
+		_, ok := d.opts.Unmarshalers[name]
+		return !ok
+	}
+	return false
+}
+
+// applyStructPatch applies the merge patch object that is next in the input to
+// the google.protobuf.Struct or google.protobuf.Value message m. A Value that
+// does not hold a Struct is set to an empty one first.
+func (d decoder) applyStructPatch(m pref.Message) error {
+	if m.Descriptor().FullName() == "google.protobuf.Value" {
+		fd := m.Descriptor().Fields().ByNumber(fieldnum.Value_StructValue)
+		if !m.Has(fd) {
+			m.Set(fd, m.NewField(fd))
+		}
+		m = m.Mutable(fd).Message()
+	}
+	fd := m.Descriptor().Fields().ByNumber(fieldnum.Struct_Fields)
+	mmap := m.Mutable(fd).Map()
+
+	d.Read() // Read json.ObjectOpen.
+	seen := make(map[string]bool)
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind() {
+		default:
+			return d.unexpectedTokenError(tok)
+		case json.ObjectClose:
+			return nil
+		case json.Name:
+			// Continue below.
+		}
+
+		name := tok.Name()
+		if seen[name] {
+			return d.newError(tok.Pos(), "duplicate map key %v", tok.RawString())
+		}
+		seen[name] = true
+		d.push(name, fd)
+
+		key := pref.ValueOfString(name).MapKey()
+		next, err := d.Peek()
+		if err != nil {
+			return err
+		}
+		switch next.Kind() {
+		case json.Null:
+			d.Read()
+			mmap.Clear(key)
+
+		case json.ObjectOpen:
+			if err := d.applyStructPatch(mmap.Mutable(key).Message()); err != nil {
+				return err
+			}
+
+		default:
+			val := mmap.NewValue()
+			if err := d.unmarshalMessage(val.Message(), ""); err != nil {
+				return err
+			}
+			mmap.Set(key, val)
+		}
+		d.pop()
+	}
+}
+
+// patchPath returns the field mask path of fd within the message at prefix.
+func patchPath(prefix string, fd pref.FieldDescriptor) string {
+	name := string(fd.Name())
+	if fd.IsExtension() {
+		name = "[" + string(fd.FullName()) + "]"
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,220 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protojson_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	fieldmaskpb "google.golang.org/protobuf/internal/testprotos/fieldmaskpb"
+	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
+	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		desc      string
+		umo       protojson.UnmarshalOptions
+		noMask    bool // pass a nil mask
+		input     proto.Message
+		patch     string
+		want      proto.Message
+		wantPaths []string
+		wantErr   string // Expected error substring.
+	}{{
+		desc:      "empty patch",
+		input:     &pb3.Nested{SString: "a"},
+		patch:     `{}`,
+		want:      &pb3.Nested{SString: "a"},
+		wantPaths: nil,
+	}, {
+		desc:      "replace scalar",
+		input:     &pb3.Scalars{SInt32: 1, SString: "a"},
+		patch:     `{"sString": "b", "s_bool": true}`,
+		want:      &pb3.Scalars{SInt32: 1, SString: "b", SBool: true},
+		wantPaths: []string{"s_string", "s_bool"},
+	}, {
+		desc:   "nil mask",
+		noMask: true,
+		input:  &pb3.Scalars{SString: "a"},
+		patch:  `{"sInt32": 5}`,
+		want:   &pb3.Scalars{SInt32: 5, SString: "a"},
+	}, {
+		desc:      "null clears field",
+		input:     &pb3.Scalars{SInt32: 1, SString: "a"},
+		patch:     `{"sString": null}`,
+		want:      &pb3.Scalars{SInt32: 1},
+		wantPaths: []string{"s_string"},
+	}, {
+		desc: "object recurses into message",
+		input: &pb3.Nested{
+			SString: "a",
+			SNested: &pb3.Nested{SString: "b", SNested: &pb3.Nested{}},
+		},
+		patch: `{"sNested": {"sString": "c", "sNested": null}}`,
+		want: &pb3.Nested{
+			SString: "a",
+			SNested: &pb3.Nested{SString: "c"},
+		},
+		wantPaths: []string{"s_nested.s_string", "s_nested.s_nested"},
+	}, {
+		desc:      "empty object populates message",
+		input:     &pb2.Nested{},
+		patch:     `{"optNested": {}}`,
+		want:      &pb2.Nested{OptNested: &pb2.Nested{}},
+		wantPaths: []string{"opt_nested"},
+	}, {
+		desc: "array replaces repeated field",
+		input: &pb2.Nests{
+			RptNested: []*pb2.Nested{{OptString: proto.String("a")}, {}},
+		},
+		patch: `{"rptNested": [{"optString": "b"}]}`,
+		want: &pb2.Nests{
+			RptNested: []*pb2.Nested{{OptString: proto.String("b")}},
+		},
+		wantPaths: []string{"rpt_nested"},
+	}, {
+		desc: "object patches map entries",
+		input: &pb3.Maps{
+			Int32ToStr: map[int32]string{1: "one", 2: "two"},
+			StrToNested: map[string]*pb3.Nested{
+				"a": {SString: "a", SNested: &pb3.Nested{}},
+			},
+		},
+		patch: `{
+  "int32ToStr": {"1": null, "3": "three"},
+  "strToNested": {"a": {"sNested": null}, "b": {"sString": "b"}}
+}`,
+		want: &pb3.Maps{
+			Int32ToStr: map[int32]string{2: "two", 3: "three"},
+			StrToNested: map[string]*pb3.Nested{
+				"a": {SString: "a"},
+				"b": {SString: "b"},
+			},
+		},
+		wantPaths: []string{"int32_to_str", "str_to_nested"},
+	}, {
+		desc:  "well-known types are replaced",
+		input: &pb2.KnownTypes{OptDuration: &durationpb.Duration{Nanos: 1}},
+		patch: `{"optDuration": "2s", "optStruct": {"a": 1}}`,
+		want: &pb2.KnownTypes{
+			OptDuration: &durationpb.Duration{Seconds: 2},
+			OptStruct: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"a": {Kind: &structpb.Value_NumberValue{NumberValue: 1}},
+			}},
+		},
+		wantPaths: []string{"opt_duration", "opt_struct"},
+	}, {
+		desc: "object patches struct members",
+		input: &pb2.KnownTypes{
+			OptStruct: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"a": {Kind: &structpb.Value_StringValue{StringValue: "x"}},
+				"b": {Kind: &structpb.Value_StringValue{StringValue: "y"}},
+				"n": {Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"c": {Kind: &structpb.Value_NumberValue{NumberValue: 1}},
+					"d": {Kind: &structpb.Value_NumberValue{NumberValue: 2}},
+				}}}},
+			}},
+			OptValue: &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: "s"}},
+		},
+		patch: `{"optStruct": {"a": null, "n": {"c": null, "e": true}, "f": [1]}, "optValue": {"k": "v", "z": null}}`,
+		want: &pb2.KnownTypes{
+			OptStruct: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"b": {Kind: &structpb.Value_StringValue{StringValue: "y"}},
+				"n": {Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{Fields: map[string]*structpb.Value{
+					"d": {Kind: &structpb.Value_NumberValue{NumberValue: 2}},
+					"e": {Kind: &structpb.Value_BoolValue{BoolValue: true}},
+				}}}},
+				"f": {Kind: &structpb.Value_ListValue{ListValue: &structpb.ListValue{Values: []*structpb.Value{
+					{Kind: &structpb.Value_NumberValue{NumberValue: 1}},
+				}}}},
+			}},
+			OptValue: &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"k": {Kind: &structpb.Value_StringValue{StringValue: "v"}},
+			}}}},
+		},
+		wantPaths: []string{"opt_struct", "opt_value"},
+	}, {
+		desc:      "oneof field replaces other oneof field",
+		input:     &pb3.Oneofs{Union: &pb3.Oneofs_OneofString{OneofString: "a"}},
+		patch:     `{"oneofNested": {"sString": "b"}}`,
+		want:      &pb3.Oneofs{Union: &pb3.Oneofs_OneofNested{OneofNested: &pb3.Nested{SString: "b"}}},
+		wantPaths: []string{"oneof_nested.s_string"},
+	}, {
+		desc:      "DiscardUnknown",
+		umo:       protojson.UnmarshalOptions{DiscardUnknown: true},
+		input:     &pb3.Nested{},
+		patch:     `{"unknown": {"a": 1}, "sString": "a"}`,
+		want:      &pb3.Nested{SString: "a"},
+		wantPaths: []string{"s_string"},
+	}, {
+		desc:    "unknown field",
+		input:   &pb3.Nested{SString: "a"},
+		patch:   `{"sString": "b", "unknown": 1}`,
+		wantErr: `unknown field "unknown"`,
+	}, {
+		desc:    "invalid value",
+		input:   &pb3.Nested{SString: "a"},
+		patch:   `{"sString": "b", "sNested": 1}`,
+		wantErr: `unexpected token 1`,
+	}, {
+		desc:    "duplicate field",
+		input:   &pb3.Nested{},
+		patch:   `{"sString": "a", "s_string": null}`,
+		wantErr: `duplicate field "s_string"`,
+	}, {
+		desc:    "patch is not an object",
+		input:   &pb3.Nested{},
+		patch:   `[]`,
+		wantErr: `merge patch for pb3.Nested must be a JSON object`,
+	}, {
+		desc:    "missing required field",
+		input:   &pb2.PartialRequired{ReqString: proto.String("a")},
+		patch:   `{"reqString": null}`,
+		wantErr: `required field pb2.PartialRequired.req_string not set`,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			original := proto.Clone(tt.input)
+			mask := &fieldmaskpb.FieldMask{}
+			var err error
+			if tt.noMask {
+				err = tt.umo.ApplyMergePatch([]byte(tt.patch), tt.input, nil)
+			} else {
+				err = tt.umo.ApplyMergePatch([]byte(tt.patch), tt.input, mask)
+			}
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ApplyMergePatch() error got %v, want %q", err, tt.wantErr)
+				}
+				if !proto.Equal(tt.input, original) {
+					t.Errorf("ApplyMergePatch() modified message on error\n<got>\n%v\n<want>\n%v", tt.input, original)
+				}
+				if len(mask.Paths) > 0 {
+					t.Errorf("ApplyMergePatch() modified mask on error: %v", mask.Paths)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("ApplyMergePatch() got nil error, want error %q", tt.wantErr)
+			}
+			if !proto.Equal(tt.input, tt.want) {
+				t.Errorf("ApplyMergePatch()\n<got>\n%v\n<want>\n%v", tt.input, tt.want)
+			}
+			if diff := cmp.Diff(tt.wantPaths, mask.Paths); diff != "" {
+				t.Errorf("ApplyMergePatch() paths mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protojson
+
+import (
+	"google.golang.org/protobuf/internal/encoding/json"
+	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/internal/strs"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// OneofStyle specifies the JSON representation of populated oneofs.
+// Fields declared with the proto3 optional keyword belong to synthetic oneofs
+// and are always represented like any other field.
+//
+// For a message
+//
+//	message Shape {
+//	  string name = 1;
+//	  oneof kind {
+//	    Circle circle = 2;
+//	    google.protobuf.Empty point = 3;
+//	    string label = 4;
+//	  }
+//	}
+//
+// the styles produce:
+//
+//	OneofFlattened:        {"name": "a", "circle": {"radius": 1}}
+//	OneofExternallyTagged: {"name": "a", "kind": {"circle": {"radius": 1}}}
+//	OneofInternallyTagged: {"name": "a", "kind": {"type": "circle", "radius": 1}}
+//	OneofInternallyTagged: {"name": "a", "kind": {"type": "label", "value": "x"}}
+type OneofStyle int
+
+const (
+	// OneofFlattened represents the populated field of a oneof as a member
+	// of the enclosing object, like any other field. This is the standard
+	// protobuf JSON mapping.
+	OneofFlattened OneofStyle = iota
+
+	// OneofExternallyTagged represents a oneof as a member named after the
+	// oneof, whose value is an object with a single member for the populated
+	// field.
+	OneofExternallyTagged
+
+	// OneofInternallyTagged represents a oneof as a member named after the
+	// oneof, whose value is an object with a discriminator member naming the
+	// populated field. For message fields, the fields of the message are
+	// members of the same object; for other fields and for messages with a
+	// special JSON representation, the field value is the "value" member.
+	OneofInternallyTagged
+)
+
+// defaultOneofTagKey is the discriminator member name used by
+// OneofInternallyTagged if none is specified.
+const defaultOneofTagKey = "type"
+
+// oneofValueKey is the member name of non-message variants in
+// OneofInternallyTagged.
+const oneofValueKey = "value"
+
+// isUnionMessage reports whether all fields of md belong to a single oneof.
+// Synthetic oneofs of proto3 optional fields are not considered.
+func isUnionMessage(md pref.MessageDescriptor) bool {
+	fds := md.Fields()
+	ods := md.Oneofs()
+	return ods.Len() == 1 && !ods.Get(0).IsSynthetic() && fds.Len() > 0 && fds.Len() == ods.Get(0).Fields().Len()
+}
+
+// isEmptyVariant reports whether fd is a message field whose type has no
+// fields, such as google.protobuf.Empty.
+func isEmptyVariant(fd pref.FieldDescriptor) bool {
+	return fd.Message() != nil && fd.Message().Fields().Len() == 0
+}
+
+// variantByName returns the field of od with the given JSON or proto name.
+func variantByName(od pref.OneofDescriptor, name string) pref.FieldDescriptor {
+	fds := od.Fields()
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		if fd.JSONName() == name || string(fd.Name()) == name {
+			return fd
+		}
+	}
+	return nil
+}
+
+// oneofByName returns the oneof of md with the given JSON or proto name.
+// Synthetic oneofs are not addressable since their fields are always
+// represented as if flattened.
+func oneofByName(md pref.MessageDescriptor, name string) pref.OneofDescriptor {
+	ods := md.Oneofs()
+	for i := 0; i < ods.Len(); i++ {
+		od := ods.Get(i)
+		if od.IsSynthetic() {
+			continue
+		}
+		if strs.JSONCamelCase(string(od.Name())) == name || string(od.Name()) == name {
+			return od
+		}
+	}
+	return nil
+}
+
+// oneofTagKey returns the discriminator member name for OneofInternallyTagged.
+func oneofTagKey(key string) string {
+	if key == "" {
+		return defaultOneofTagKey
+	}
+	return key
+}
+
+// variantName returns the JSON member name for the oneof field fd.
+func (e encoder) variantName(fd pref.FieldDescriptor) string {
+	if e.opts.UseProtoNames {
+		return string(fd.Name())
+	}
+	return fd.JSONName()
+}
+
+// marshalEmptyVariant writes out m as the name of its populated field if m is
+// a union message whose populated field is an empty message and
+// OneofEmptyAsString is set. It reports whether it did so.
+func (e encoder) marshalEmptyVariant(m pref.Message) (bool, error) {
+	md := m.Descriptor()
+	if !e.opts.OneofEmptyAsString || e.opts.OneofStyle != OneofFlattened || !isUnionMessage(md) {
+		return false, nil
+	}
+	fd := m.WhichOneof(md.Oneofs().Get(0))
+	if fd == nil || !isEmptyVariant(fd) {
+		return false, nil
+	}
+	return true, e.WriteString(e.variantName(fd))
+}
+
+// marshalOneof writes out the name and value of the oneof od, whose populated
+// field is fd, according to the OneofStyle.
+func (e encoder) marshalOneof(m pref.Message, od pref.OneofDescriptor, fd pref.FieldDescriptor) error {
+	if err := e.WriteName(e.oneofName(od)); err != nil {
+		return err
+	}
+	if e.opts.OneofEmptyAsString && isEmptyVariant(fd) {
+		return e.WriteString(e.variantName(fd))
+	}
+
+	e.StartObject()
+	defer e.EndObject()
+	if e.opts.OneofStyle == OneofExternallyTagged {
+		if err := e.WriteName(e.variantName(fd)); err != nil {
+			return err
+		}
+		return e.marshalValue(m.Get(fd), fd)
+	}
+
+	tagKey := oneofTagKey(e.opts.OneofTagKey)
+	if err := e.WriteName(tagKey); err != nil {
+		return err
+	}
+	if err := e.WriteString(e.variantName(fd)); err != nil {
+		return err
+	}
+	if fd.Message() == nil || e.isCustomType(fd.Message().FullName()) {
+		if err := e.WriteName(oneofValueKey); err != nil {
+			return err
+		}
+		return e.marshalValue(m.Get(fd), fd)
+	}
+	if hasMemberName(fd.Message(), tagKey) {
+		return errors.New("%v: discriminator %q conflicts with a field of %v", od.FullName(), tagKey, fd.Message().FullName())
+	}
+	return e.marshalFields(m.Get(fd).Message())
+}
+
+// oneofName returns the JSON member name for the oneof od in styles other
+// than OneofFlattened.
+func (e encoder) oneofName(od pref.OneofDescriptor) string {
+	if e.opts.UseProtoNames {
+		return string(od.Name())
+	}
+	return strs.JSONCamelCase(string(od.Name()))
+}
+
+// marshalUnsetOneof writes out null for the fields of the unpopulated oneof
+// od that are to be emitted according to EmitUnpopulatedOneofs and related
+// options, or for the oneof itself in styles other than OneofFlattened.
+func (e encoder) marshalUnsetOneof(od pref.OneofDescriptor) error {
+	fds := od.Fields()
+	for i := 0; i < fds.Len(); i++ {
+		fd := fds.Get(i)
+		if !e.emitUnpopulated(fd) {
+			continue
+		}
+		if e.opts.OneofStyle != OneofFlattened {
+			if err := e.WriteName(e.oneofName(od)); err != nil {
+				return err
+			}
+			e.WriteNull()
+			return nil
+		}
+		if err := e.WriteName(e.fieldName(fd)); err != nil {
+			return err
+		}
+		e.WriteNull()
+	}
+	return nil
+}
+
+// hasMemberName reports whether a field of md has the given JSON or proto
+// name.
+func hasMemberName(md pref.MessageDescriptor, name string) bool {
+	return md.Fields().ByJSONName(name) != nil || md.Fields().ByName(pref.Name(name)) != nil
+}
+
+// unmarshalEmptyVariant unmarshals a JSON string naming the populated field of
+// the union message m, which must be an empty message.
+func (d decoder) unmarshalEmptyVariant(m pref.Message, tok json.Token) error {
+	md := m.Descriptor()
+	if !d.opts.OneofEmptyAsString || d.opts.OneofStyle != OneofFlattened || !isUnionMessage(md) {
+		return d.unexpectedTokenError(tok)
+	}
+	return d.setEmptyVariant(m, md.Oneofs().Get(0), tok)
+}
+
+// setEmptyVariant populates the empty message field of oneof od named by the
+// string token tok.
+func (d decoder) setEmptyVariant(m pref.Message, od pref.OneofDescriptor, tok json.Token) error {
+	fd := variantByName(od, tok.ParsedString())
+	if fd == nil {
+		return d.invalidValueError(tok.Pos(), "invalid value for oneof %v: %v", od.FullName(), tok.RawString())
+	}
+	if !isEmptyVariant(fd) {
+		return d.invalidValueError(tok.Pos(), "oneof field %v cannot be given as a string: %v", fd.FullName(), tok.RawString())
+	}
+	m.Set(fd, m.NewField(fd))
+	return nil
+}
+
+// unmarshalOneof unmarshals the value of the oneof od according to the
+// OneofStyle.
+func (d decoder) unmarshalOneof(m pref.Message, od pref.OneofDescriptor) error {
+	start, err := d.Peek()
+	if err != nil {
+		return err
+	}
+	switch start.Kind() {
+	case json.Null:
+		d.Read()
+		return nil
+	case json.String:
+		if d.opts.OneofEmptyAsString {
+			d.Read()
+			return d.setEmptyVariant(m, od, start)
+		}
+		return d.unexpectedTokenError(start)
+	case json.ObjectOpen:
+	default:
+		return d.unexpectedTokenError(start)
+	}
+
+	if d.opts.OneofStyle == OneofExternallyTagged {
+		d.Read() // Read json.ObjectOpen.
+		tok, err := d.Read()
+		if err != nil {
+			return err
+		}
+		if tok.Kind() != json.Name {
+			return d.invalidValueError(tok.Pos(), "oneof %v has no populated field", od.FullName())
+		}
+		fd := variantByName(od, tok.Name())
+		if fd == nil {
+			return d.unknownFieldError(tok)
+		}
+		d.push(tok.Name(), fd)
+		if err := d.unmarshalVariant(m, fd); err != nil {
+			return err
+		}
+		d.pop()
+		tok, err = d.Read()
+		if err != nil {
+			return err
+		}
+		if tok.Kind() != json.ObjectClose {
+			return d.invalidValueError(tok.Pos(), "oneof %v has more than one populated field", od.FullName())
+		}
+		return nil
+	}
+
+	// Use another decoder to find the discriminator, which may appear after
+	// the fields of the variant.
+	tagKey := oneofTagKey(d.opts.OneofTagKey)
+	tok, err := findOneofTag(decoder{d.Clone(), UnmarshalOptions{}, d.path}, tagKey)
+	if err != nil {
+		return err
+	}
+	if tok.Kind() != json.String {
+		return d.invalidValueError(start.Pos(), "oneof %v is missing discriminator %q", od.FullName(), tagKey)
+	}
+	fd := variantByName(od, tok.ParsedString())
+	if fd == nil {
+		return d.invalidValueError(tok.Pos(), "invalid value for oneof %v discriminator: %v", od.FullName(), tok.RawString())
+	}
+	if fd.Message() != nil && !d.isCustomType(fd.Message().FullName()) {
+		if hasMemberName(fd.Message(), tagKey) {
+			return d.newError(tok.Pos(), "%v: discriminator %q conflicts with a field of %v", od.FullName(), tagKey, fd.Message().FullName())
+		}
+		val := m.NewField(fd)
+		if err := d.unmarshalMessage(val.Message(), tagKey); err != nil {
+			return err
+		}
+		m.Set(fd, val)
+		return nil
+	}
+
+	d.Read() // Read json.ObjectOpen.
+	var seenValue bool
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return err
+		}
+		switch {
+		case tok.Kind() == json.ObjectClose:
+			if !seenValue {
+				return d.invalidValueError(tok.Pos(), "oneof %v is missing %q", od.FullName(), oneofValueKey)
+			}
+			return nil
+		case tok.Kind() != json.Name:
+			return d.unexpectedTokenError(tok)
+		case tok.Name() == tagKey:
+			d.Read()
+		case tok.Name() == oneofValueKey:
+			if seenValue {
+				return d.newError(tok.Pos(), "duplicate field %v", tok.RawString())
+			}
+			seenValue = true
+			d.push(oneofValueKey, fd)
+			if err := d.unmarshalVariant(m, fd); err != nil {
+				return err
+			}
+			d.pop()
+		default:
+			return d.unknownFieldError(tok)
+		}
+	}
+}
+
+// unmarshalVariant unmarshals the next JSON value into the oneof field fd.
+func (d decoder) unmarshalVariant(m pref.Message, fd pref.FieldDescriptor) error {
+	if tok, _ := d.Peek(); tok.Kind() == json.String && d.opts.OneofEmptyAsString && isEmptyVariant(fd) {
+		d.Read()
+		m.Set(fd, m.NewField(fd))
+		return nil
+	}
+	if tok, _ := d.Peek(); tok.Kind() == json.Null && !isKnownValue(fd) && !isNullValue(fd) {
+		return d.invalidValueError(tok.Pos(), "oneof field %v cannot be null", fd.FullName())
+	}
+	return d.unmarshalSingular(m, fd)
+}
+
+// findOneofTag returns the token for the value of the discriminator member
+// tagKey in the JSON object that d starts with. It returns a zero token if
+// there is no such member and an error if there is more than one.
+func findOneofTag(d decoder, tagKey string) (json.Token, error) {
+	var tagTok json.Token
+	d.Read() // Read json.ObjectOpen.
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return json.Token{}, err
+		}
+		switch tok.Kind() {
+		case json.ObjectClose:
+			return tagTok, nil
+		case json.Name:
+			if tok.Name() != tagKey {
+				if err := d.skipJSONValue(); err != nil {
+					return json.Token{}, err
+				}
+				continue
+			}
+			if tagTok.Kind() != json.Invalid {
+				return json.Token{}, d.newError(tok.Pos(), "duplicate discriminator %q", tagKey)
+			}
+			if tagTok, err = d.Read(); err != nil {
+				return json.Token{}, err
+			}
+			if tagTok.Kind() != json.String {
+				return json.Token{}, d.invalidValueError(tagTok.Pos(), "invalid value for discriminator %q: %v", tagKey, tagTok.RawString())
+			}
+		default:
+			return json.Token{}, d.unexpectedTokenError(tok)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protojson_test
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
+)
+
+func TestOneofStyle(t *testing.T) {
+	tests := []struct {
+		desc     string
+		style    protojson.OneofStyle
+		tagKey   string
+		asString bool
+		message  proto.Message
+		want     string
+		wantErr  string // expected Marshal error substring
+	}{{
+		desc:    "flattened",
+		message: &pb3.Shape{Name: "a", ShapeKind: &pb3.Shape_Circle{Circle: &pb3.Circle{Radius: 1}}},
+		want:    `{"name":"a","circle":{"radius":1}}`,
+	}, {
+		desc:    "externally tagged unset oneof",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{Name: "a"},
+		want:    `{"name":"a"}`,
+	}, {
+		desc:     "flattened union message with empty variant as string",
+		asString: true,
+		message:  &pb3.Union{Value: &pb3.Union_None{None: &emptypb.Empty{}}},
+		want:     `"none"`,
+	}, {
+		desc:     "flattened union message with non-empty variant",
+		asString: true,
+		message:  &pb3.Union{Value: &pb3.Union_Circle{Circle: &pb3.Circle{Radius: 2}}},
+		want:     `{"circle":{"radius":2}}`,
+	}, {
+		desc:     "flattened message with other fields is not a union",
+		asString: true,
+		message:  &pb3.Shape{ShapeKind: &pb3.Shape_Point{Point: &emptypb.Empty{}}},
+		want:     `{"point":{}}`,
+	}, {
+		desc:    "externally tagged message",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{Name: "a", ShapeKind: &pb3.Shape_Circle{Circle: &pb3.Circle{Radius: 1}}},
+		want:    `{"name":"a","shapeKind":{"circle":{"radius":1}}}`,
+	}, {
+		desc:    "externally tagged scalar",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{ShapeKind: &pb3.Shape_Label{Label: "x"}},
+		want:    `{"shapeKind":{"label":"x"}}`,
+	}, {
+		desc:    "externally tagged empty",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{ShapeKind: &pb3.Shape_Point{Point: &emptypb.Empty{}}},
+		want:    `{"shapeKind":{"point":{}}}`,
+	}, {
+		desc:     "externally tagged empty as string",
+		style:    protojson.OneofExternallyTagged,
+		asString: true,
+		message:  &pb3.Shape{ShapeKind: &pb3.Shape_Point{Point: &emptypb.Empty{}}},
+		want:     `{"shapeKind":"point"}`,
+	}, {
+		desc:    "internally tagged message",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{Name: "a", ShapeKind: &pb3.Shape_Circle{Circle: &pb3.Circle{Radius: 1}}},
+		want:    `{"name":"a","shapeKind":{"type":"circle","radius":1}}`,
+	}, {
+		desc:    "internally tagged scalar",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{ShapeKind: &pb3.Shape_Label{Label: "x"}},
+		want:    `{"shapeKind":{"type":"label","value":"x"}}`,
+	}, {
+		desc:    "internally tagged well-known type",
+		style:   protojson.OneofInternallyTagged,
+		tagKey:  "@kind",
+		message: &pb3.Shape{ShapeKind: &pb3.Shape_Point{Point: &emptypb.Empty{}}},
+		want:    `{"shapeKind":{"@kind":"point","value":{}}}`,
+	}, {
+		desc:     "internally tagged empty as string",
+		style:    protojson.OneofInternallyTagged,
+		asString: true,
+		message:  &pb3.Union{Value: &pb3.Union_None{None: &emptypb.Empty{}}},
+		want:     `{"value":"none"}`,
+	}, {
+		desc:    "internally tagged discriminator conflicts with field",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Conflict{Kind: &pb3.Conflict_Typed_{Typed: &pb3.Conflict_Typed{Type: "x"}}},
+		wantErr: `discriminator "type" conflicts with a field of pb3.Conflict.Typed`,
+	}, {
+		desc:    "internally tagged with other discriminator",
+		style:   protojson.OneofInternallyTagged,
+		tagKey:  "kind",
+		message: &pb3.Conflict{Kind: &pb3.Conflict_Typed_{Typed: &pb3.Conflict_Typed{Type: "x"}}},
+		want:    `{"kind":{"kind":"typed","type":"x"}}`,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := protojson.MarshalOptions{
+				OneofStyle:         tt.style,
+				OneofTagKey:        tt.tagKey,
+				OneofEmptyAsString: tt.asString,
+			}.Marshal(tt.message)
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Marshal() error got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("Marshal() got nil error, want error %q", tt.wantErr)
+			}
+			if got := string(b); got != tt.want {
+				t.Errorf("Marshal()\n<got>\n%v\n<want>\n%v", got, tt.want)
+			}
+
+			got := tt.message.ProtoReflect().New().Interface()
+			if err := (protojson.UnmarshalOptions{
+				OneofStyle:         tt.style,
+				OneofTagKey:        tt.tagKey,
+				OneofEmptyAsString: tt.asString,
+			}).Unmarshal(b, got); err != nil {
+				t.Fatalf("Unmarshal() returned error: %v", err)
+			}
+			if !proto.Equal(got, tt.message) {
+				t.Errorf("Unmarshal() round trip\n<got>\n%v\n<want>\n%v", got, tt.message)
+			}
+		})
+	}
+}
+
+func TestOneofStyleUnmarshalError(t *testing.T) {
+	tests := []struct {
+		desc     string
+		style    protojson.OneofStyle
+		asString bool
+		message  proto.Message
+		input    string
+		wantErr  string
+	}{{
+		desc:    "string without OneofEmptyAsString",
+		message: &pb3.Union{},
+		input:   `"none"`,
+		wantErr: `unexpected token "none"`,
+	}, {
+		desc:     "string naming non-empty field",
+		asString: true,
+		message:  &pb3.Union{},
+		input:    `"circle"`,
+		wantErr:  `oneof field pb3.Union.circle cannot be given as a string`,
+	}, {
+		desc:     "string naming unknown field",
+		asString: true,
+		message:  &pb3.Union{},
+		input:    `"square"`,
+		wantErr:  `invalid value for oneof pb3.Union.value: "square"`,
+	}, {
+		desc:    "flattened field with tagged style",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"circle":{}}`,
+		wantErr: `unknown field "circle"`,
+	}, {
+		desc:    "externally tagged with several fields",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"circle":{},"label":"x"}}`,
+		wantErr: `oneof pb3.Shape.shape_kind has more than one populated field`,
+	}, {
+		desc:    "externally tagged without field",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{}}`,
+		wantErr: `oneof pb3.Shape.shape_kind has no populated field`,
+	}, {
+		desc:    "oneof given twice",
+		style:   protojson.OneofExternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"label":"x"},"shape_kind":{"label":"y"}}`,
+		wantErr: `oneof pb3.Shape.shape_kind is already set`,
+	}, {
+		desc:    "internally tagged without discriminator",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"radius":1}}`,
+		wantErr: `oneof pb3.Shape.shape_kind is missing discriminator "type"`,
+	}, {
+		desc:    "internally tagged with duplicate discriminator",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"type":"circle","type":"label"}}`,
+		wantErr: `duplicate discriminator "type"`,
+	}, {
+		desc:    "internally tagged with unknown variant",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"type":"square"}}`,
+		wantErr: `invalid value for oneof pb3.Shape.shape_kind discriminator: "square"`,
+	}, {
+		desc:    "internally tagged scalar without value",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"type":"label"}}`,
+		wantErr: `oneof pb3.Shape.shape_kind is missing "value"`,
+	}, {
+		desc:    "internally tagged scalar with extra member",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"type":"label","value":"x","radius":1}}`,
+		wantErr: `unknown field "radius"`,
+	}, {
+		desc:    "internally tagged scalar with duplicate value",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"type":"label","value":"x","value":"y"}}`,
+		wantErr: `duplicate field "value"`,
+	}, {
+		desc:    "internally tagged message with discriminator last",
+		style:   protojson.OneofInternallyTagged,
+		message: &pb3.Shape{},
+		input:   `{"shapeKind":{"radius":1,"type":"circle","bogus":2}}`,
+		wantErr: `unknown field "bogus"`,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			err := protojson.UnmarshalOptions{
+				OneofStyle:         tt.style,
+				OneofEmptyAsString: tt.asString,
+			}.Unmarshal([]byte(tt.input), tt.message)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unmarshal() error got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
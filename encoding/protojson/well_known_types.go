@@ -6,6 +6,7 @@ package protojson
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
@@ -17,6 +18,7 @@ import (
 	"google.golang.org/protobuf/internal/strs"
 	"google.golang.org/protobuf/proto"
 	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 // isCustomType returns true if type name has special JSON conversion rules.
@@ -46,11 +48,15 @@ func isCustomType(name pref.FullName) bool {
 	return false
 }
 
-// marshalCustomType marshals given well-known type message that have special
-// JSON conversion rules. It needs to be a message type where isCustomType
-// returns true, else it will panic.
+// marshalCustomType marshals given message that has a custom marshaler or is
+// a well-known type that has special JSON conversion rules. It needs to be a
+// message type where encoder.isCustomType returns true, else it will panic.
 func (e encoder) marshalCustomType(m pref.Message) error {
 	name := m.Descriptor().FullName()
+	if fn := e.opts.Marshalers[name]; fn != nil {
+		return e.marshalCustomMarshaler(m, fn)
+	}
+
 	switch name {
 	case "google.protobuf.Any":
 		return e.marshalAny(m)
@@ -91,11 +97,16 @@ func (e encoder) marshalCustomType(m pref.Message) error {
 	panic(fmt.Sprintf("%s does not have a custom marshaler", name))
 }
 
-// unmarshalCustomType unmarshals given well-known type message that have
-// special JSON conversion rules. It needs to be a message type where
-// isCustomType returns true, else it will panic.
+// unmarshalCustomType unmarshals given message that has a custom unmarshaler
+// or is a well-known type that has special JSON conversion rules. It needs to
+// be a message type where decoder.isCustomType returns true, else it will
+// panic.
 func (d decoder) unmarshalCustomType(m pref.Message) error {
 	name := m.Descriptor().FullName()
+	if fn := d.opts.Unmarshalers[name]; fn != nil {
+		return d.unmarshalCustomUnmarshaler(m, fn)
+	}
+
 	switch name {
 	case "google.protobuf.Any":
 		return d.unmarshalAny(m)
@@ -172,8 +183,14 @@ func (e encoder) marshalAny(m pref.Message) error {
 	}
 
 	// Resolve the type in order to unmarshal value field.
-	emt, err := e.opts.Resolver.FindMessageByURL(typeURL)
+	emt, err := findAnyType(e.opts.Resolver, e.opts.AnyFallbackResolver, typeURL)
 	if err != nil {
+		if e.opts.EmitUnresolvableAny {
+			// Marshal out the serialized message as is.
+			e.WriteName("value")
+			e.WriteString(base64.StdEncoding.EncodeToString(valueVal.Bytes()))
+			return nil
+		}
 		return errors.New("%s: unable to resolve %q: %v", m.Descriptor().FullName(), typeURL, err)
 	}
 
@@ -189,7 +206,7 @@ func (e encoder) marshalAny(m pref.Message) error {
 	// If type of value has custom JSON encoding, marshal out a field "value"
 	// with corresponding custom JSON encoding of the embedded message as a
 	// field.
-	if isCustomType(emt.Descriptor().FullName()) {
+	if e.isCustomType(emt.Descriptor().FullName()) {
 		e.WriteName("value")
 		return e.marshalCustomType(em)
 	}
@@ -215,7 +232,7 @@ func (d decoder) unmarshalAny(m pref.Message) error {
 	// Use another decoder to parse the unread bytes for @type field. This
 	// avoids advancing a read from current decoder because the current JSON
 	// object may contain the fields of the embedded type.
-	dec := decoder{d.Clone(), UnmarshalOptions{}}
+	dec := decoder{d.Clone(), UnmarshalOptions{}, d.path}
 	tok, err := findTypeURL(dec)
 	switch err {
 	case errEmptyObject:
@@ -239,14 +256,18 @@ func (d decoder) unmarshalAny(m pref.Message) error {
 	}
 
 	typeURL := tok.ParsedString()
-	emt, err := d.opts.Resolver.FindMessageByURL(typeURL)
+	emt, err := findAnyType(d.opts.Resolver, d.opts.AnyFallbackResolver, typeURL)
 	if err != nil {
-		return d.newError(tok.Pos(), "unable to resolve %v: %q", tok.RawString(), err)
+		err = d.newError(tok.Pos(), "unable to resolve %v: %q", tok.RawString(), err)
+		if d.opts.AllowUnresolvableAny {
+			return d.unmarshalUnresolvedAny(m, err)
+		}
+		return err
 	}
 
 	// Create new message for the embedded message type and unmarshal into it.
 	em := emt.New()
-	if isCustomType(emt.Descriptor().FullName()) {
+	if d.isCustomType(emt.Descriptor().FullName()) {
 		// If embedded message is a custom type,
 		// unmarshal the JSON "value" field into it.
 		if err := d.unmarshalAnyValue(em); err != nil {
@@ -254,7 +275,7 @@ func (d decoder) unmarshalAny(m pref.Message) error {
 		}
 	} else {
 		// Else unmarshal the current JSON object into it.
-		if err := d.unmarshalMessage(em, true); err != nil {
+		if err := d.unmarshalMessage(em, "@type"); err != nil {
 			return err
 		}
 	}
@@ -277,6 +298,69 @@ func (d decoder) unmarshalAny(m pref.Message) error {
 	return nil
 }
 
+// unmarshalUnresolvedAny unmarshals the current JSON object into the given
+// google.protobuf.Any message of unresolvable type from its "@type" field and
+// the "value" field holding the base64 encoding of the serialized message.
+// It returns resolveErr if the object contains other fields.
+func (d decoder) unmarshalUnresolvedAny(m pref.Message, resolveErr error) error {
+	fds := m.Descriptor().Fields()
+	fdType := fds.ByNumber(fieldnum.Any_TypeUrl)
+	fdValue := fds.ByNumber(fieldnum.Any_Value)
+
+	// Skip ObjectOpen, and start reading the fields.
+	d.Read()
+
+	var found bool // Used for detecting duplicate "value".
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind() {
+		case json.ObjectClose:
+			return nil
+
+		case json.Name:
+			switch tok.Name() {
+			case "@type":
+				tok, err := d.Read()
+				if err != nil {
+					return err
+				}
+				m.Set(fdType, pref.ValueOfString(tok.ParsedString()))
+
+			case "value":
+				if found {
+					return d.newError(tok.Pos(), `duplicate "value" field`)
+				}
+				tok, err := d.Read()
+				if err != nil {
+					return err
+				}
+				val, ok := unmarshalBytes(tok, BytesStdBase64)
+				if !ok {
+					return d.invalidValueError(tok.Pos(), "invalid value for Any.value: %v", tok.RawString())
+				}
+				m.Set(fdValue, val)
+				found = true
+
+			default:
+				return resolveErr
+			}
+		}
+	}
+}
+
+// findAnyType returns the message type for the given google.protobuf.Any type
+// URL, using fallback if resolver fails to find it.
+func findAnyType(resolver protoregistry.MessageTypeResolver, fallback func(string) (pref.MessageType, error), typeURL string) (pref.MessageType, error) {
+	mt, err := resolver.FindMessageByURL(typeURL)
+	if err != nil && fallback != nil {
+		mt, err = fallback(typeURL)
+	}
+	return mt, err
+}
+
 var errEmptyObject = fmt.Errorf(`empty object`)
 var errMissingType = fmt.Errorf(`missing "@type" field`)
 
@@ -330,7 +414,7 @@ Loop:
 				return json.Token{}, err
 			}
 			if tok.Kind() != json.String {
-				return json.Token{}, d.newError(tok.Pos(), `@type field value is not a string: %v`, tok.RawString())
+				return json.Token{}, d.invalidValueError(tok.Pos(), `@type field value is not a string: %v`, tok.RawString())
 			}
 			typeURL = tok.ParsedString()
 			if typeURL == "" {
@@ -421,9 +505,11 @@ func (d decoder) unmarshalAnyValue(m pref.Message) error {
 					return d.newError(tok.Pos(), `duplicate "value" field`)
 				}
 				// Unmarshal the field value into the given message.
+				d.push("value", nil)
 				if err := d.unmarshalCustomType(m); err != nil {
 					return err
 				}
+				d.pop()
 				found = true
 
 			default:
@@ -433,7 +519,7 @@ func (d decoder) unmarshalAnyValue(m pref.Message) error {
 					}
 					continue
 				}
-				return d.newError(tok.Pos(), "unknown field %v", tok.RawString())
+				return d.unknownFieldError(tok)
 			}
 		}
 	}
@@ -505,7 +591,7 @@ func (d decoder) unmarshalEmpty(pref.Message) error {
 				}
 				continue
 			}
-			return d.newError(tok.Pos(), "unknown field %v", tok.RawString())
+			return d.unknownFieldError(tok)
 
 		default:
 			return d.unexpectedTokenError(tok)
@@ -584,7 +670,7 @@ func (d decoder) unmarshalKnownValue(m pref.Message) error {
 		var ok bool
 		val, ok = unmarshalFloat(tok, 64)
 		if !ok {
-			return d.newError(tok.Pos(), "invalid google.protobuf.Value: %v", tok.RawString())
+			return d.invalidValueError(tok.Pos(), "invalid google.protobuf.Value: %v", tok.RawString())
 		}
 
 	case json.String:
@@ -616,7 +702,7 @@ func (d decoder) unmarshalKnownValue(m pref.Message) error {
 		}
 
 	default:
-		return d.newError(tok.Pos(), "invalid google.protobuf.Value: %v", tok.RawString())
+		return d.invalidValueError(tok.Pos(), "invalid google.protobuf.Value: %v", tok.RawString())
 	}
 
 	m.Set(fd, val)
@@ -686,12 +772,12 @@ func (d decoder) unmarshalDuration(m pref.Message) error {
 
 	secs, nanos, ok := parseDuration(tok.ParsedString())
 	if !ok {
-		return d.newError(tok.Pos(), "invalid google.protobuf.Duration value %v", tok.RawString())
+		return d.invalidValueError(tok.Pos(), "invalid google.protobuf.Duration value %v", tok.RawString())
 	}
 	// Validate seconds. No need to validate nanos because parseDuration would
 	// have covered that already.
 	if secs < -maxSecondsInDuration || secs > maxSecondsInDuration {
-		return d.newError(tok.Pos(), "google.protobuf.Duration value out of range: %v", tok.RawString())
+		return d.invalidValueError(tok.Pos(), "google.protobuf.Duration value out of range: %v", tok.RawString())
 	}
 
 	fds := m.Descriptor().Fields()
@@ -868,13 +954,13 @@ func (d decoder) unmarshalTimestamp(m pref.Message) error {
 
 	t, err := time.Parse(time.RFC3339Nano, tok.ParsedString())
 	if err != nil {
-		return d.newError(tok.Pos(), "invalid google.protobuf.Timestamp value %v", tok.RawString())
+		return d.invalidValueError(tok.Pos(), "invalid google.protobuf.Timestamp value %v", tok.RawString())
 	}
 	// Validate seconds. No need to validate nanos because time.Parse would have
 	// covered that already.
 	secs := t.Unix()
 	if secs < minTimestampSeconds || secs > maxTimestampSeconds {
-		return d.newError(tok.Pos(), "google.protobuf.Timestamp value out of range: %v", tok.RawString())
+		return d.invalidValueError(tok.Pos(), "google.protobuf.Timestamp value out of range: %v", tok.RawString())
 	}
 
 	fds := m.Descriptor().Fields()
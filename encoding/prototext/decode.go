@@ -6,11 +6,13 @@ package prototext
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"unicode/utf8"
 
 	"google.golang.org/protobuf/internal/encoding/messageset"
 	"google.golang.org/protobuf/internal/encoding/text"
+	"google.golang.org/protobuf/internal/encoding/wire"
 	"google.golang.org/protobuf/internal/errors"
 	"google.golang.org/protobuf/internal/fieldnum"
 	"google.golang.org/protobuf/internal/flags"
@@ -41,6 +43,18 @@ type UnmarshalOptions struct {
 	// By default, unmarshal rejects unknown fields as an error.
 	DiscardUnknown bool
 
+	// AllowFieldNumbers specifies whether to accept fields identified by
+	// field number, such as "12: 5". A field number that resolves to a known
+	// or extension field is parsed as that field. Otherwise, unless
+	// DiscardUnknown is set, the value is stored in the unknown fields of the
+	// message with a wire type inferred from its representation: strings as
+	// length-delimited, hexadecimal integers of up to 8 digits as fixed32 and
+	// longer ones as fixed64, other integers and booleans as varint,
+	// floating-point numbers as fixed64 and messages as groups. Lists yield
+	// one unknown field per element. Unknown fields written with
+	// MarshalOptions.EmitUnknown are thereby parsed back losslessly.
+	AllowFieldNumbers bool
+
 	// Resolver is used for looking up types when unmarshaling
 	// google.protobuf.Any messages or extension fields.
 	// If nil, this defaults to using protoregistry.GlobalTypes.
@@ -48,6 +62,11 @@ type UnmarshalOptions struct {
 		protoregistry.MessageTypeResolver
 		protoregistry.ExtensionTypeResolver
 	}
+
+	// Files is used for looking up the message named by the schema header of
+	// a document in UnmarshalWithHeader.
+	// If nil, this defaults to using protoregistry.GlobalFiles.
+	Files *protoregistry.Files
 }
 
 // Unmarshal reads the given []byte and populates the given proto.Message using options in
@@ -199,18 +218,19 @@ func (d decoder) unmarshalMessage(m pref.Message, checkDelims bool) error {
 				d.skipValue()
 				continue
 			}
+			if isFieldNumberName && d.opts.AllowFieldNumbers {
+				b, err := d.unmarshalUnknownField(tok)
+				if err != nil {
+					return err
+				}
+				m.SetUnknown(append(m.GetUnknown(), b...))
+				continue
+			}
 			return d.newError(tok.Pos(), "unknown field: %v", tok.RawString())
 		}
 
 		// Handle fields identified by field number.
-		if isFieldNumberName {
-			// TODO: Add an option to permit parsing field numbers.
-			//
-			// This requires careful thought as the MarshalOptions.EmitUnknown
-			// option allows formatting unknown fields as the field number and the
-			// best-effort textual representation of the field value.  In that case,
-			// it may not be possible to unmarshal the value from a parser that does
-			// have information about the unknown field.
+		if isFieldNumberName && !d.opts.AllowFieldNumbers {
 			return d.newError(tok.Pos(), "cannot specify field by number: %v", tok.RawString())
 		}
 
@@ -262,6 +282,114 @@ func (d decoder) unmarshalMessage(m pref.Message, checkDelims bool) error {
 	return nil
 }
 
+// unmarshalUnknownField unmarshals the value of the unknown field named by the
+// field number token tok and returns its wire encoding.
+func (d decoder) unmarshalUnknownField(tok text.Token) ([]byte, error) {
+	num := pref.FieldNumber(tok.FieldNumber())
+	val, err := d.Read()
+	if err != nil {
+		return nil, err
+	}
+	if val.Kind() == text.Scalar && !tok.HasSeparator() {
+		return nil, d.newError(tok.Pos(), "missing field separator :")
+	}
+	if val.Kind() != text.ListOpen {
+		return d.unmarshalUnknown(num, val)
+	}
+
+	var b []byte
+	for {
+		val, err := d.Read()
+		if err != nil {
+			return nil, err
+		}
+		if val.Kind() == text.ListClose {
+			return b, nil
+		}
+		v, err := d.unmarshalUnknown(num, val)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, v...)
+	}
+}
+
+// unmarshalUnknown returns the wire encoding of the unknown field num with the
+// value starting at token tok, inferring the wire type from the representation
+// of the value.
+func (d decoder) unmarshalUnknown(num pref.FieldNumber, tok text.Token) ([]byte, error) {
+	switch tok.Kind() {
+	case text.Scalar:
+		// Handled below.
+
+	case text.MessageOpen:
+		b := wire.AppendTag(nil, num, wire.StartGroupType)
+		for {
+			tok, err := d.Read()
+			if err != nil {
+				return nil, err
+			}
+			switch tok.Kind() {
+			case text.MessageClose:
+				return wire.AppendTag(b, num, wire.EndGroupType), nil
+			case text.Name:
+				// Continue below.
+			default:
+				return nil, d.unexpectedTokenError(tok)
+			}
+			if tok.NameKind() != text.FieldNumber {
+				return nil, d.newError(tok.Pos(), "unknown field: %v", tok.RawString())
+			}
+			if n := pref.FieldNumber(tok.FieldNumber()); !n.IsValid() {
+				return nil, d.newError(tok.Pos(), "invalid field number: %d", n)
+			}
+			v, err := d.unmarshalUnknownField(tok)
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, v...)
+		}
+
+	default:
+		return nil, d.unexpectedTokenError(tok)
+	}
+
+	if s, ok := tok.String(); ok {
+		b := wire.AppendTag(nil, num, wire.BytesType)
+		return wire.AppendString(b, s), nil
+	}
+	raw := tok.RawString()
+	if strings.HasPrefix(raw, "0x") || strings.HasPrefix(raw, "0X") {
+		if n, ok := tok.Uint64(); ok {
+			// MarshalOptions.EmitUnknown pads fixed32 values to 8 digits and
+			// fixed64 values to 16 digits.
+			if len(raw) <= len("0x")+8 {
+				b := wire.AppendTag(nil, num, wire.Fixed32Type)
+				return wire.AppendFixed32(b, uint32(n)), nil
+			}
+			b := wire.AppendTag(nil, num, wire.Fixed64Type)
+			return wire.AppendFixed64(b, n), nil
+		}
+	}
+	if n, ok := tok.Uint64(); ok {
+		b := wire.AppendTag(nil, num, wire.VarintType)
+		return wire.AppendVarint(b, n), nil
+	}
+	if n, ok := tok.Int64(); ok {
+		b := wire.AppendTag(nil, num, wire.VarintType)
+		return wire.AppendVarint(b, uint64(n)), nil
+	}
+	if v, ok := tok.Bool(); ok {
+		b := wire.AppendTag(nil, num, wire.VarintType)
+		return wire.AppendVarint(b, wire.EncodeBool(v)), nil
+	}
+	if f, ok := tok.Float64(); ok {
+		b := wire.AppendTag(nil, num, wire.Fixed64Type)
+		return wire.AppendFixed64(b, math.Float64bits(f)), nil
+	}
+	return nil, d.newError(tok.Pos(), "invalid value for unknown field %d: %v", num, raw)
+}
+
 // findExtension returns protoreflect.ExtensionType from the Resolver if found.
 func (d decoder) findExtension(xtName pref.FullName) (pref.ExtensionType, error) {
 	xt, err := d.opts.Resolver.FindExtensionByName(xtName)
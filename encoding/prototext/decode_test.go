@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/internal/encoding/pack"
 	"google.golang.org/protobuf/internal/flags"
 	"google.golang.org/protobuf/proto"
 	preg "google.golang.org/protobuf/reflect/protoregistry"
@@ -206,6 +207,69 @@ s_string: "谷歌"
 		inputMessage: &pb3.Scalars{},
 		inputText:    "1: true",
 		wantErr:      "cannot specify field by number",
+	}, {
+		desc:         "AllowFieldNumbers: known fields",
+		umo:          prototext.UnmarshalOptions{AllowFieldNumbers: true},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `1: true 13: "hello"`,
+		wantMessage: &pb2.Scalars{
+			OptBool:   proto.Bool(true),
+			OptString: proto.String("hello"),
+		},
+	}, {
+		desc:         "AllowFieldNumbers: unknown fields",
+		umo:          prototext.UnmarshalOptions{AllowFieldNumbers: true},
+		inputMessage: &pb2.Scalars{},
+		inputText: `
+101: 255
+102: 0x00000047
+103: 0x00000000deadbeef
+104: "hello"
+105: {1: -1 2: {}}
+106: [1, true]
+107: 1.5
+`,
+		wantMessage: func() proto.Message {
+			m := &pb2.Scalars{}
+			m.ProtoReflect().SetUnknown(pack.Message{
+				pack.Tag{101, pack.VarintType}, pack.Varint(255),
+				pack.Tag{102, pack.Fixed32Type}, pack.Uint32(0x47),
+				pack.Tag{103, pack.Fixed64Type}, pack.Uint64(0xdeadbeef),
+				pack.Tag{104, pack.BytesType}, pack.String("hello"),
+				pack.Tag{105, pack.StartGroupType},
+				pack.Tag{1, pack.VarintType}, pack.Varint(-1),
+				pack.Tag{2, pack.StartGroupType}, pack.Tag{2, pack.EndGroupType},
+				pack.Tag{105, pack.EndGroupType},
+				pack.Tag{106, pack.VarintType}, pack.Varint(1),
+				pack.Tag{106, pack.VarintType}, pack.Bool(true),
+				pack.Tag{107, pack.Fixed64Type}, pack.Float64(1.5),
+			}.Marshal())
+			return m
+		}(),
+	}, {
+		desc:         "AllowFieldNumbers with DiscardUnknown",
+		umo:          prototext.UnmarshalOptions{AllowFieldNumbers: true, DiscardUnknown: true},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `101: 255 13: "hello"`,
+		wantMessage:  &pb2.Scalars{OptString: proto.String("hello")},
+	}, {
+		desc:         "AllowFieldNumbers: field name in unknown group",
+		umo:          prototext.UnmarshalOptions{AllowFieldNumbers: true},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `101: {name: 1}`,
+		wantErr:      "unknown field: name",
+	}, {
+		desc:         "AllowFieldNumbers: unknown field with enum literal",
+		umo:          prototext.UnmarshalOptions{AllowFieldNumbers: true},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `101: ONE`,
+		wantErr:      "invalid value for unknown field 101: ONE",
+	}, {
+		desc:         "AllowFieldNumbers: unknown field missing separator",
+		umo:          prototext.UnmarshalOptions{AllowFieldNumbers: true},
+		inputMessage: &pb2.Scalars{},
+		inputText:    `101 1`,
+		wantErr:      "missing field separator",
 	}, {
 		desc:         "invalid bool value",
 		inputMessage: &pb3.Scalars{},
@@ -61,10 +61,44 @@ type MarshalOptions struct {
 	AllowPartial bool
 
 	// EmitUnknown specifies whether to emit unknown fields in the output.
-	// If specified, the unmarshaler may be unable to parse the output.
+	// The output can be parsed with UnmarshalOptions.AllowFieldNumbers.
 	// The default is to exclude unknown fields.
 	EmitUnknown bool
 
+	// EmitComments specifies whether to precede fields with the leading
+	// comments of their declarations, as recorded in the source locations of
+	// the file descriptors, and fields of enum type additionally with the
+	// leading comments of their values. Comments are only emitted in Multiline
+	// output.
+	EmitComments bool
+
+	// EmitHeader specifies whether to begin the output with a schema header
+	// naming the file and the full name of the message type (see Header).
+	EmitHeader bool
+
+	// EmitASCII specifies whether to escape non-ASCII characters in string
+	// and bytes values, such that the output is ASCII. By default, valid
+	// UTF-8 is written unescaped.
+	EmitASCII bool
+
+	// EscapeOctal specifies whether to write escaped bytes as three-digit
+	// octal escape sequences, such as \303, instead of hexadecimal ones,
+	// such as \xc3. Single quotes and DEL characters are escaped as well,
+	// bytes values are escaped as binary data with every non-ASCII byte
+	// escaped, and non-ASCII characters escaped due to EmitASCII are escaped
+	// byte by byte. This matches the escaping of the C++ TextFormat printer,
+	// which corresponds to EmitASCII unless UTF-8 escaping is enabled.
+	EscapeOctal bool
+
+	// FloatPrecision and DoublePrecision specify the number of significant
+	// digits to write float and double values with. A value that does not
+	// parse back to itself at that precision is written with 9 or 17 digits
+	// respectively. If zero (the default), values are written with the fewest
+	// digits that parse back to the same value. The C++ TextFormat printer
+	// uses a precision of 6 for floats and 15 for doubles.
+	FloatPrecision  int
+	DoublePrecision int
+
 	// Resolver is used for looking up types when expanding google.protobuf.Any
 	// messages. If nil, this defaults to using protoregistry.GlobalTypes.
 	Resolver interface {
@@ -91,7 +125,6 @@ func (o MarshalOptions) Format(m proto.Message) string {
 // MarshalOptions object. Do not depend on the output being stable. It may
 // change over time across different versions of the program.
 func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
-	const outputASCII = false
 	var delims = [2]byte{'{', '}'}
 
 	if o.Multiline && o.Indent == "" {
@@ -101,12 +134,17 @@ func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
 		o.Resolver = protoregistry.GlobalTypes
 	}
 
-	internalEnc, err := text.NewEncoder(o.Indent, delims, outputASCII)
+	internalEnc, err := text.NewEncoder(o.Indent, delims, text.EncoderOptions{
+		OutputASCII:     o.EmitASCII,
+		OctalEscapes:    o.EscapeOctal,
+		FloatPrecision:  o.FloatPrecision,
+		DoublePrecision: o.DoublePrecision,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	enc := encoder{internalEnc, o}
+	enc := encoder{internalEnc, o, o.EmitComments && o.Indent != ""}
 	err = enc.marshalMessage(m.ProtoReflect(), false)
 	if err != nil {
 		return nil, err
@@ -115,6 +153,9 @@ func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
 	if len(o.Indent) > 0 && len(out) > 0 {
 		out = append(out, '\n')
 	}
+	if o.EmitHeader {
+		out = append(appendHeader(nil, m.ProtoReflect().Descriptor()), out...)
+	}
 	if o.AllowPartial {
 		return out, nil
 	}
@@ -123,7 +164,8 @@ func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
 
 type encoder struct {
 	*text.Encoder
-	opts MarshalOptions
+	opts     MarshalOptions
+	comments bool // whether comments are emitted
 }
 
 // marshalMessage marshals the given protoreflect.Message.
@@ -188,12 +230,14 @@ func (e encoder) marshalMessage(m pref.Message, inclDelims bool) error {
 
 // marshalField marshals the given field with protoreflect.Value.
 func (e encoder) marshalField(name string, val pref.Value, fd pref.FieldDescriptor) error {
+	e.writeComment(fd)
 	switch {
 	case fd.IsList():
 		return e.marshalList(name, val.List(), fd)
 	case fd.IsMap():
 		return e.marshalMap(name, val.Map(), fd)
 	default:
+		e.writeEnumComment(val, fd)
 		e.WriteName(name)
 		return e.marshalSingular(val, fd)
 	}
@@ -232,7 +276,7 @@ func (e encoder) marshalSingular(val pref.Value, fd pref.FieldDescriptor) error
 		e.WriteFloat(val.Float(), 64)
 
 	case pref.BytesKind:
-		e.WriteString(string(val.Bytes()))
+		e.WriteBytes(val.Bytes())
 
 	case pref.EnumKind:
 		num := val.Enum()
@@ -256,6 +300,7 @@ func (e encoder) marshalSingular(val pref.Value, fd pref.FieldDescriptor) error
 func (e encoder) marshalList(name string, list pref.List, fd pref.FieldDescriptor) error {
 	size := list.Len()
 	for i := 0; i < size; i++ {
+		e.writeEnumComment(list.Get(i), fd)
 		e.WriteName(name)
 		if err := e.marshalSingular(list.Get(i), fd); err != nil {
 			return err
@@ -334,7 +379,6 @@ func (e encoder) marshalExtensions(m pref.Message) error {
 // This function assumes proper encoding in the given []byte.
 func (e encoder) marshalUnknown(b []byte) {
 	const dec = 10
-	const hex = 16
 	for len(b) > 0 {
 		num, wtype, n := wire.ConsumeTag(b)
 		b = b[n:]
@@ -348,15 +392,15 @@ func (e encoder) marshalUnknown(b []byte) {
 		case wire.Fixed32Type:
 			var v uint32
 			v, n = wire.ConsumeFixed32(b)
-			e.WriteLiteral("0x" + strconv.FormatUint(uint64(v), hex))
+			e.WriteLiteral(fmt.Sprintf("0x%08x", v))
 		case wire.Fixed64Type:
 			var v uint64
 			v, n = wire.ConsumeFixed64(b)
-			e.WriteLiteral("0x" + strconv.FormatUint(v, hex))
+			e.WriteLiteral(fmt.Sprintf("0x%016x", v))
 		case wire.BytesType:
 			var v []byte
 			v, n = wire.ConsumeBytes(b)
-			e.WriteString(string(v))
+			e.WriteBytes(v)
 		case wire.StartGroupType:
 			e.StartMessage()
 			var v []byte
@@ -408,3 +452,29 @@ func (e encoder) marshalAny(any pref.Message) bool {
 	}
 	return true
 }
+
+// writeComment writes the leading comments of the declaration of d, if
+// comments are emitted.
+func (e encoder) writeComment(d pref.Descriptor) {
+	if !e.comments {
+		return
+	}
+	file := d.ParentFile()
+	if file == nil {
+		return
+	}
+	if c := file.SourceLocations().ByDescriptor(d).LeadingComments; c != "" {
+		e.WriteComment(c)
+	}
+}
+
+// writeEnumComment writes the leading comments of the declaration of the
+// enum value val of the field fd, if fd is of enum type.
+func (e encoder) writeEnumComment(val pref.Value, fd pref.FieldDescriptor) {
+	if !e.comments || fd.Kind() != pref.EnumKind {
+		return
+	}
+	if ev := fd.Enum().Values().ByNumber(val.Enum()); ev != nil {
+		e.writeComment(ev)
+	}
+}
@@ -15,8 +15,12 @@ import (
 	"google.golang.org/protobuf/internal/encoding/pack"
 	"google.golang.org/protobuf/internal/flags"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	preg "google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 
+	test3pb "google.golang.org/protobuf/internal/testprotos/test3"
 	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
 	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -98,6 +102,45 @@ opt_string: ""
 			SString:   "",
 		},
 		want: "",
+	}, {
+		desc: "proto3 optional set to zero values",
+		input: &test3pb.TestProto3Optional{
+			OptionalInt32:         proto.Int32(0),
+			OptionalInt64:         proto.Int64(0),
+			OptionalUint32:        proto.Uint32(0),
+			OptionalUint64:        proto.Uint64(0),
+			OptionalSint32:        proto.Int32(0),
+			OptionalSint64:        proto.Int64(0),
+			OptionalFixed32:       proto.Uint32(0),
+			OptionalFixed64:       proto.Uint64(0),
+			OptionalSfixed32:      proto.Int32(0),
+			OptionalSfixed64:      proto.Int64(0),
+			OptionalFloat:         proto.Float32(0),
+			OptionalDouble:        proto.Float64(0),
+			OptionalBool:          proto.Bool(false),
+			OptionalString:        proto.String(""),
+			OptionalBytes:         []byte{},
+			OptionalNestedMessage: &test3pb.TestAllTypes_NestedMessage{},
+			OptionalNestedEnum:    test3pb.TestAllTypes_FOO.Enum(),
+		},
+		want: `optional_int32: 0
+optional_int64: 0
+optional_uint32: 0
+optional_uint64: 0
+optional_sint32: 0
+optional_sint64: 0
+optional_fixed32: 0
+optional_fixed64: 0
+optional_sfixed32: 0
+optional_sfixed64: 0
+optional_float: 0
+optional_double: 0
+optional_bool: false
+optional_string: ""
+optional_bytes: ""
+optional_nested_message: {}
+optional_nested_enum: FOO
+`,
 	}, {
 		desc: "proto2 optional scalars set to some values",
 		input: &pb2.Scalars{
@@ -135,6 +178,53 @@ opt_string: "谷歌"
 			SString: "abc\xff",
 		},
 		wantErr: true,
+	}, {
+		desc: "ASCII output",
+		mo:   prototext.MarshalOptions{EmitASCII: true},
+		input: &pb2.Scalars{
+			OptBytes:  []byte("\xe8\xb0\xb7\xe6\xad\x8c\xff"),
+			OptString: proto.String("谷歌'"),
+		},
+		want: `opt_bytes: "\u8c37\u6b4c\xff"
+opt_string: "\u8c37\u6b4c'"
+`,
+	}, {
+		desc: "octal escapes",
+		mo:   prototext.MarshalOptions{EscapeOctal: true},
+		input: &pb2.Scalars{
+			OptBytes:  []byte("\xe8\xb0\xb7\xe6\xad\x8c\xff"),
+			OptString: proto.String("谷歌'"),
+		},
+		want: `opt_bytes: "\350\260\267\346\255\214\377"
+opt_string: "谷歌\'"
+`,
+	}, {
+		desc: "octal escapes with ASCII output",
+		mo:   prototext.MarshalOptions{EmitASCII: true, EscapeOctal: true},
+		input: &pb2.Scalars{
+			OptBytes:  []byte("\xe8\xb0\xb7\xe6\xad\x8c\xff"),
+			OptString: proto.String("谷歌'"),
+		},
+		want: `opt_bytes: "\350\260\267\346\255\214\377"
+opt_string: "\350\260\267\346\255\214\'"
+`,
+	}, {
+		desc: "float precision",
+		mo:   prototext.MarshalOptions{FloatPrecision: 6, DoublePrecision: 15},
+		input: &pb2.Scalars{
+			OptFloat:  proto.Float32(1.02),
+			OptDouble: proto.Float64(1e6),
+		},
+		want: `opt_float: 1.02
+opt_double: 1000000
+`,
+	}, {
+		desc: "double precision exceeded",
+		mo:   prototext.MarshalOptions{DoublePrecision: 15},
+		input: &pb2.Scalars{
+			OptDouble: proto.Float64(1.0199999809265137),
+		},
+		want: "opt_double: 1.0199999809265137\n",
 	}, {
 		desc: "float nan",
 		input: &pb3.Scalars{
@@ -854,8 +944,8 @@ req_nested: {}
 		want: `opt_string: "this message contains unknown fields"
 101: 1
 102: 255
-103: 0x47
-104: 0xdeadbeef
+103: 0x00000047
+104: 0x00000000deadbeef
 `,
 	}, {
 		desc: "unknown length-delimited",
@@ -1321,3 +1411,67 @@ value: "\x80"
 		})
 	}
 }
+
+func TestMarshalComments(t *testing.T) {
+	fdp := new(descriptorpb.FileDescriptorProto)
+	if err := prototext.Unmarshal([]byte(`
+		name: "comments.proto"
+		package: "test"
+		message_type {
+			name: "Config"
+			field { name: "name" number: 1 label: LABEL_OPTIONAL type: TYPE_STRING }
+			field { name: "level" number: 2 label: LABEL_REPEATED type: TYPE_ENUM type_name: ".test.Config.Level" }
+			field { name: "sub" number: 3 label: LABEL_OPTIONAL type: TYPE_MESSAGE type_name: ".test.Config" }
+			enum_type {
+				name: "Level"
+				value { name: "LOW" number: 0 }
+				value { name: "HIGH" number: 1 }
+			}
+		}
+		source_code_info {
+			location { path: [4, 0, 2, 0] span: [0, 0, 1] leading_comments: " The name.\n" }
+			location { path: [4, 0, 2, 1] span: [0, 0, 1] leading_comments: " Levels,\n in order.\n" }
+			location { path: [4, 0, 4, 0, 2, 1] span: [0, 0, 1] leading_comments: " Very high.\n" }
+			location { path: [4, 0, 2, 2] span: [0, 0, 1] trailing_comments: " Not emitted.\n" }
+		}
+	`), fdp); err != nil {
+		t.Fatalf("unmarshal descriptor: %v", err)
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile: %v", err)
+	}
+	m := dynamicpb.NewMessage(fd.Messages().Get(0))
+	if err := prototext.Unmarshal([]byte(`name: "a" level: [HIGH, LOW] sub { name: "b" }`), m); err != nil {
+		t.Fatalf("unmarshal message: %v", err)
+	}
+
+	got, err := prototext.MarshalOptions{Multiline: true, EmitComments: true}.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	want := `# The name.
+name: "a"
+# Levels,
+# in order.
+# Very high.
+level: HIGH
+level: LOW
+sub: {
+  # The name.
+  name: "b"
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Marshal() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Comments are not emitted in single-line output.
+	got, err = prototext.MarshalOptions{EmitComments: true}.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	if want := `name:"a" level:HIGH level:LOW sub:{name:"b"}`; string(got) != want {
+		t.Errorf("Marshal() got %q, want %q", got, want)
+	}
+}
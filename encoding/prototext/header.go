@@ -0,0 +1,164 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prototext
+
+import (
+	"bytes"
+
+	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Header is the schema header of a text format document, which consists of
+// directives in the comments at the top of the document that name the message
+// type of its contents:
+//
+//	# proto-file: path/to/file.proto
+//	# proto-message: pkg.Message
+//	# proto-import: path/to/extensions.proto
+//
+// The message name may be relative to the package of the file.
+type Header struct {
+	// File is the path of the file declaring the message.
+	File string
+
+	// Message is the name of the message.
+	Message string
+
+	// Imports are the paths of other files needed to interpret the
+	// document, such as files declaring extensions.
+	Imports []string
+}
+
+const (
+	fileDirective    = "proto-file"
+	messageDirective = "proto-message"
+	importDirective  = "proto-import"
+)
+
+// ParseHeader returns the schema header of the text format document b, as
+// read from the comments preceding its first field.
+func ParseHeader(b []byte) (Header, error) {
+	var h Header
+	for len(b) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(b, '\n'); i >= 0 {
+			line, b = b[:i], b[i+1:]
+		} else {
+			line, b = b, nil
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != '#' {
+			break
+		}
+
+		i := bytes.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key := string(bytes.TrimSpace(line[1:i]))
+		val := string(bytes.TrimSpace(line[i+1:]))
+		switch key {
+		case fileDirective:
+			if h.File != "" {
+				return Header{}, errors.New("duplicate %s directive", key)
+			}
+			h.File = val
+		case messageDirective:
+			if h.Message != "" {
+				return Header{}, errors.New("duplicate %s directive", key)
+			}
+			h.Message = val
+		case importDirective:
+			h.Imports = append(h.Imports, val)
+		}
+	}
+	return h, nil
+}
+
+// FindMessage returns the descriptor of the message named by the header in
+// files. If the header names a file, the message must be declared in it, and
+// a relative message name is resolved in the package of the file.
+func (h Header) FindMessage(files *protoregistry.Files) (pref.MessageDescriptor, error) {
+	if h.Message == "" {
+		return nil, errors.New("missing %s directive", messageDirective)
+	}
+	for _, path := range h.Imports {
+		if _, err := files.FindFileByPath(path); err != nil {
+			return nil, errors.New("unable to find imported file %q: %v", path, err)
+		}
+	}
+
+	names := []pref.FullName{pref.FullName(h.Message)}
+	if h.File != "" {
+		fd, err := files.FindFileByPath(h.File)
+		if err != nil {
+			return nil, errors.New("unable to find file %q: %v", h.File, err)
+		}
+		if pkg := fd.Package(); pkg != "" {
+			names = append(names, pkg.Append(pref.Name(h.Message)))
+		}
+	}
+	for _, name := range names {
+		d, err := files.FindDescriptorByName(name)
+		if err != nil {
+			continue
+		}
+		md, ok := d.(pref.MessageDescriptor)
+		if !ok || (h.File != "" && md.ParentFile().Path() != h.File) {
+			continue
+		}
+		return md, nil
+	}
+	if h.File != "" {
+		return nil, errors.New("unable to find message %s in file %q", h.Message, h.File)
+	}
+	return nil, errors.New("unable to find message %s", h.Message)
+}
+
+// appendHeader appends the schema header of messages of type md to b.
+func appendHeader(b []byte, md pref.MessageDescriptor) []byte {
+	if file := md.ParentFile(); file != nil {
+		b = append(b, "# "+fileDirective+": "+file.Path()+"\n"...)
+	}
+	return append(b, "# "+messageDirective+": "+string(md.FullName())+"\n"...)
+}
+
+// UnmarshalWithHeader reads a text format document whose message type is
+// named by its schema header, and returns its contents as a message of that
+// type. The message descriptor is looked up in Files and the message type
+// with Resolver, such that a dynamicpb.Types resolver over the same Files
+// produces dynamic messages, for instance for files of a descriptor set.
+func (o UnmarshalOptions) UnmarshalWithHeader(b []byte) (proto.Message, error) {
+	if o.Files == nil {
+		o.Files = protoregistry.GlobalFiles
+	}
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+
+	h, err := ParseHeader(b)
+	if err != nil {
+		return nil, err
+	}
+	md, err := h.FindMessage(o.Files)
+	if err != nil {
+		return nil, err
+	}
+	mt, err := o.Resolver.FindMessageByName(md.FullName())
+	if err != nil {
+		return nil, errors.New("unable to resolve message type %v: %v", md.FullName(), err)
+	}
+	m := mt.New().Interface()
+	if err := o.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prototext_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	preg "google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   string
+		want    prototext.Header
+		wantErr string // Expected error substring.
+	}{{
+		desc:  "no header",
+		input: "opt_int32: 1\n",
+	}, {
+		desc: "directives",
+		input: `
+# Configuration of the frobnicator.
+#
+# proto-file: path/to/file.proto
+#proto-message:Config
+# proto-import: a.proto
+# proto-import: b.proto
+
+opt_int32: 1
+`,
+		want: prototext.Header{
+			File:    "path/to/file.proto",
+			Message: "Config",
+			Imports: []string{"a.proto", "b.proto"},
+		},
+	}, {
+		desc:  "directives after first field",
+		input: "# proto-message: A\nopt_int32: 1\n# proto-file: a.proto\n",
+		want:  prototext.Header{Message: "A"},
+	}, {
+		desc:    "duplicate directive",
+		input:   "# proto-message: A\n# proto-message: B\n",
+		wantErr: "duplicate proto-message directive",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := prototext.ParseHeader([]byte(tt.input))
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseHeader() error got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("ParseHeader() got nil error, want error %q", tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseHeader() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalWithHeader(t *testing.T) {
+	const file = "internal/testprotos/textpb2/test.proto"
+	dynamicTypes := dynamicpb.NewTypes(preg.GlobalFiles)
+	tests := []struct {
+		desc        string
+		umo         prototext.UnmarshalOptions
+		input       string
+		want        proto.Message
+		wantDynamic bool
+		wantErr     string // Expected error substring.
+	}{{
+		desc:  "relative name",
+		input: "# proto-file: " + file + "\n# proto-message: Nested\nopt_string: \"a\"\n",
+		want:  &pb2.Nested{OptString: proto.String("a")},
+	}, {
+		desc:  "full name without file",
+		input: "# proto-message: pb2.Nested\nopt_string: \"a\"\n",
+		want:  &pb2.Nested{OptString: proto.String("a")},
+	}, {
+		desc:        "dynamic types",
+		umo:         prototext.UnmarshalOptions{Resolver: dynamicTypes},
+		input:       "# proto-file: " + file + "\n# proto-message: Extensions\n[pb2.opt_ext_string]: \"x\"\n",
+		want:        extensionsWithString("x"),
+		wantDynamic: true,
+	}, {
+		desc:    "missing message directive",
+		input:   "# proto-file: " + file + "\n",
+		wantErr: "missing proto-message directive",
+	}, {
+		desc:    "unknown file",
+		input:   "# proto-file: unknown.proto\n# proto-message: pb2.Nested\n",
+		wantErr: `unable to find file "unknown.proto"`,
+	}, {
+		desc:    "message not in file",
+		input:   "# proto-file: " + file + "\n# proto-message: google.protobuf.Any\n",
+		wantErr: "unable to find message google.protobuf.Any in file",
+	}, {
+		desc:    "unknown import",
+		input:   "# proto-message: pb2.Nested\n# proto-import: unknown.proto\n",
+		wantErr: `unable to find imported file "unknown.proto"`,
+	}, {
+		desc:    "invalid contents",
+		input:   "# proto-message: pb2.Nested\nunknown: 1\n",
+		wantErr: "unknown field: unknown",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.umo.UnmarshalWithHeader([]byte(tt.input))
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("UnmarshalWithHeader() error got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("UnmarshalWithHeader() got nil error, want error %q", tt.wantErr)
+			}
+			if _, ok := got.(*dynamicpb.Message); ok != tt.wantDynamic {
+				t.Errorf("UnmarshalWithHeader() got message of type %T", got)
+			}
+			// Compare through the wire format to allow for dynamic messages.
+			gotb, _ := proto.MarshalOptions{Deterministic: true}.Marshal(got)
+			wantb, _ := proto.MarshalOptions{Deterministic: true}.Marshal(tt.want)
+			if string(gotb) != string(wantb) {
+				t.Errorf("UnmarshalWithHeader()\n<got>\n%v\n<want>\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func extensionsWithString(s string) proto.Message {
+	m := &pb2.Extensions{}
+	proto.SetExtension(m, pb2.E_OptExtString, s)
+	return m
+}
+
+func TestMarshalHeader(t *testing.T) {
+	m := &pb2.Nested{OptString: proto.String("a")}
+	b, err := prototext.MarshalOptions{Multiline: true, EmitHeader: true}.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	want := "# proto-file: internal/testprotos/textpb2/test.proto\n# proto-message: pb2.Nested\nopt_string: \"a\"\n"
+	if string(b) != want {
+		t.Errorf("Marshal() mismatch (-want +got):\n%s", cmp.Diff(want, string(b)))
+	}
+
+	got, err := prototext.UnmarshalOptions{}.UnmarshalWithHeader(b)
+	if err != nil {
+		t.Fatalf("UnmarshalWithHeader() error: %v", err)
+	}
+	if !proto.Equal(got, m) {
+		t.Errorf("UnmarshalWithHeader()\n<got>\n%v\n<want>\n%v", got, m)
+	}
+}
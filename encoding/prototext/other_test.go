@@ -5,12 +5,14 @@
 package prototext_test
 
 import (
+	"bytes"
 	"testing"
 
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 	preg "google.golang.org/protobuf/reflect/protoregistry"
 
+	"google.golang.org/protobuf/internal/encoding/pack"
 	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -236,3 +238,30 @@ func TestRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestRoundTripUnknown(t *testing.T) {
+	m := &pb2.Scalars{OptString: proto.String("known")}
+	m.ProtoReflect().SetUnknown(pack.Message{
+		pack.Tag{101, pack.VarintType}, pack.Varint(-1),
+		pack.Tag{102, pack.Fixed32Type}, pack.Uint32(1),
+		pack.Tag{103, pack.Fixed64Type}, pack.Uint64(1),
+		pack.Tag{104, pack.BytesType}, pack.Bytes("\x00\xff世界"),
+		pack.Tag{105, pack.StartGroupType},
+		pack.Tag{1, pack.Fixed32Type}, pack.Float32(1.5),
+		pack.Tag{2, pack.BytesType}, pack.LengthPrefix{pack.Bool(true)},
+		pack.Tag{105, pack.EndGroupType},
+		pack.Tag{101, pack.VarintType}, pack.Varint(0),
+	}.Marshal())
+
+	b, err := prototext.MarshalOptions{EmitUnknown: true}.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %v", err)
+	}
+	got := new(pb2.Scalars)
+	if err := (prototext.UnmarshalOptions{AllowFieldNumbers: true}).Unmarshal(b, got); err != nil {
+		t.Fatalf("Unmarshal() returned error: %v\n<input>\n%s", err, b)
+	}
+	if !proto.Equal(got, m) || !bytes.Equal(got.ProtoReflect().GetUnknown(), m.ProtoReflect().GetUnknown()) {
+		t.Errorf("Unmarshal()\n<got>\n%v\n<want>\n%v\n<input>\n%s", got, m, b)
+	}
+}
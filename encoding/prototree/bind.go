@@ -0,0 +1,541 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prototree
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/internal/encoding/text"
+	"google.golang.org/protobuf/internal/encoding/wire"
+	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/internal/fieldnum"
+	"google.golang.org/protobuf/internal/pragma"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Bind populates m from the tree t according to the descriptor of m.
+func Bind(t *Message, m proto.Message) error {
+	return BindOptions{}.Bind(t, m)
+}
+
+// BindOptions is a configurable binder of trees to messages.
+type BindOptions struct {
+	pragma.NoUnkeyedLiterals
+
+	// AllowPartial accepts trees that result in messages with missing
+	// required fields. If AllowPartial is false (the default), Bind will
+	// return an error if there are any missing required fields.
+	AllowPartial bool
+
+	// DiscardUnknown specifies whether to ignore fields that do not resolve
+	// to any known or extension field in the message. Otherwise, unknown
+	// fields identified by number are stored in the unknown fields of the
+	// message with the wire types of their values, and unknown fields
+	// identified by name are an error.
+	DiscardUnknown bool
+
+	// Resolver is used for looking up extension fields and the message types
+	// of expanded google.protobuf.Any messages.
+	// If nil, this defaults to using protoregistry.GlobalTypes.
+	Resolver interface {
+		protoregistry.MessageTypeResolver
+		protoregistry.ExtensionTypeResolver
+	}
+}
+
+// Bind populates m from the tree t according to the descriptor of m using
+// options in BindOptions. Values are applied in the order in which they
+// appear. As in the wire format, the last value of a singular scalar field
+// or oneof wins, while the values of a singular message field are merged.
+// As in prototext, a tree from the text format with more than one member of
+// a oneof is rejected.
+func (o BindOptions) Bind(t *Message, m proto.Message) error {
+	proto.Reset(m)
+
+	if o.Resolver == nil {
+		o.Resolver = protoregistry.GlobalTypes
+	}
+	if err := o.bindMessage(t, m.ProtoReflect()); err != nil {
+		return err
+	}
+	if o.AllowPartial {
+		return nil
+	}
+	return proto.CheckInitialized(m)
+}
+
+// bindMessage merges the fields of t into m in the order in which they appear.
+func (o BindOptions) bindMessage(t *Message, m pref.Message) error {
+	md := m.Descriptor()
+	// As in prototext, the text format rejects multiple members of a oneof,
+	// while the last member wins in the wire format.
+	var seenOneofs map[pref.OneofDescriptor]pref.FieldDescriptor
+	for _, f := range t.Fields {
+		if md.FullName() == "google.protobuf.Any" && strings.Contains(f.Name, "/") {
+			if err := o.bindAny(f, m); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fd, err := o.findField(md, f)
+		if err != nil {
+			return err
+		}
+		if fd == nil {
+			if o.DiscardUnknown || md.ReservedNames().Has(pref.Name(f.Name)) {
+				continue
+			}
+			if f.Name != "" {
+				return errors.New("unknown field: %v", f.Name)
+			}
+			b, err := appendField(m.GetUnknown(), f)
+			if err != nil {
+				return err
+			}
+			m.SetUnknown(b)
+			continue
+		}
+		if od := fd.ContainingOneof(); od != nil && t.text {
+			if seen, ok := seenOneofs[od]; ok && seen != fd {
+				return errors.New("error parsing %q, oneof %v is already set", fieldName(f), od.FullName())
+			}
+			if seenOneofs == nil {
+				seenOneofs = make(map[pref.OneofDescriptor]pref.FieldDescriptor)
+			}
+			seenOneofs[od] = fd
+		}
+
+		for _, v := range f.Values {
+			var err error
+			switch {
+			case fd.IsList():
+				err = o.bindList(fd, v, m.Mutable(fd).List())
+			case fd.IsMap():
+				err = o.bindMapEntry(fd, v, m.Mutable(fd).Map())
+			case fd.Message() != nil:
+				err = o.bindMessageValue(v, m.Mutable(fd).Message())
+			default:
+				var val pref.Value
+				if val, err = bindScalar(fd, v); err == nil {
+					m.Set(fd, val)
+				}
+			}
+			if err != nil {
+				return errors.Wrap(err, "field %v", fd.FullName())
+			}
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name or number identifying f in the input.
+func fieldName(f *Field) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return strconv.Itoa(int(f.Number))
+}
+
+// findField returns the descriptor of the field f of messages of type md, or
+// nil if f is unknown.
+func (o BindOptions) findField(md pref.MessageDescriptor, f *Field) (pref.FieldDescriptor, error) {
+	fieldDescs := md.Fields()
+	var fd pref.FieldDescriptor
+	var xt pref.ExtensionType
+	var xtErr error
+	switch {
+	case strings.HasPrefix(f.Name, "["):
+		xt, xtErr = o.Resolver.FindExtensionByName(pref.FullName(strings.Trim(f.Name, "[]")))
+	case f.Name != "":
+		name := pref.Name(f.Name)
+		fd = fieldDescs.ByName(name)
+		if fd == nil {
+			// The proto name of a group field is in all lowercase,
+			// while the textproto field name is the group message name.
+			gd := fieldDescs.ByName(pref.Name(strings.ToLower(f.Name)))
+			if gd != nil && gd.Kind() == pref.GroupKind && gd.Message().Name() == name {
+				fd = gd
+			}
+		} else if fd.Kind() == pref.GroupKind && fd.Message().Name() != name {
+			fd = nil // reset since field name is actually the message name
+		}
+	default:
+		fd = fieldDescs.ByNumber(f.Number)
+		if fd == nil {
+			xt, xtErr = o.Resolver.FindExtensionByNumber(md.FullName(), f.Number)
+		}
+	}
+
+	if xt != nil {
+		fd = xt.TypeDescriptor()
+		if !md.ExtensionRanges().Has(fd.Number()) || fd.ContainingMessage().FullName() != md.FullName() {
+			return nil, errors.New("message %v cannot be extended by %v", md.FullName(), fd.FullName())
+		}
+	} else if xtErr != nil && xtErr != protoregistry.NotFound {
+		return nil, errors.New("unable to resolve %v: %v", f.Name, xtErr)
+	}
+	return fd, nil
+}
+
+// bindAny sets the google.protobuf.Any message m to the values of the
+// expanded field f, whose name holds the type URL.
+func (o BindOptions) bindAny(f *Field, m pref.Message) error {
+	typeURL := strings.Trim(f.Name, "[]")
+	mt, err := o.Resolver.FindMessageByURL(typeURL)
+	if err != nil {
+		return errors.New("unable to resolve %v: %v", f.Name, err)
+	}
+	for _, v := range f.Values {
+		mv := mt.New()
+		if err := o.bindMessageValue(v, mv); err != nil {
+			return errors.Wrap(err, "field %v", f.Name)
+		}
+		b, err := proto.MarshalOptions{
+			AllowPartial:  true, // completeness is checked by Bind
+			Deterministic: true,
+		}.Marshal(mv.Interface())
+		if err != nil {
+			return err
+		}
+		fds := m.Descriptor().Fields()
+		m.Set(fds.ByNumber(fieldnum.Any_TypeUrl), pref.ValueOfString(typeURL))
+		m.Set(fds.ByNumber(fieldnum.Any_Value), pref.ValueOfBytes(b))
+	}
+	return nil
+}
+
+// bindMessageValue merges the message value v into m.
+func (o BindOptions) bindMessageValue(v *Value, m pref.Message) error {
+	switch {
+	case v.Message != nil:
+		return o.bindMessage(v.Message, m)
+	case v.Kind == BytesKind && v.Text == "":
+		return proto.UnmarshalOptions{
+			AllowPartial: true, // completeness is checked by Bind
+			Merge:        true,
+			Resolver:     o.Resolver,
+		}.Unmarshal(v.Bytes, m.Interface())
+	default:
+		return errors.New("invalid %v value for message", v.Kind)
+	}
+}
+
+// bindList appends the value v to list. A length-delimited value from the wire
+// format of a list of scalars is a packed list of values.
+func (o BindOptions) bindList(fd pref.FieldDescriptor, v *Value, list pref.List) error {
+	if fd.Message() != nil {
+		val := list.NewElement()
+		if err := o.bindMessageValue(v, val.Message()); err != nil {
+			return err
+		}
+		list.Append(val)
+		return nil
+	}
+
+	if v.Kind != BytesKind || v.Text != "" || fd.Kind() == pref.StringKind || fd.Kind() == pref.BytesKind {
+		val, err := bindScalar(fd, v)
+		if err != nil {
+			return err
+		}
+		list.Append(val)
+		return nil
+	}
+
+	for b := v.Bytes; len(b) > 0; {
+		var elem Value
+		var n int
+		switch fd.Kind() {
+		case pref.Sfixed32Kind, pref.Fixed32Kind, pref.FloatKind:
+			var x uint32
+			x, n = wire.ConsumeFixed32(b)
+			elem = Value{Kind: Fixed32Kind, Uint: uint64(x)}
+		case pref.Sfixed64Kind, pref.Fixed64Kind, pref.DoubleKind:
+			elem.Kind = Fixed64Kind
+			elem.Uint, n = wire.ConsumeFixed64(b)
+		default:
+			elem.Kind = VarintKind
+			elem.Uint, n = wire.ConsumeVarint(b)
+		}
+		if n < 0 {
+			return wire.ParseError(n)
+		}
+		b = b[n:]
+		val, err := bindScalar(fd, &elem)
+		if err != nil {
+			return err
+		}
+		list.Append(val)
+	}
+	return nil
+}
+
+// bindMapEntry sets the entry of mmap given by the map entry message value v.
+func (o BindOptions) bindMapEntry(fd pref.FieldDescriptor, v *Value, mmap pref.Map) error {
+	entry := v.Message
+	if entry == nil {
+		if v.Kind != BytesKind || v.Text != "" {
+			return errors.New("invalid %v value for map entry", v.Kind)
+		}
+		var err error
+		if entry, err = UnmarshalWire(v.Bytes); err != nil {
+			return err
+		}
+	}
+
+	keyDesc, valDesc := fd.MapKey(), fd.MapValue()
+	key := keyDesc.Default()
+	val := valDesc.Default()
+	if valDesc.Message() != nil {
+		val = mmap.NewValue()
+	}
+	for _, f := range entry.Fields {
+		var ed pref.FieldDescriptor
+		switch {
+		case f.Name == string(keyDesc.Name()) || (f.Name == "" && f.Number == keyDesc.Number()):
+			ed = keyDesc
+		case f.Name == string(valDesc.Name()) || (f.Name == "" && f.Number == valDesc.Number()):
+			ed = valDesc
+		case o.DiscardUnknown || f.Name == "":
+			continue
+		default:
+			return errors.New("unknown map entry field: %v", f.Name)
+		}
+		for _, ev := range f.Values {
+			if ed.Message() != nil {
+				if err := o.bindMessageValue(ev, val.Message()); err != nil {
+					return err
+				}
+				continue
+			}
+			x, err := bindScalar(ed, ev)
+			if err != nil {
+				return err
+			}
+			if ed == keyDesc {
+				key = x
+			} else {
+				val = x
+			}
+		}
+	}
+	mmap.Set(key.MapKey(), val)
+	return nil
+}
+
+// bindScalar returns the value of the scalar field fd given by v.
+func bindScalar(fd pref.FieldDescriptor, v *Value) (pref.Value, error) {
+	if v.Text != "" {
+		return bindTextScalar(fd, v.Text)
+	}
+
+	kind := fd.Kind()
+	switch kind {
+	case pref.BoolKind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfBool(wire.DecodeBool(v.Uint)), nil
+		}
+	case pref.EnumKind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfEnum(pref.EnumNumber(v.Uint)), nil
+		}
+	case pref.Int32Kind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfInt32(int32(v.Uint)), nil
+		}
+	case pref.Sint32Kind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfInt32(int32(wire.DecodeZigZag(v.Uint & math.MaxUint32))), nil
+		}
+	case pref.Uint32Kind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfUint32(uint32(v.Uint)), nil
+		}
+	case pref.Int64Kind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfInt64(int64(v.Uint)), nil
+		}
+	case pref.Sint64Kind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfInt64(wire.DecodeZigZag(v.Uint)), nil
+		}
+	case pref.Uint64Kind:
+		if v.Kind == VarintKind {
+			return pref.ValueOfUint64(v.Uint), nil
+		}
+	case pref.Sfixed32Kind:
+		if v.Kind == Fixed32Kind {
+			return pref.ValueOfInt32(int32(v.Uint)), nil
+		}
+	case pref.Fixed32Kind:
+		if v.Kind == Fixed32Kind {
+			return pref.ValueOfUint32(uint32(v.Uint)), nil
+		}
+	case pref.FloatKind:
+		if v.Kind == Fixed32Kind {
+			return pref.ValueOfFloat32(math.Float32frombits(uint32(v.Uint))), nil
+		}
+	case pref.Sfixed64Kind:
+		if v.Kind == Fixed64Kind {
+			return pref.ValueOfInt64(int64(v.Uint)), nil
+		}
+	case pref.Fixed64Kind:
+		if v.Kind == Fixed64Kind {
+			return pref.ValueOfUint64(v.Uint), nil
+		}
+	case pref.DoubleKind:
+		if v.Kind == Fixed64Kind {
+			return pref.ValueOfFloat64(math.Float64frombits(v.Uint)), nil
+		}
+	case pref.StringKind:
+		if v.Kind == BytesKind {
+			if !utf8.Valid(v.Bytes) {
+				return pref.Value{}, errors.InvalidUTF8(string(fd.FullName()))
+			}
+			return pref.ValueOfString(string(v.Bytes)), nil
+		}
+	case pref.BytesKind:
+		if v.Kind == BytesKind {
+			return pref.ValueOfBytes(append([]byte(nil), v.Bytes...)), nil
+		}
+	}
+	return pref.Value{}, errors.New("invalid %v value for %v type", v.Kind, kind)
+}
+
+// bindTextScalar returns the value of the scalar field fd given by the text
+// format literal lit.
+func bindTextScalar(fd pref.FieldDescriptor, lit string) (pref.Value, error) {
+	tok, err := parseLiteral(lit)
+	if err != nil {
+		return pref.Value{}, err
+	}
+
+	kind := fd.Kind()
+	switch kind {
+	case pref.BoolKind:
+		if b, ok := tok.Bool(); ok {
+			return pref.ValueOfBool(b), nil
+		}
+	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind:
+		if n, ok := tok.Int32(); ok {
+			return pref.ValueOfInt32(n), nil
+		}
+	case pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
+		if n, ok := tok.Int64(); ok {
+			return pref.ValueOfInt64(n), nil
+		}
+	case pref.Uint32Kind, pref.Fixed32Kind:
+		if n, ok := tok.Uint32(); ok {
+			return pref.ValueOfUint32(n), nil
+		}
+	case pref.Uint64Kind, pref.Fixed64Kind:
+		if n, ok := tok.Uint64(); ok {
+			return pref.ValueOfUint64(n), nil
+		}
+	case pref.FloatKind:
+		if n, ok := tok.Float32(); ok {
+			return pref.ValueOfFloat32(n), nil
+		}
+	case pref.DoubleKind:
+		if n, ok := tok.Float64(); ok {
+			return pref.ValueOfFloat64(n), nil
+		}
+	case pref.StringKind:
+		if s, ok := tok.String(); ok {
+			if !utf8.ValidString(s) {
+				return pref.Value{}, errors.InvalidUTF8(string(fd.FullName()))
+			}
+			return pref.ValueOfString(s), nil
+		}
+	case pref.BytesKind:
+		if b, ok := tok.String(); ok {
+			return pref.ValueOfBytes([]byte(b)), nil
+		}
+	case pref.EnumKind:
+		if name, ok := tok.Enum(); ok {
+			if ev := fd.Enum().Values().ByName(pref.Name(name)); ev != nil {
+				return pref.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		if n, ok := tok.Int32(); ok {
+			return pref.ValueOfEnum(pref.EnumNumber(n)), nil
+		}
+	}
+	return pref.Value{}, errors.New("invalid value for %v type: %v", kind, lit)
+}
+
+// parseLiteral returns the scalar token of the text format literal lit.
+func parseLiteral(lit string) (text.Token, error) {
+	d := text.NewDecoder([]byte("_: " + lit))
+	if _, err := d.Read(); err != nil {
+		return text.Token{}, err
+	}
+	tok, err := d.Read()
+	if err != nil || tok.Kind() != text.Scalar {
+		return text.Token{}, errors.New("invalid literal: %v", lit)
+	}
+	if eof, err := d.Read(); err != nil || eof.Kind() != text.EOF {
+		return text.Token{}, errors.New("invalid literal: %v", lit)
+	}
+	return tok, nil
+}
+
+// appendField appends the wire encoding of the unknown field f to b.
+func appendField(b []byte, f *Field) ([]byte, error) {
+	if f.Name != "" {
+		return nil, errors.New("unknown field: %v", f.Name)
+	}
+	num := f.Number
+	for _, v := range f.Values {
+		switch v.Kind {
+		case VarintKind:
+			if v.Text != "" && v.Uint == 0 {
+				// Reject enum names, whose numbers are unknown.
+				if tok, err := parseLiteral(v.Text); err != nil || !isNumeric(tok) {
+					return nil, errors.New("invalid value for unknown field %d: %v", num, v.Text)
+				}
+			}
+			b = wire.AppendTag(b, num, wire.VarintType)
+			b = wire.AppendVarint(b, v.Uint)
+		case Fixed32Kind:
+			b = wire.AppendTag(b, num, wire.Fixed32Type)
+			b = wire.AppendFixed32(b, uint32(v.Uint))
+		case Fixed64Kind:
+			b = wire.AppendTag(b, num, wire.Fixed64Type)
+			b = wire.AppendFixed64(b, v.Uint)
+		case BytesKind:
+			b = wire.AppendTag(b, num, wire.BytesType)
+			b = wire.AppendBytes(b, v.Bytes)
+		case GroupKind:
+			b = wire.AppendTag(b, num, wire.StartGroupType)
+			if v.Message != nil {
+				for _, f := range v.Message.Fields {
+					var err error
+					if b, err = appendField(b, f); err != nil {
+						return nil, err
+					}
+				}
+			}
+			b = wire.AppendTag(b, num, wire.EndGroupType)
+		default:
+			return nil, errors.New("invalid wire type %v for unknown field %d", v.Kind, num)
+		}
+	}
+	return b, nil
+}
+
+// isNumeric reports whether the scalar token tok is a number or boolean.
+func isNumeric(tok text.Token) bool {
+	if _, ok := tok.Bool(); ok {
+		return true
+	}
+	_, ok := tok.Int64()
+	return ok
+}
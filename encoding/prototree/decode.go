@@ -0,0 +1,219 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prototree
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"google.golang.org/protobuf/internal/encoding/text"
+	"google.golang.org/protobuf/internal/encoding/wire"
+	"google.golang.org/protobuf/internal/errors"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// UnmarshalText decodes a message in the text format without its descriptor.
+func UnmarshalText(b []byte) (*Message, error) {
+	d := textDecoder{text.NewDecoder(b)}
+	return d.unmarshalMessage(false)
+}
+
+type textDecoder struct {
+	*text.Decoder
+}
+
+// newError returns an error object with position info.
+func (d textDecoder) newError(pos int, f string, x ...interface{}) error {
+	line, column := d.Position(pos)
+	head := fmt.Sprintf("(line %d:%d): ", line, column)
+	return errors.New(head+f, x...)
+}
+
+// unexpectedTokenError returns a syntax error for the given unexpected token.
+func (d textDecoder) unexpectedTokenError(tok text.Token) error {
+	return d.newError(tok.Pos(), "unexpected token: %s", tok.RawString())
+}
+
+// unmarshalMessage decodes the fields of a message, which is enclosed in
+// delimiters if checkDelims is set.
+func (d textDecoder) unmarshalMessage(checkDelims bool) (*Message, error) {
+	m := &Message{text: true}
+	for {
+		tok, err := d.Read()
+		if err != nil {
+			return nil, err
+		}
+		switch typ := tok.Kind(); typ {
+		case text.Name:
+			// Continue below.
+		case text.EOF:
+			if checkDelims {
+				return nil, text.ErrUnexpectedEOF
+			}
+			return m, nil
+		default:
+			if checkDelims && typ == text.MessageClose {
+				return m, nil
+			}
+			return nil, d.unexpectedTokenError(tok)
+		}
+
+		var name string
+		var num pref.FieldNumber
+		switch tok.NameKind() {
+		case text.IdentName:
+			name = tok.IdentName()
+		case text.TypeName:
+			name = "[" + tok.TypeName() + "]"
+		case text.FieldNumber:
+			num = pref.FieldNumber(tok.FieldNumber())
+			if !num.IsValid() {
+				return nil, d.newError(tok.Pos(), "invalid field number: %d", num)
+			}
+		}
+		if err := d.unmarshalField(m, name, num, tok); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// unmarshalField decodes the value of the field named by the token tok, which
+// is either a single value or a list.
+func (d textDecoder) unmarshalField(m *Message, name string, num pref.FieldNumber, tok text.Token) error {
+	val, err := d.Read()
+	if err != nil {
+		return err
+	}
+	if val.Kind() == text.Scalar && !tok.HasSeparator() {
+		return d.newError(tok.Pos(), "missing field separator :")
+	}
+	if val.Kind() != text.ListOpen {
+		v, err := d.unmarshalValue(val)
+		if err != nil {
+			return err
+		}
+		m.add(name, num, v)
+		return nil
+	}
+
+	for {
+		val, err := d.Read()
+		if err != nil {
+			return err
+		}
+		if val.Kind() == text.ListClose {
+			return nil
+		}
+		v, err := d.unmarshalValue(val)
+		if err != nil {
+			return err
+		}
+		m.add(name, num, v)
+	}
+}
+
+// unmarshalValue decodes the value starting at token tok.
+func (d textDecoder) unmarshalValue(tok text.Token) (*Value, error) {
+	switch tok.Kind() {
+	case text.Scalar:
+		return newTextValue(tok), nil
+	case text.MessageOpen:
+		m, err := d.unmarshalMessage(true)
+		if err != nil {
+			return nil, err
+		}
+		return &Value{Kind: GroupKind, Message: m}, nil
+	default:
+		return nil, d.unexpectedTokenError(tok)
+	}
+}
+
+// newTextValue returns the value of the scalar token tok, inferring its wire
+// type from its representation.
+func newTextValue(tok text.Token) *Value {
+	if s, ok := tok.String(); ok {
+		// The raw string may include trailing whitespace and comments,
+		// so quote the value anew.
+		e, _ := text.NewEncoder("", [2]byte{}, text.EncoderOptions{})
+		e.WriteString(s)
+		return &Value{Kind: BytesKind, Bytes: []byte(s), Text: string(e.Bytes())}
+	}
+	raw := tok.RawString()
+	if strings.HasPrefix(raw, "0x") || strings.HasPrefix(raw, "0X") {
+		if n, ok := tok.Uint64(); ok {
+			if len(raw) <= len("0x")+8 {
+				return &Value{Kind: Fixed32Kind, Uint: n, Text: raw}
+			}
+			return &Value{Kind: Fixed64Kind, Uint: n, Text: raw}
+		}
+	}
+	if n, ok := tok.Uint64(); ok {
+		return &Value{Kind: VarintKind, Uint: n, Text: raw}
+	}
+	if n, ok := tok.Int64(); ok {
+		return &Value{Kind: VarintKind, Uint: uint64(n), Text: raw}
+	}
+	if b, ok := tok.Bool(); ok {
+		return &Value{Kind: VarintKind, Uint: wire.EncodeBool(b), Text: raw}
+	}
+	if f, ok := tok.Float64(); ok {
+		return &Value{Kind: Fixed64Kind, Uint: math.Float64bits(f), Text: raw}
+	}
+	// An enum name, or a number out of range for any integer type.
+	return &Value{Kind: VarintKind, Text: raw}
+}
+
+// UnmarshalWire decodes a message in the wire format without its descriptor.
+// The content of each length-delimited value is also decoded as a message
+// if it is well-formed as such.
+func UnmarshalWire(b []byte) (*Message, error) {
+	m := new(Message)
+	for len(b) > 0 {
+		num, typ, n := wire.ConsumeTag(b)
+		if n < 0 {
+			return nil, wire.ParseError(n)
+		}
+		b = b[n:]
+
+		v := &Value{}
+		switch typ {
+		case wire.VarintType:
+			v.Kind = VarintKind
+			v.Uint, n = wire.ConsumeVarint(b)
+		case wire.Fixed32Type:
+			var x uint32
+			x, n = wire.ConsumeFixed32(b)
+			v.Kind, v.Uint = Fixed32Kind, uint64(x)
+		case wire.Fixed64Type:
+			v.Kind = Fixed64Kind
+			v.Uint, n = wire.ConsumeFixed64(b)
+		case wire.BytesType:
+			v.Kind = BytesKind
+			v.Bytes, n = wire.ConsumeBytes(b)
+			if n >= 0 && len(v.Bytes) > 0 {
+				v.Message, _ = UnmarshalWire(v.Bytes)
+			}
+		case wire.StartGroupType:
+			var gb []byte
+			gb, n = wire.ConsumeGroup(num, b)
+			if n >= 0 {
+				var err error
+				if v.Message, err = UnmarshalWire(gb); err != nil {
+					return nil, err
+				}
+			}
+			v.Kind = GroupKind
+		default:
+			return nil, errors.New("unexpected wire type %d for field %d", typ, num)
+		}
+		if n < 0 {
+			return nil, wire.ParseError(n)
+		}
+		b = b[n:]
+		m.add("", num, v)
+	}
+	return m, nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package prototree decodes messages in the protocol buffer text and wire
+// formats without their descriptors.
+//
+// Decoding produces a generic tree of fields, each identified by the name or
+// number found in the input and holding the values of the field with the
+// wire types inferred for them. Since neither format is self-describing,
+// the tree keeps enough of the input to interpret the values once the
+// message type is known: Bind populates a message from a tree according to
+// the message descriptor.
+package prototree
+
+import (
+	"fmt"
+
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Message is a message decoded without its descriptor.
+type Message struct {
+	// Fields are the fields of the message in the order in which they
+	// appear. Consecutive occurrences of a field are gathered in one Field,
+	// while a field that occurs again after another field has a Field for
+	// each run of occurrences, so that the input order is preserved.
+	Fields []*Field
+
+	// text reports whether the message was decoded from the text format.
+	text bool
+}
+
+// Field is a field of a Message.
+type Field struct {
+	// Name is the field name as written in the text format, which is the
+	// name of the field, the message name of a group, "[pkg.ext]" for an
+	// extension or "[type.googleapis.com/pkg.Message]" for an expanded
+	// google.protobuf.Any. It is empty for fields identified by number.
+	Name string
+
+	// Number is the field number. It is zero for fields identified by name.
+	Number pref.FieldNumber
+
+	// Values are the values of the field in the order in which they appear.
+	// A list in the text format contributes a value per element.
+	Values []*Value
+}
+
+// Kind is the wire type inferred for a Value.
+type Kind int8
+
+const (
+	VarintKind  Kind = iota + 1 // such as int32, bool and enum values
+	Fixed32Kind                 // such as fixed32 and float values
+	Fixed64Kind                 // such as fixed64 and double values
+	BytesKind                   // such as strings, messages and packed lists
+	GroupKind                   // a message delimited by group tags
+)
+
+// String returns the name of the wire type of k.
+func (k Kind) String() string {
+	switch k {
+	case VarintKind:
+		return "varint"
+	case Fixed32Kind:
+		return "fixed32"
+	case Fixed64Kind:
+		return "fixed64"
+	case BytesKind:
+		return "bytes"
+	case GroupKind:
+		return "group"
+	default:
+		return fmt.Sprintf("<unknown:%d>", k)
+	}
+}
+
+// Value is a single value of a field.
+//
+// The wire type of a value in the text format is inferred from its
+// representation, as for unknown fields in prototext.UnmarshalOptions:
+// strings are length-delimited, hexadecimal integers of up to 8 digits are
+// fixed32 and longer ones fixed64, other integers, booleans and enum names
+// are varints, floating-point numbers are fixed64 and messages are groups.
+type Value struct {
+	// Kind is the wire type of the value.
+	Kind Kind
+
+	// Uint is the wire encoding of a VarintKind, Fixed32Kind or Fixed64Kind
+	// value. It is zero for enum names in the text format.
+	Uint uint64
+
+	// Bytes is the content of a BytesKind value.
+	Bytes []byte
+
+	// Message is the content of a GroupKind value. For a BytesKind value from
+	// the wire format, it is the content parsed as a message, or nil if the
+	// content is empty or not a valid message.
+	Message *Message
+
+	// Text is the literal of a scalar value in the text format, such as
+	// "-12", "0x1f", "1.5", "FOO" or `"abc"`. Bind interprets the literal
+	// according to the kind of the field. It is empty for values from the
+	// wire format.
+	Text string
+}
+
+// FieldByName returns the first field of m named name, or nil if there is
+// none.
+func (m *Message) FieldByName(name string) *Field {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// FieldByNumber returns the first field of m identified by number n, or nil
+// if there is none.
+func (m *Message) FieldByNumber(n pref.FieldNumber) *Field {
+	for _, f := range m.Fields {
+		if f.Name == "" && f.Number == n {
+			return f
+		}
+	}
+	return nil
+}
+
+// add appends v to the values of the field identified by name or number n,
+// which are gathered in the last field of m if it has the same identifier.
+// Otherwise, a new field is added.
+func (m *Message) add(name string, n pref.FieldNumber, v *Value) {
+	var f *Field
+	if len(m.Fields) > 0 {
+		f = m.Fields[len(m.Fields)-1]
+	}
+	if f == nil || f.Name != name || f.Number != n {
+		f = &Field{Name: name, Number: n}
+		m.Fields = append(m.Fields, f)
+	}
+	f.Values = append(f.Values, v)
+}
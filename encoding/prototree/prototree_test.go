@@ -0,0 +1,478 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package prototree_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/prototree"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	testpb "google.golang.org/protobuf/internal/testprotos/test"
+	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
+)
+
+func TestUnmarshalText(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   string
+		want    *prototree.Message
+		wantErr string // Expected error substring.
+	}{{
+		desc:  "empty",
+		input: "# comment\n",
+		want:  &prototree.Message{},
+	}, {
+		desc: "scalars",
+		input: `
+s: "a" 'b' # comment
+i: -1
+u: 18446744073709551615
+x32: 0x1f
+x64: 0x000000000000001f
+f: 1.5
+inf: -inf
+b: true
+e: FOO
+`,
+		want: &prototree.Message{Fields: []*prototree.Field{
+			{Name: "s", Values: []*prototree.Value{{Kind: prototree.BytesKind, Bytes: []byte("ab"), Text: `"ab"`}}},
+			{Name: "i", Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: math.MaxUint64, Text: "-1"}}},
+			{Name: "u", Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: math.MaxUint64, Text: "18446744073709551615"}}},
+			{Name: "x32", Values: []*prototree.Value{{Kind: prototree.Fixed32Kind, Uint: 0x1f, Text: "0x1f"}}},
+			{Name: "x64", Values: []*prototree.Value{{Kind: prototree.Fixed64Kind, Uint: 0x1f, Text: "0x000000000000001f"}}},
+			{Name: "f", Values: []*prototree.Value{{Kind: prototree.Fixed64Kind, Uint: math.Float64bits(1.5), Text: "1.5"}}},
+			{Name: "inf", Values: []*prototree.Value{{Kind: prototree.Fixed64Kind, Uint: math.Float64bits(math.Inf(-1)), Text: "-inf"}}},
+			{Name: "b", Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 1, Text: "true"}}},
+			{Name: "e", Values: []*prototree.Value{{Kind: prototree.VarintKind, Text: "FOO"}}},
+		}},
+	}, {
+		desc:  "lists and repeated fields",
+		input: "a: [1, 2] 3: 4 a: 5 3: [] 3: [6]",
+		want: &prototree.Message{Fields: []*prototree.Field{
+			{Name: "a", Values: []*prototree.Value{
+				{Kind: prototree.VarintKind, Uint: 1, Text: "1"},
+				{Kind: prototree.VarintKind, Uint: 2, Text: "2"},
+			}},
+			{Number: 3, Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 4, Text: "4"}}},
+			{Name: "a", Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 5, Text: "5"}}},
+			{Number: 3, Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 6, Text: "6"}}},
+		}},
+	}, {
+		desc:  "messages",
+		input: "m { [pkg.ext]: 1 } m: <> [type.googleapis.com/pkg.M] {} l: [{}, {a: 1}]",
+		want: &prototree.Message{Fields: []*prototree.Field{
+			{Name: "m", Values: []*prototree.Value{
+				{Kind: prototree.GroupKind, Message: &prototree.Message{Fields: []*prototree.Field{
+					{Name: "[pkg.ext]", Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 1, Text: "1"}}},
+				}}},
+				{Kind: prototree.GroupKind, Message: &prototree.Message{}},
+			}},
+			{Name: "[type.googleapis.com/pkg.M]", Values: []*prototree.Value{
+				{Kind: prototree.GroupKind, Message: &prototree.Message{}},
+			}},
+			{Name: "l", Values: []*prototree.Value{
+				{Kind: prototree.GroupKind, Message: &prototree.Message{}},
+				{Kind: prototree.GroupKind, Message: &prototree.Message{Fields: []*prototree.Field{
+					{Name: "a", Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 1, Text: "1"}}},
+				}}},
+			}},
+		}},
+	}, {
+		desc:    "missing separator",
+		input:   "a 1",
+		wantErr: "missing field separator",
+	}, {
+		desc:    "invalid field number",
+		input:   "0: 1",
+		wantErr: "invalid field number: 0",
+	}, {
+		desc:    "unclosed message",
+		input:   "m {",
+		wantErr: "unexpected EOF",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := prototree.UnmarshalText([]byte(tt.input))
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("UnmarshalText() error got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("UnmarshalText() got nil error, want error %q", tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(prototree.Message{})); diff != "" {
+				t.Errorf("UnmarshalText() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalWire(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   []byte
+		want    *prototree.Message
+		wantErr string // Expected error substring.
+	}{{
+		desc: "scalars",
+		input: mustMarshal(&testpb.TestAllTypes{
+			OptionalSint32:  proto.Int32(-1),
+			OptionalFixed32: proto.Uint32(2),
+			OptionalDouble:  proto.Float64(1.5),
+			OptionalString:  proto.String(""),
+			RepeatedInt32:   []int32{3, 4},
+		}),
+		want: &prototree.Message{Fields: []*prototree.Field{
+			{Number: 5, Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 1}}},
+			{Number: 7, Values: []*prototree.Value{{Kind: prototree.Fixed32Kind, Uint: 2}}},
+			{Number: 12, Values: []*prototree.Value{{Kind: prototree.Fixed64Kind, Uint: math.Float64bits(1.5)}}},
+			{Number: 14, Values: []*prototree.Value{{Kind: prototree.BytesKind, Bytes: []byte{}}}},
+			{Number: 31, Values: []*prototree.Value{
+				{Kind: prototree.VarintKind, Uint: 3},
+				{Kind: prototree.VarintKind, Uint: 4},
+			}},
+		}},
+	}, {
+		desc: "messages",
+		input: mustMarshal(&testpb.TestAllTypes{
+			Optionalgroup: &testpb.TestAllTypes_OptionalGroup{A: proto.Int32(1)},
+			OptionalNestedMessage: &testpb.TestAllTypes_NestedMessage{
+				A: proto.Int32(2),
+			},
+		}),
+		want: &prototree.Message{Fields: []*prototree.Field{
+			{Number: 16, Values: []*prototree.Value{{Kind: prototree.GroupKind, Message: &prototree.Message{Fields: []*prototree.Field{
+				{Number: 17, Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 1}}},
+			}}}}},
+			{Number: 18, Values: []*prototree.Value{{Kind: prototree.BytesKind, Bytes: []byte{0x08, 0x02}, Message: &prototree.Message{Fields: []*prototree.Field{
+				{Number: 1, Values: []*prototree.Value{{Kind: prototree.VarintKind, Uint: 2}}},
+			}}}}},
+		}},
+	}, {
+		desc:  "bytes that are not a message",
+		input: mustMarshal(&testpb.TestAllTypes{OptionalString: proto.String("abc")}),
+		want: &prototree.Message{Fields: []*prototree.Field{
+			{Number: 14, Values: []*prototree.Value{{Kind: prototree.BytesKind, Bytes: []byte("abc")}}},
+		}},
+	}, {
+		desc:    "truncated",
+		input:   []byte{0x08},
+		wantErr: "unexpected EOF",
+	}, {
+		desc:    "unmatched end group",
+		input:   []byte{0x0c},
+		wantErr: "unexpected wire type",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := prototree.UnmarshalWire(tt.input)
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("UnmarshalWire() error got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("UnmarshalWire() got nil error, want error %q", tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreUnexported(prototree.Message{})); diff != "" {
+				t.Errorf("UnmarshalWire() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		desc    string
+		message proto.Message
+	}{{
+		desc: "scalars",
+		message: &testpb.TestAllTypes{
+			OptionalInt32:    proto.Int32(-1),
+			OptionalInt64:    proto.Int64(-2),
+			OptionalUint32:   proto.Uint32(3),
+			OptionalUint64:   proto.Uint64(math.MaxUint64),
+			OptionalSint32:   proto.Int32(-5),
+			OptionalSint64:   proto.Int64(-6),
+			OptionalFixed32:  proto.Uint32(7),
+			OptionalFixed64:  proto.Uint64(8),
+			OptionalSfixed32: proto.Int32(-9),
+			OptionalSfixed64: proto.Int64(-10),
+			OptionalFloat:    proto.Float32(11.5),
+			OptionalDouble:   proto.Float64(math.Inf(-1)),
+			OptionalBool:     proto.Bool(true),
+			OptionalString:   proto.String("string"),
+			OptionalBytes:    []byte("\x00\xff"),
+		},
+	}, {
+		desc: "messages and enums",
+		message: &testpb.TestAllTypes{
+			Optionalgroup: &testpb.TestAllTypes_OptionalGroup{A: proto.Int32(1)},
+			OptionalNestedMessage: &testpb.TestAllTypes_NestedMessage{
+				A: proto.Int32(2),
+				Corecursive: &testpb.TestAllTypes{
+					OptionalString: proto.String("nested"),
+				},
+			},
+			OptionalNestedEnum:  testpb.TestAllTypes_BAZ.Enum(),
+			OptionalForeignEnum: testpb.ForeignEnum_FOREIGN_BAR.Enum(),
+			OneofField:          &testpb.TestAllTypes_OneofNestedMessage{OneofNestedMessage: &testpb.TestAllTypes_NestedMessage{}},
+		},
+	}, {
+		desc: "lists",
+		message: &testpb.TestAllTypes{
+			RepeatedInt32:   []int32{1, -2},
+			RepeatedSint64:  []int64{-3, 4},
+			RepeatedFixed32: []uint32{5, 6},
+			RepeatedDouble:  []float64{7.5, math.MaxFloat64},
+			RepeatedBool:    []bool{true, false},
+			RepeatedString:  []string{"a", ""},
+			RepeatedBytes:   [][]byte{{}, []byte("b")},
+			Repeatedgroup: []*testpb.TestAllTypes_RepeatedGroup{
+				{A: proto.Int32(1)},
+				{},
+			},
+			RepeatedNestedMessage: []*testpb.TestAllTypes_NestedMessage{
+				{A: proto.Int32(2)},
+			},
+			RepeatedNestedEnum: []testpb.TestAllTypes_NestedEnum{
+				testpb.TestAllTypes_FOO,
+				testpb.TestAllTypes_NEG,
+			},
+		},
+	}, {
+		desc: "packed lists",
+		message: &testpb.TestPackedTypes{
+			PackedInt32:    []int32{1, -2},
+			PackedSint64:   []int64{-3, 4},
+			PackedFixed32:  []uint32{5, 6},
+			PackedFloat:    []float32{7.5, 8},
+			PackedBool:     []bool{true, false},
+			PackedEnum:     []testpb.ForeignEnum{testpb.ForeignEnum_FOREIGN_BAZ},
+			PackedSfixed64: []int64{-9},
+		},
+	}, {
+		desc: "maps",
+		message: &testpb.TestAllTypes{
+			MapInt32Int32:       map[int32]int32{1: -1, 0: 0},
+			MapSint64Sint64:     map[int64]int64{-2: 2},
+			MapFixed64Fixed64:   map[uint64]uint64{3: 4},
+			MapBoolBool:         map[bool]bool{true: false},
+			MapStringString:     map[string]string{"k": "v", "": ""},
+			MapStringBytes:      map[string][]byte{"k": []byte("v")},
+			MapStringNestedEnum: map[string]testpb.TestAllTypes_NestedEnum{"k": testpb.TestAllTypes_BAR},
+			MapStringNestedMessage: map[string]*testpb.TestAllTypes_NestedMessage{
+				"k": {A: proto.Int32(5)},
+				"":  {},
+			},
+		},
+	}, {
+		desc: "extensions",
+		message: func() proto.Message {
+			m := &testpb.TestAllExtensions{}
+			proto.SetExtension(m, testpb.E_OptionalInt32, int32(1))
+			proto.SetExtension(m, testpb.E_RepeatedString, []string{"a", "b"})
+			proto.SetExtension(m, testpb.E_OptionalNestedMessage, &testpb.TestAllExtensions_NestedMessage{A: proto.Int32(2)})
+			return m
+		}(),
+	}, {
+		desc: "any",
+		message: &pb2.KnownTypes{OptAny: &anypb.Any{
+			TypeUrl: "type.googleapis.com/pb2.Nested",
+			Value:   mustMarshal(&pb2.Nested{OptString: proto.String("a")}),
+		}},
+	}, {
+		desc:    "required",
+		message: &pb2.Requireds{ReqBool: proto.Bool(true), ReqSfixed64: proto.Int64(1), ReqDouble: proto.Float64(2), ReqString: proto.String("s"), ReqEnum: pb2.Enum_ONE.Enum(), ReqNested: &pb2.Nested{}},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc+"/wire", func(t *testing.T) {
+			tree, err := prototree.UnmarshalWire(mustMarshal(tt.message))
+			if err != nil {
+				t.Fatalf("UnmarshalWire() error: %v", err)
+			}
+			checkBind(t, tree, tt.message)
+		})
+		t.Run(tt.desc+"/text", func(t *testing.T) {
+			b, err := prototext.Marshal(tt.message)
+			if err != nil {
+				t.Fatalf("prototext.Marshal() error: %v", err)
+			}
+			tree, err := prototree.UnmarshalText(b)
+			if err != nil {
+				t.Fatalf("UnmarshalText() error: %v", err)
+			}
+			checkBind(t, tree, tt.message)
+		})
+	}
+}
+
+func TestBindOrder(t *testing.T) {
+	wireTree := func(b []byte) *prototree.Message {
+		tree, err := prototree.UnmarshalWire(b)
+		if err != nil {
+			t.Fatalf("UnmarshalWire() error: %v", err)
+		}
+		return tree
+	}
+	textTree := func(s string) *prototree.Message {
+		tree, err := prototree.UnmarshalText([]byte(s))
+		if err != nil {
+			t.Fatalf("UnmarshalText() error: %v", err)
+		}
+		return tree
+	}
+
+	t.Run("wire oneof", func(t *testing.T) {
+		b := mustMarshal(&testpb.TestAllTypes{OneofField: &testpb.TestAllTypes_OneofUint32{OneofUint32: 1}})
+		b = append(b, mustMarshal(&testpb.TestAllTypes{OneofField: &testpb.TestAllTypes_OneofString{OneofString: "x"}})...)
+		b = append(b, mustMarshal(&testpb.TestAllTypes{OneofField: &testpb.TestAllTypes_OneofUint32{OneofUint32: 3}})...)
+		checkBind(t, wireTree(b), &testpb.TestAllTypes{OneofField: &testpb.TestAllTypes_OneofUint32{OneofUint32: 3}})
+	})
+	t.Run("text names and numbers", func(t *testing.T) {
+		tree := textTree("optional_int32: 1  1: 2  optional_int32: 3")
+		checkBind(t, tree, &testpb.TestAllTypes{OptionalInt32: proto.Int32(3)})
+	})
+	t.Run("text numbers and names", func(t *testing.T) {
+		tree := textTree("1: 1  optional_int32: 2  1: 3")
+		checkBind(t, tree, &testpb.TestAllTypes{OptionalInt32: proto.Int32(3)})
+	})
+	t.Run("text repeated oneof member", func(t *testing.T) {
+		tree := textTree("oneof_uint32: 1  oneof_uint32: 2")
+		checkBind(t, tree, &testpb.TestAllTypes{OneofField: &testpb.TestAllTypes_OneofUint32{OneofUint32: 2}})
+	})
+}
+
+func checkBind(t *testing.T, tree *prototree.Message, want proto.Message) {
+	t.Helper()
+	got := want.ProtoReflect().New().Interface()
+	if err := prototree.Bind(tree, got); err != nil {
+		t.Fatalf("Bind() error: %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("Bind() mismatch\n<got>\n%v\n<want>\n%v", got, want)
+	}
+}
+
+func TestBindUnknown(t *testing.T) {
+	tree, err := prototree.UnmarshalText([]byte(`opt_string: "a" 100: 1 101: 0x01 102: "b" 103 { 1: 2.5 }`))
+	if err != nil {
+		t.Fatalf("UnmarshalText() error: %v", err)
+	}
+	got := &pb2.Nested{}
+	if err := prototree.Bind(tree, got); err != nil {
+		t.Fatalf("Bind() error: %v", err)
+	}
+
+	// Parsing the same unknown fields with prototext yields the same wire encoding.
+	want := &pb2.Nested{}
+	if err := (prototext.UnmarshalOptions{AllowFieldNumbers: true}).Unmarshal([]byte(`opt_string: "a" 100: 1 101: 0x01 102: "b" 103 { 1: 2.5 }`), want); err != nil {
+		t.Fatalf("prototext.Unmarshal() error: %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("Bind() mismatch\n<got>\n%v\n<want>\n%v", got, want)
+	}
+
+	got = &pb2.Nested{}
+	if err := (prototree.BindOptions{DiscardUnknown: true}).Bind(tree, got); err != nil {
+		t.Fatalf("Bind() error: %v", err)
+	}
+	if want := (&pb2.Nested{OptString: proto.String("a")}); !proto.Equal(got, want) {
+		t.Errorf("Bind() with DiscardUnknown mismatch\n<got>\n%v\n<want>\n%v", got, want)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   string
+		message proto.Message
+		wantErr string // Expected error substring.
+	}{{
+		desc:    "unknown field name",
+		input:   "unknown: 1",
+		message: &pb2.Scalars{},
+		wantErr: "unknown field: unknown",
+	}, {
+		desc:    "unknown enum name for unknown field",
+		input:   "100: FOO",
+		message: &pb2.Scalars{},
+		wantErr: "invalid value for unknown field 100: FOO",
+	}, {
+		desc:    "invalid scalar",
+		input:   `opt_int32: "a"`,
+		message: &pb2.Scalars{},
+		wantErr: "invalid value for int32 type",
+	}, {
+		desc:    "invalid enum",
+		input:   "opt_enum: UNKNOWN",
+		message: &pb2.Enums{},
+		wantErr: "invalid value for enum type: UNKNOWN",
+	}, {
+		desc:    "invalid UTF-8",
+		input:   `opt_string: "\xff"`,
+		message: &pb2.Scalars{},
+		wantErr: "invalid UTF-8",
+	}, {
+		desc:    "scalar for message",
+		input:   "opt_nested: 1",
+		message: &pb2.Nests{},
+		wantErr: "invalid varint value for message",
+	}, {
+		desc:    "multiple oneof members",
+		input:   `oneof_uint32: 1  oneof_string: "x"`,
+		message: &testpb.TestAllTypes{},
+		wantErr: "oneof goproto.proto.test.TestAllTypes.oneof_field is already set",
+	}, {
+		desc:    "multiple oneof members by number",
+		input:   `111: 1  oneof_uint32: 2  113: "x"`,
+		message: &testpb.TestAllTypes{},
+		wantErr: `error parsing "113", oneof goproto.proto.test.TestAllTypes.oneof_field is already set`,
+	}, {
+		desc:    "missing required field",
+		input:   "req_bool: true",
+		message: &pb2.Requireds{},
+		wantErr: "required field",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			tree, err := prototree.UnmarshalText([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("UnmarshalText() error: %v", err)
+			}
+			err = prototree.Bind(tree, tt.message)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Bind() error got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustMarshal(m proto.Message) []byte {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,589 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textedit
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/internal/encoding/text"
+	"google.golang.org/protobuf/internal/errors"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// indentUnit is the indentation added for the fields of a new message.
+const indentUnit = "  "
+
+// Set sets the field at path to v.
+//
+// A path is a sequence of field names separated by '.', where each name is
+// the name of a field, the name of a group, a field number, or the full name
+// of an extension enclosed in brackets. Each name may be followed by an index
+// in brackets, such as "rpt_nested[2]", to select an element of a repeated
+// field in the order in which the elements appear in the document. The
+// entries of a map field are elements of a repeated field of entry messages.
+//
+// Singular message fields along the path are added if absent. If the last
+// field of the path is present, its value is replaced, keeping the text
+// around it; otherwise, the field is added after the last element of the
+// same field or at the end of its message, indented like its siblings. A
+// repeated field without an index has v appended as a new element. Setting a
+// member of a oneof removes the other members.
+//
+// The type of v must be that of the field, or of an element of the field if
+// the field is repeated. Set requires a document parsed with a Schema. If Set
+// returns an error, the document is left unchanged.
+func (d *Document) Set(path string, v pref.Value) error {
+	return d.edit(path, true, func(c cursor, fd pref.FieldDescriptor, index int) error {
+		return d.set(c, fd, index, v)
+	})
+}
+
+// Remove removes the field at path, or the element of a repeated field if
+// the path ends in an index. See Set for the syntax of paths. The comments
+// directly above a removed field are removed with it, while comments
+// separated from it by a blank line are kept. It is not an error for the
+// field to be absent.
+//
+// Remove requires a document parsed with a Schema. If Remove returns an
+// error, the document is left unchanged.
+func (d *Document) Remove(path string) error {
+	return d.edit(path, false, func(c cursor, fd pref.FieldDescriptor, index int) error {
+		occs := c.occurrences(fd)
+		if index < 0 {
+			for i := len(c.m.Fields) - 1; i >= 0; i-- {
+				if fieldMatches(c.m.Fields[i].Name, fd) {
+					removeField(c.m, i)
+				}
+			}
+			return nil
+		}
+		if index >= len(occs) {
+			return nil
+		}
+		o := occs[index]
+		if o.elem < 0 {
+			removeField(c.m, o.field)
+			return nil
+		}
+		lv := c.m.Fields[o.field].Value
+		if o.elem == 0 && len(lv.List) > 1 {
+			lv.List[1].Before = lv.List[0].Before
+		}
+		lv.List = append(lv.List[:o.elem:o.elem], lv.List[o.elem+1:]...)
+		if len(lv.List) == 0 {
+			removeField(c.m, o.field)
+		}
+		return nil
+	})
+}
+
+// edit resolves path and calls f with the message holding the last field of
+// the path, restoring the document if f fails or produces an invalid document.
+func (d *Document) edit(path string, create bool, f func(c cursor, fd pref.FieldDescriptor, index int) error) error {
+	if d.opts.Schema == nil {
+		return errors.New("cannot edit document without a schema")
+	}
+	elems, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	orig := d.Format()
+	err = d.apply(elems, create, f)
+	if err == nil {
+		err = d.validate(d.Format())
+	}
+	if err != nil {
+		root, perr := parse(orig)
+		if perr != nil {
+			panic(perr) // the original document was parsed before
+		}
+		*d.Root = *root
+		return errors.Wrap(err, "cannot edit %s", path)
+	}
+	return nil
+}
+
+func (d *Document) apply(elems []pathElem, create bool, f func(c cursor, fd pref.FieldDescriptor, index int) error) error {
+	c := cursor{m: d.Root, md: d.opts.Schema}
+	for i, e := range elems {
+		fd, err := d.findField(c.md, e.name)
+		if err != nil {
+			return err
+		}
+		if e.index >= 0 && !fd.IsList() && !fd.IsMap() {
+			return errors.New("field %v is not repeated", fd.FullName())
+		}
+		if i == len(elems)-1 {
+			return f(c, fd, e.index)
+		}
+
+		if fd.Message() == nil {
+			return errors.New("field %v is not a message", fd.FullName())
+		}
+		occs := c.occurrences(fd)
+		var o occurrence
+		switch {
+		case fd.IsList() || fd.IsMap():
+			if e.index < 0 {
+				return errors.New("repeated field %v requires an index", fd.FullName())
+			}
+			if e.index >= len(occs) {
+				if !create {
+					return nil
+				}
+				return errors.New("index %d out of range for field %v with %d elements", e.index, fd.FullName(), len(occs))
+			}
+			o = occs[e.index]
+		case len(occs) > 0:
+			o = occs[len(occs)-1]
+		case !create:
+			return nil
+		default:
+			c.addField(fd, &Value{Kind: MessageValue, Message: &Message{}})
+			o = c.occurrences(fd)[0]
+		}
+		v := o.value(c.m)
+		if v.Kind != MessageValue {
+			return errors.New("field %v does not hold a message", fd.FullName())
+		}
+		c = cursor{m: v.Message, md: fd.Message(), indent: o.indent}
+	}
+	return nil
+}
+
+// set sets the field fd of the message at c, or its element at index.
+func (d *Document) set(c cursor, fd pref.FieldDescriptor, index int, v pref.Value) error {
+	nv, err := d.newValue(fd, v)
+	if err != nil {
+		return err
+	}
+	occs := c.occurrences(fd)
+	if fd.IsList() || fd.IsMap() {
+		if index >= len(occs) {
+			return errors.New("index %d out of range for field %v with %d elements", index, fd.FullName(), len(occs))
+		}
+		if index >= 0 {
+			replaceValue(occs[index], c.m, nv)
+			return nil
+		}
+	} else if len(occs) > 0 {
+		replaceValue(occs[len(occs)-1], c.m, nv)
+		return nil
+	}
+
+	if od := fd.ContainingOneof(); od != nil {
+		fields := od.Fields()
+		for i := len(c.m.Fields) - 1; i >= 0; i-- {
+			for j := 0; j < fields.Len(); j++ {
+				if fields.Get(j) != fd && fieldMatches(c.m.Fields[i].Name, fields.Get(j)) {
+					removeField(c.m, i)
+					break
+				}
+			}
+		}
+	}
+	c.addField(fd, nv)
+	return nil
+}
+
+// newValue returns the syntax tree of v as a value of the field fd, or as an
+// element of fd if fd is repeated.
+func (d *Document) newValue(fd pref.FieldDescriptor, v pref.Value) (*Value, error) {
+	var ok bool
+	switch fd.Kind() {
+	case pref.BoolKind:
+		_, ok = v.Interface().(bool)
+	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind:
+		_, ok = v.Interface().(int32)
+	case pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
+		_, ok = v.Interface().(int64)
+	case pref.Uint32Kind, pref.Fixed32Kind:
+		_, ok = v.Interface().(uint32)
+	case pref.Uint64Kind, pref.Fixed64Kind:
+		_, ok = v.Interface().(uint64)
+	case pref.FloatKind:
+		_, ok = v.Interface().(float32)
+	case pref.DoubleKind:
+		_, ok = v.Interface().(float64)
+	case pref.StringKind:
+		_, ok = v.Interface().(string)
+	case pref.BytesKind:
+		_, ok = v.Interface().([]byte)
+	case pref.EnumKind:
+		_, ok = v.Interface().(pref.EnumNumber)
+	case pref.MessageKind, pref.GroupKind:
+		var m pref.Message
+		m, ok = v.Interface().(pref.Message)
+		ok = ok && m.Descriptor().FullName() == fd.Message().FullName()
+	}
+	if !ok {
+		return nil, errors.New("invalid value of type %T for field %v", v.Interface(), fd.FullName())
+	}
+
+	if fd.Message() != nil {
+		b, err := prototext.MarshalOptions{
+			AllowPartial: true,
+			Resolver:     d.opts.Resolver,
+		}.Marshal(v.Message().Interface())
+		if err != nil {
+			return nil, err
+		}
+		m, err := parse(b)
+		if err != nil {
+			return nil, err
+		}
+		return &Value{Kind: MessageValue, Message: m}, nil
+	}
+
+	e, _ := text.NewEncoder("", [2]byte{}, text.EncoderOptions{})
+	switch fd.Kind() {
+	case pref.BoolKind:
+		e.WriteBool(v.Bool())
+	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind,
+		pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
+		e.WriteInt(v.Int())
+	case pref.Uint32Kind, pref.Fixed32Kind, pref.Uint64Kind, pref.Fixed64Kind:
+		e.WriteUint(v.Uint())
+	case pref.FloatKind:
+		e.WriteFloat(v.Float(), 32)
+	case pref.DoubleKind:
+		e.WriteFloat(v.Float(), 64)
+	case pref.StringKind:
+		if !utf8.ValidString(v.String()) {
+			return nil, errors.InvalidUTF8(string(fd.FullName()))
+		}
+		e.WriteString(v.String())
+	case pref.BytesKind:
+		e.WriteString(string(v.Bytes()))
+	case pref.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			e.WriteLiteral(string(ev.Name()))
+		} else {
+			e.WriteInt(int64(v.Enum()))
+		}
+	}
+	return &Value{Kind: ScalarValue, Scalar: string(e.Bytes())}, nil
+}
+
+// findField returns the field of md identified by name in a path.
+func (d *Document) findField(md pref.MessageDescriptor, name string) (pref.FieldDescriptor, error) {
+	var resolver protoregistry.ExtensionTypeResolver = protoregistry.GlobalTypes
+	if d.opts.Resolver != nil {
+		resolver = d.opts.Resolver
+	}
+	switch {
+	case name[0] == '[':
+		xt, err := resolver.FindExtensionByName(pref.FullName(name[1 : len(name)-1]))
+		if err != nil {
+			return nil, errors.New("unable to resolve %s: %v", name, err)
+		}
+		xd := xt.TypeDescriptor()
+		if xd.ContainingMessage().FullName() != md.FullName() {
+			return nil, errors.New("extension %v does not extend %v", xd.FullName(), md.FullName())
+		}
+		return xd, nil
+	case name[0] >= '0' && name[0] <= '9':
+		n, err := strconv.ParseInt(name, 10, 32)
+		if err != nil {
+			break
+		}
+		if fd := md.Fields().ByNumber(pref.FieldNumber(n)); fd != nil {
+			return fd, nil
+		}
+		if xt, err := resolver.FindExtensionByNumber(md.FullName(), pref.FieldNumber(n)); err == nil {
+			return xt.TypeDescriptor(), nil
+		}
+	default:
+		if fd := md.Fields().ByName(pref.Name(name)); fd != nil {
+			return fd, nil
+		}
+		// Groups are named by their message name in the text format.
+		fd := md.Fields().ByName(pref.Name(strings.ToLower(name)))
+		if fd != nil && fd.Kind() == pref.GroupKind && fd.Message().Name() == pref.Name(name) {
+			return fd, nil
+		}
+	}
+	return nil, errors.New("%v has no field %s", md.FullName(), name)
+}
+
+// fieldMatches reports whether name, as written in a document, names fd.
+func fieldMatches(name string, fd pref.FieldDescriptor) bool {
+	switch {
+	case name == "":
+		return false
+	case name[0] == '[':
+		return fd.IsExtension() && removeSpace(name[1:len(name)-1]) == string(fd.FullName())
+	case name[0] >= '0' && name[0] <= '9':
+		return name == strconv.Itoa(int(fd.Number()))
+	case fd.IsExtension():
+		return false
+	case fd.Kind() == pref.GroupKind:
+		return name == string(fd.Message().Name())
+	default:
+		return name == string(fd.Name())
+	}
+}
+
+// fieldName returns the name used for fd in new fields.
+func fieldName(fd pref.FieldDescriptor) string {
+	switch {
+	case fd.IsExtension():
+		return "[" + string(fd.FullName()) + "]"
+	case fd.Kind() == pref.GroupKind:
+		return string(fd.Message().Name())
+	default:
+		return string(fd.Name())
+	}
+}
+
+// pathElem is a field name in a path with an optional index.
+type pathElem struct {
+	name  string
+	index int // -1 if absent
+}
+
+func parsePath(path string) ([]pathElem, error) {
+	var elems []pathElem
+	s := path
+	for {
+		e := pathElem{index: -1}
+		if strings.HasPrefix(s, "[") {
+			i := strings.IndexByte(s, ']')
+			if i < 0 {
+				return nil, errors.New("invalid path %q", path)
+			}
+			e.name, s = s[:i+1], s[i+1:]
+		} else {
+			i := strings.IndexAny(s, ".[")
+			if i < 0 {
+				i = len(s)
+			}
+			e.name, s = s[:i], s[i:]
+		}
+		if e.name == "" || e.name == "[]" {
+			return nil, errors.New("invalid path %q", path)
+		}
+		if strings.HasPrefix(s, "[") {
+			i := strings.IndexByte(s, ']')
+			if i < 0 {
+				return nil, errors.New("invalid path %q", path)
+			}
+			n, err := strconv.Atoi(s[1:i])
+			if err != nil || n < 0 {
+				return nil, errors.New("invalid index in path %q", path)
+			}
+			e.index, s = n, s[i+1:]
+		}
+		elems = append(elems, e)
+		if s == "" {
+			return elems, nil
+		}
+		if s[0] != '.' {
+			return nil, errors.New("invalid path %q", path)
+		}
+		s = s[1:]
+	}
+}
+
+// cursor is a message of a document together with its descriptor.
+type cursor struct {
+	m      *Message
+	md     pref.MessageDescriptor
+	indent string // indentation of the line on which the message starts
+}
+
+// occurrence is a value of a field in a message, which is either the value
+// of a field or an element of its list value.
+type occurrence struct {
+	field  int    // index of the field in the message
+	elem   int    // index of the element in the list value, or -1
+	indent string // indentation of the line on which the value starts
+}
+
+func (o occurrence) value(m *Message) *Value {
+	v := m.Fields[o.field].Value
+	if o.elem >= 0 {
+		return v.List[o.elem]
+	}
+	return v
+}
+
+// occurrences returns the values of fd in the message at c.
+func (c cursor) occurrences(fd pref.FieldDescriptor) []occurrence {
+	var occs []occurrence
+	for i, f := range c.m.Fields {
+		if !fieldMatches(f.Name, fd) {
+			continue
+		}
+		indent := lineIndent(f.Before, c.indent)
+		if f.Value.Kind != ListValue {
+			occs = append(occs, occurrence{i, -1, indent})
+			continue
+		}
+		for j, e := range f.Value.List {
+			occs = append(occs, occurrence{i, j, lineIndent(e.Before, indent)})
+		}
+	}
+	return occs
+}
+
+// layout reports whether the fields of the message at c are on separate
+// lines, and the indentation of its fields.
+func (c cursor) layout() (multiline bool, indent string) {
+	m := c.m
+	if len(m.Fields) == 0 {
+		if m.Open == "" {
+			return true, c.indent
+		}
+		return true, c.indent + indentUnit
+	}
+	for _, f := range m.Fields {
+		if i := strings.LastIndexByte(f.Before, '\n'); i >= 0 {
+			return true, f.Before[i+1:]
+		}
+	}
+	return m.Open == "", c.indent
+}
+
+// addField adds the field fd with value v to the message at c, after the
+// last occurrence of fd or at the end of the message.
+func (c cursor) addField(fd pref.FieldDescriptor, v *Value) {
+	m := c.m
+	k := len(m.Fields)
+	if occs := c.occurrences(fd); len(occs) > 0 {
+		k = occs[len(occs)-1].field + 1
+	}
+	next := &m.End
+	if k < len(m.Fields) {
+		next = &m.Fields[k].Before
+	}
+
+	f := &Field{Name: fieldName(fd), Value: v}
+	multiline, indent := c.layout()
+	switch {
+	case len(m.Fields) == 0 && m.Open == "":
+		// Comments in an empty document are kept as its header.
+		f.Before, m.End = m.End, "\n"
+		if f.Before != "" && !strings.HasSuffix(f.Before, "\n") {
+			f.Before += "\n"
+		}
+	case multiline:
+		// Keep the rest of the line of the preceding field, such as a
+		// trailing comment, before the new field.
+		i := strings.IndexByte(*next, '\n')
+		if i < 0 {
+			i = len(*next)
+		}
+		rest := (*next)[i:]
+		f.Before = (*next)[:i] + "\n" + indent
+		if next == &m.End && m.Open != "" && !strings.Contains(rest, "\n") {
+			rest = "\n" + c.indent
+		}
+		*next = rest
+	default:
+		f.Before = " "
+	}
+	layoutValue(v, multiline, indent)
+	m.Fields = append(m.Fields[:k], append([]*Field{f}, m.Fields[k:]...)...)
+}
+
+// replaceValue replaces the value at o in m with v, keeping the text
+// preceding the value.
+func replaceValue(o occurrence, m *Message, v *Value) {
+	old := o.value(m)
+	multiline := true
+	if old.Kind == MessageValue {
+		multiline = isMultiline(old.Message)
+	}
+	layoutValue(v, multiline, o.indent)
+	v.Before = old.Before
+	*old = *v
+}
+
+// isMultiline reports whether m spans several lines.
+func isMultiline(m *Message) bool {
+	for _, f := range m.Fields {
+		if strings.Contains(f.Before, "\n") {
+			return true
+		}
+	}
+	return strings.Contains(m.End, "\n")
+}
+
+// layoutValue sets the whitespace of a new value of a field on a line with
+// the given indentation.
+func layoutValue(v *Value, multiline bool, indent string) {
+	switch v.Kind {
+	case ScalarValue:
+		v.Before = ": "
+	case MessageValue:
+		v.Before = " "
+		m := v.Message
+		m.Open, m.Close, m.End = "{", "}", ""
+		if len(m.Fields) == 0 {
+			return
+		}
+		inner := indent + indentUnit
+		for _, f := range m.Fields {
+			f.Before = " "
+			if multiline {
+				f.Before = "\n" + inner
+			}
+			layoutValue(f.Value, multiline, inner)
+		}
+		m.End = " "
+		if multiline {
+			m.End = "\n" + indent
+		}
+	case ListValue:
+		v.Before = ": "
+		for i, e := range v.List {
+			layoutValue(e, false, indent)
+			e.Before = ""
+			if i > 0 {
+				e.Before = ", "
+			}
+		}
+		v.ListEnd = ""
+	}
+}
+
+// removeField removes the i-th field of m. The comments directly above the
+// field and on the rest of its line are removed with it.
+func removeField(m *Message, i int) {
+	before := m.Fields[i].Before
+	var keep string
+	if j := strings.LastIndex(before, "\n\n"); j >= 0 {
+		keep = before[:j+2]
+	} else if j := strings.IndexByte(before, '\n'); j >= 0 && i > 0 {
+		// Keep the rest of the line of the preceding field.
+		keep = before[:j+1]
+	}
+	m.Fields = append(m.Fields[:i:i], m.Fields[i+1:]...)
+
+	next := &m.End
+	if i < len(m.Fields) {
+		next = &m.Fields[i].Before
+	}
+	rest := *next
+	if j := strings.IndexByte(rest, '\n'); j >= 0 {
+		rest = rest[j+1:]
+	}
+	*next = keep + rest
+}
+
+// lineIndent returns the indentation of the line following the last newline
+// in s, or indent if s has no newline.
+func lineIndent(s, indent string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return indent
+}
@@ -0,0 +1,339 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textedit
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/internal/pragma"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ReformatOptions configures Document.Reformat.
+type ReformatOptions struct {
+	pragma.NoUnkeyedLiterals
+
+	// Indent is the indentation of each level of nesting, which may only be
+	// composed of space or tab characters. If empty, two spaces are used.
+	Indent string
+
+	// SortFields specifies whether to sort the fields of each message by
+	// field number. Fields that cannot be resolved with the schema of the
+	// document are placed last, in their original order. Fields are only
+	// sorted in documents parsed with a Schema.
+	SortFields bool
+}
+
+// Reformat rewrites the layout of the document in a canonical style: every
+// field is on a line of its own, indented by its nesting level, messages are
+// delimited by braces, field separators are removed, runs of blank lines are
+// reduced to one, and lists of messages or with comments have an element per
+// line. Comments are kept with the field that they precede or trail.
+//
+// If the document has a schema, fields identified by number are named, enum
+// values written as numbers are replaced by the names of the values, and the
+// names of extensions are written without whitespace.
+func (d *Document) Reformat(o ReformatOptions) error {
+	if o.Indent == "" {
+		o.Indent = indentUnit
+	}
+	if strings.Trim(o.Indent, " \t") != "" {
+		return errors.New("indent may only be composed of space and tab characters")
+	}
+	r := &reformatter{opts: o, doc: d}
+	r.fields(d.Root, d.opts.Schema, true)
+	root, err := parse(r.out)
+	if err != nil {
+		return err
+	}
+	*d.Root = *root
+	return nil
+}
+
+// reformatter writes a document in the canonical style.
+type reformatter struct {
+	opts   ReformatOptions
+	doc    *Document
+	out    []byte
+	indent string
+	start  bool // at the start of a block
+	blank  bool // a blank line is pending
+}
+
+// entry is a field together with its comments.
+type entry struct {
+	f        *Field
+	name     string
+	fd       pref.FieldDescriptor
+	md       pref.MessageDescriptor // descriptor of a message value
+	leading  []string
+	trailing string
+}
+
+// fields writes the fields of m, which is the top level of the document if
+// root is set. The comment trailing the opening delimiter of m is written by
+// the caller.
+func (r *reformatter) fields(m *Message, md pref.MessageDescriptor, root bool) {
+	es := make([]entry, len(m.Fields))
+	for i, f := range m.Fields {
+		before := f.Before
+		if root && i == 0 {
+			before = "\n" + before
+		}
+		trailing, lines := splitComments(before)
+		if i > 0 {
+			es[i-1].trailing = trailing
+		}
+		es[i].f = f
+		es[i].name, es[i].fd, es[i].md = r.resolve(md, f.Name)
+		es[i].leading = append(lines, comments(f.Value.Before)...)
+	}
+	end := m.End
+	if root && len(m.Fields) == 0 {
+		end = "\n" + end
+	}
+	trailing, endLines := splitComments(end)
+	if len(es) > 0 {
+		es[len(es)-1].trailing = trailing
+	}
+	if r.opts.SortFields && md != nil {
+		sort.SliceStable(es, func(i, j int) bool {
+			return fieldOrder(es[i].fd) < fieldOrder(es[j].fd)
+		})
+	}
+
+	r.start = true
+	for _, e := range es {
+		r.lines(e.leading)
+		r.beginLine()
+		r.out = append(r.out, e.name...)
+		r.value(e.f.Value, e.fd, e.md)
+		r.endLine(e.trailing)
+	}
+	r.lines(endLines)
+	r.blank = false
+}
+
+func fieldOrder(fd pref.FieldDescriptor) int {
+	if fd == nil {
+		return math.MaxInt32 + 1
+	}
+	return int(fd.Number())
+}
+
+// value writes v as the value of the field fd, or as an element of fd if fd
+// is repeated. The descriptor of a message value is md.
+func (r *reformatter) value(v *Value, fd pref.FieldDescriptor, md pref.MessageDescriptor) {
+	switch v.Kind {
+	case ScalarValue:
+		r.out = append(r.out, ": "...)
+		r.out = append(r.out, r.scalar(v.Scalar, fd)...)
+	case MessageValue:
+		r.out = append(r.out, ' ')
+		r.message(v.Message, md)
+	case ListValue:
+		r.out = append(r.out, ": "...)
+		r.list(v, fd, md)
+	}
+}
+
+func (r *reformatter) message(m *Message, md pref.MessageDescriptor) {
+	if len(m.Fields) == 0 && !strings.Contains(m.End, "#") {
+		r.out = append(r.out, "{}"...)
+		return
+	}
+	r.out = append(r.out, '{')
+	if len(m.Fields) > 0 {
+		r.endLine(firstComment(m.Fields[0].Before))
+	} else {
+		r.endLine(firstComment(m.End))
+	}
+	r.indent += r.opts.Indent
+	r.fields(m, md, false)
+	r.indent = r.indent[:len(r.indent)-len(r.opts.Indent)]
+	r.beginLine()
+	r.out = append(r.out, '}')
+}
+
+func (r *reformatter) list(v *Value, fd pref.FieldDescriptor, md pref.MessageDescriptor) {
+	compact := !strings.Contains(v.ListEnd, "#")
+	for _, e := range v.List {
+		if e.Kind != ScalarValue || strings.Contains(e.Before, "#") {
+			compact = false
+		}
+	}
+	if compact {
+		r.out = append(r.out, '[')
+		for i, e := range v.List {
+			if i > 0 {
+				r.out = append(r.out, ", "...)
+			}
+			r.out = append(r.out, r.scalar(e.Scalar, fd)...)
+		}
+		r.out = append(r.out, ']')
+		return
+	}
+
+	r.out = append(r.out, '[')
+	befores := make([]string, len(v.List)+1)
+	for i, e := range v.List {
+		befores[i] = e.Before
+	}
+	befores[len(v.List)] = v.ListEnd
+	r.endLine(firstComment(befores[0]))
+	r.indent += r.opts.Indent
+	r.start = true
+	for i, e := range v.List {
+		_, lines := splitComments(befores[i])
+		r.lines(lines)
+		r.beginLine()
+		switch e.Kind {
+		case ScalarValue:
+			r.out = append(r.out, r.scalar(e.Scalar, fd)...)
+		case MessageValue:
+			r.message(e.Message, md)
+		}
+		if i < len(v.List)-1 {
+			r.out = append(r.out, ',')
+		}
+		r.endLine(firstComment(befores[i+1]))
+	}
+	_, lines := splitComments(v.ListEnd)
+	r.lines(lines)
+	r.blank = false
+	r.indent = r.indent[:len(r.indent)-len(r.opts.Indent)]
+	r.beginLine()
+	r.out = append(r.out, ']')
+}
+
+// scalar returns the literal s of a value of fd, with enum values written as
+// numbers replaced by names.
+func (r *reformatter) scalar(s string, fd pref.FieldDescriptor) string {
+	if fd == nil || fd.Kind() != pref.EnumKind {
+		return s
+	}
+	n, err := strconv.ParseInt(s, 0, 32)
+	if err != nil {
+		return s
+	}
+	if ev := fd.Enum().Values().ByNumber(pref.EnumNumber(n)); ev != nil {
+		return string(ev.Name())
+	}
+	return s
+}
+
+// resolve returns the canonical form of the field name of a field in a
+// message of type md, along with the field and the type of a message value,
+// if known.
+func (r *reformatter) resolve(md pref.MessageDescriptor, name string) (string, pref.FieldDescriptor, pref.MessageDescriptor) {
+	if name[0] == '[' {
+		name = "[" + removeSpace(name[1:len(name)-1]) + "]"
+	}
+	if md == nil {
+		return name, nil, nil
+	}
+	if name[0] == '[' && strings.Contains(name, "/") {
+		// Expanded google.protobuf.Any.
+		var resolver protoregistry.MessageTypeResolver = protoregistry.GlobalTypes
+		if r.doc.opts.Resolver != nil {
+			resolver = r.doc.opts.Resolver
+		}
+		mt, err := resolver.FindMessageByURL(name[1 : len(name)-1])
+		if err != nil {
+			return name, nil, nil
+		}
+		return name, nil, mt.Descriptor()
+	}
+	fd, err := r.doc.findField(md, name)
+	if err != nil {
+		return name, nil, nil
+	}
+	return fieldName(fd), fd, fd.Message()
+}
+
+func (r *reformatter) lines(lines []string) {
+	for _, s := range lines {
+		if s == "" {
+			r.blank = true
+			continue
+		}
+		r.beginLine()
+		r.out = append(r.out, s...)
+		r.out = append(r.out, '\n')
+	}
+}
+
+func (r *reformatter) beginLine() {
+	if r.blank && !r.start {
+		r.out = append(r.out, '\n')
+	}
+	r.blank, r.start = false, false
+	r.out = append(r.out, r.indent...)
+}
+
+func (r *reformatter) endLine(comment string) {
+	if comment != "" {
+		r.out = append(r.out, ' ')
+		r.out = append(r.out, comment...)
+	}
+	r.out = append(r.out, '\n')
+}
+
+// splitComments splits the text s between two tokens into the comment on the
+// line of the preceding token, if any, and the lines that follow, where each
+// line is either a comment or empty for a blank line.
+func splitComments(s string) (trailing string, lines []string) {
+	segs := strings.Split(s, "\n")
+	trailing = comment(segs[0])
+	segs = segs[1:]
+	if n := len(segs); n > 0 && comment(segs[n-1]) == "" {
+		// The last line continues with the next token.
+		segs = segs[:n-1]
+	}
+	for _, seg := range segs {
+		lines = append(lines, comment(seg))
+	}
+	return trailing, lines
+}
+
+// firstComment returns the comment on the first line of s, if any.
+func firstComment(s string) string {
+	return comment(strings.SplitN(s, "\n", 2)[0])
+}
+
+// comments returns all comments in s.
+func comments(s string) []string {
+	var cs []string
+	for _, seg := range strings.Split(s, "\n") {
+		if c := comment(seg); c != "" {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// comment returns the comment in the line s, if any.
+func comment(s string) string {
+	i := strings.IndexByte(s, '#')
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimRight(s[i:], " \t\r")
+}
+
+func removeSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\v', '\f':
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,304 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package textedit parses and edits documents in the protocol buffer text
+// format while preserving their comments, whitespace and field order.
+//
+// A Document is a syntax tree that keeps every token of its input together
+// with the text preceding it, such that formatting an unmodified Document
+// reproduces the input byte for byte. Set and Remove only rewrite the fields
+// that they touch, so that an edited document keeps its layout and comments,
+// and a diff against the original shows the edit alone.
+package textedit
+
+import (
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/internal/encoding/text"
+	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/internal/pragma"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Document is a parsed text format document.
+type Document struct {
+	// Root holds the top-level fields of the document.
+	Root *Message
+
+	opts ParseOptions
+}
+
+// Message is a sequence of fields, either at the top level of a document or
+// enclosed in a pair of delimiters.
+type Message struct {
+	// Open and Close are the delimiters of the message, either "{" and "}" or
+	// "<" and ">". Both are empty for the top level of a document.
+	Open, Close string
+
+	// Fields are the fields of the message in the order in which they appear.
+	Fields []*Field
+
+	// End is the text between the last field and Close, or the end of the
+	// document for the top level.
+	End string
+}
+
+// Field is a single field of a message.
+type Field struct {
+	// Before is the text preceding the field name, consisting of whitespace,
+	// comments and field separators.
+	Before string
+
+	// Name is the field name as written, such as "foo", "[pkg.ext]" or "12".
+	Name string
+
+	// Value is the value of the field. Its Before text holds the ':' that
+	// separates the name from the value, if any.
+	Value *Value
+}
+
+// ValueKind is the kind of a Value.
+type ValueKind int
+
+const (
+	ScalarValue  ValueKind = iota // a literal, such as 42, "str" or FOO
+	MessageValue                  // a message enclosed in delimiters
+	ListValue                     // a list enclosed in '[' and ']'
+)
+
+// Value is the value of a field or an element of a list.
+type Value struct {
+	// Before is the text preceding the value.
+	Before string
+
+	// Kind is the kind of the value.
+	Kind ValueKind
+
+	// Scalar is the literal of a ScalarValue as written, such as "42", "FOO"
+	// or `"a" "b"`.
+	Scalar string
+
+	// Message is the message of a MessageValue.
+	Message *Message
+
+	// List holds the elements of a ListValue. The Before text of each element
+	// holds the comma preceding it, if any.
+	List []*Value
+
+	// ListEnd is the text between the last element of a ListValue and the
+	// closing ']'.
+	ListEnd string
+}
+
+// Parse parses the text format document b using default options.
+func Parse(b []byte) (*Document, error) {
+	return ParseOptions{}.Parse(b)
+}
+
+// ParseOptions is a configurable text format parser.
+type ParseOptions struct {
+	pragma.NoUnkeyedLiterals
+
+	// Schema is the descriptor of the message that the document represents.
+	// If set, Parse reports an error if the document is not a valid text
+	// format representation of the message, and the resulting Document may
+	// be edited with Set and Remove. Missing required fields are permitted.
+	Schema pref.MessageDescriptor
+
+	// Resolver is used for looking up extensions and the types of expanded
+	// google.protobuf.Any messages. If nil, this defaults to using
+	// protoregistry.GlobalTypes.
+	Resolver interface {
+		protoregistry.MessageTypeResolver
+		protoregistry.ExtensionTypeResolver
+	}
+}
+
+// Parse parses the text format document b.
+func (o ParseOptions) Parse(b []byte) (*Document, error) {
+	root, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	d := &Document{Root: root, opts: o}
+	if err := d.validate(b); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Format returns the text of the document.
+func (d *Document) Format() []byte {
+	return appendMessage(nil, d.Root)
+}
+
+// validate checks that b is a valid representation of the schema, if any.
+func (d *Document) validate(b []byte) error {
+	if d.opts.Schema == nil {
+		return nil
+	}
+	return prototext.UnmarshalOptions{
+		AllowPartial:      true,
+		AllowFieldNumbers: true,
+		Resolver:          d.opts.Resolver,
+	}.Unmarshal(b, dynamicpb.NewMessage(d.opts.Schema))
+}
+
+// parser builds the syntax tree of a document from the tokens of a
+// text.Decoder, attributing the text between tokens to the following token.
+type parser struct {
+	dec *text.Decoder
+	in  []byte
+	pos int // offset of the end of the last token read
+}
+
+func parse(b []byte) (*Message, error) {
+	p := &parser{dec: text.NewDecoder(b), in: b}
+	m := &Message{}
+	if err := p.parseFields(m, text.EOF); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// read returns the next token and the text preceding it.
+func (p *parser) read() (text.Token, string, error) {
+	tok, err := p.dec.Read()
+	if err != nil {
+		return text.Token{}, "", err
+	}
+	before := string(p.in[p.pos:tok.Pos()])
+	p.pos = tok.Pos() + tokenLen(tok.RawString())
+	return tok, before, nil
+}
+
+// tokenLen returns the length of the raw text of a token. The raw text of a
+// string literal includes the whitespace and comments following it, which
+// are left to the next token.
+func tokenLen(raw string) int {
+	if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+		return len(raw)
+	}
+	var n int
+	for i := 0; i < len(raw); {
+		switch q := raw[i]; q {
+		case '"', '\'':
+			for i++; i < len(raw) && raw[i] != q; i++ {
+				if raw[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			n = i
+		case '#':
+			for i < len(raw) && raw[i] != '\n' {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return n
+}
+
+// parseFields parses fields into m until a token of the given kind.
+func (p *parser) parseFields(m *Message, end text.Kind) error {
+	for {
+		tok, before, err := p.read()
+		if err != nil {
+			return err
+		}
+		switch tok.Kind() {
+		case end:
+			m.End, m.Close = before, tok.RawString()
+			return nil
+		case text.Name:
+			// Continue below.
+		default:
+			return errors.New("unexpected token %s", tok.RawString())
+		}
+
+		f := &Field{Before: before, Name: tok.RawString()}
+		if f.Value, err = p.parseValue(); err != nil {
+			return err
+		}
+		m.Fields = append(m.Fields, f)
+	}
+}
+
+// parseValue parses the value of a field or an element of a list.
+func (p *parser) parseValue() (*Value, error) {
+	tok, before, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+	v := &Value{Before: before}
+	switch tok.Kind() {
+	case text.Scalar:
+		v.Kind = ScalarValue
+		v.Scalar = string(p.in[tok.Pos():p.pos])
+	case text.MessageOpen:
+		v.Kind = MessageValue
+		v.Message = &Message{Open: tok.RawString()}
+		err = p.parseFields(v.Message, text.MessageClose)
+	case text.ListOpen:
+		v.Kind = ListValue
+		err = p.parseList(v)
+	default:
+		return nil, errors.New("unexpected token %s", tok.RawString())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// parseList parses the elements of the list value v.
+func (p *parser) parseList(v *Value) error {
+	for {
+		tok, err := p.dec.Peek()
+		if err != nil {
+			return err
+		}
+		if tok.Kind() == text.ListClose {
+			_, v.ListEnd, _ = p.read()
+			return nil
+		}
+		e, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		v.List = append(v.List, e)
+	}
+}
+
+func appendMessage(b []byte, m *Message) []byte {
+	b = append(b, m.Open...)
+	for _, f := range m.Fields {
+		b = append(b, f.Before...)
+		b = append(b, f.Name...)
+		b = appendValue(b, f.Value)
+	}
+	b = append(b, m.End...)
+	return append(b, m.Close...)
+}
+
+func appendValue(b []byte, v *Value) []byte {
+	b = append(b, v.Before...)
+	switch v.Kind {
+	case ScalarValue:
+		b = append(b, v.Scalar...)
+	case MessageValue:
+		b = appendMessage(b, v.Message)
+	case ListValue:
+		b = append(b, '[')
+		for _, e := range v.List {
+			b = appendValue(b, e)
+		}
+		b = append(b, v.ListEnd...)
+		b = append(b, ']')
+	}
+	return b
+}
@@ -0,0 +1,431 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package textedit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/protobuf/encoding/textedit"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	pb2 "google.golang.org/protobuf/internal/testprotos/textpb2"
+	pb3 "google.golang.org/protobuf/internal/testprotos/textpb3"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		desc    string
+		input   string
+		wantErr string // Expected error substring.
+	}{{
+		desc:  "empty",
+		input: "",
+	}, {
+		desc:  "only comments",
+		input: "# header\n\n# trailer\n",
+	}, {
+		desc: "comments and whitespace",
+		input: `# header
+
+# about a
+a: 1  # trailing
+b : "x" 'y'
+  "z" # concatenated
+c{
+  # inside
+  d: FOO;
+}
+`,
+	}, {
+		desc:  "separators and delimiters",
+		input: "a: 1, b <c: 2; d: [1, 2 ,3]>; e: [] f [{}, <>]",
+	}, {
+		desc:  "extensions and field numbers",
+		input: "[pkg.ext]: 1\n[ pkg . other ] {}\n12: 0x1F\n[type.googleapis.com/pkg.M] { x: 1 }",
+	}, {
+		desc:  "no trailing newline",
+		input: "a: -inf # end",
+	}, {
+		desc:    "unclosed message",
+		input:   "a { b: 1",
+		wantErr: "unexpected EOF",
+	}, {
+		desc:    "missing value",
+		input:   "a: 1 b:",
+		wantErr: "unexpected EOF",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			d, err := textedit.Parse([]byte(tt.input))
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error got %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatalf("Parse() got nil error, want error %q", tt.wantErr)
+			}
+			if got := string(d.Format()); got != tt.input {
+				t.Errorf("Format() mismatch (-want +got):\n%s", cmp.Diff(tt.input, got))
+			}
+		})
+	}
+}
+
+func TestEdit(t *testing.T) {
+	type edit struct {
+		path   string
+		value  pref.Value // Remove the field if invalid.
+		errStr string     // Expected error substring.
+	}
+	set := func(path string, v pref.Value) edit { return edit{path: path, value: v} }
+	remove := func(path string) edit { return edit{path: path} }
+
+	tests := []struct {
+		desc   string
+		schema proto.Message
+		input  string
+		edits  []edit
+		want   string
+	}{{
+		desc:   "replace scalar keeps comments",
+		schema: &pb2.Scalars{},
+		input: `# header
+opt_int32: 1  # trailing
+# about opt_string
+opt_string: "a"
+`,
+		edits: []edit{
+			set("opt_int32", pref.ValueOfInt32(5)),
+			set("opt_string", pref.ValueOfString("b\n")),
+		},
+		want: `# header
+opt_int32: 5  # trailing
+# about opt_string
+opt_string: "b\n"
+`,
+	}, {
+		desc:   "add scalar after trailing comment",
+		schema: &pb2.Scalars{},
+		input:  "opt_bool: true # on\n\n# end\n",
+		edits: []edit{
+			set("opt_double", pref.ValueOfFloat64(1.5)),
+			set("opt_uint64", pref.ValueOfUint64(7)),
+		},
+		want: "opt_bool: true # on\nopt_double: 1.5\nopt_uint64: 7\n\n# end\n",
+	}, {
+		desc:   "add to empty document",
+		schema: &pb2.Enums{},
+		input:  "# header\n",
+		edits: []edit{
+			set("opt_enum", pref.ValueOfEnum(2)),
+			set("rpt_enum", pref.ValueOfEnum(1)),
+			set("rpt_enum", pref.ValueOfEnum(42)),
+		},
+		want: "# header\nopt_enum: TWO\nrpt_enum: ONE\nrpt_enum: 42\n",
+	}, {
+		desc:   "create nested messages",
+		schema: &pb2.Nests{},
+		input:  "opt_nested {\n    opt_string: \"a\"\n}\n",
+		edits: []edit{
+			set("opt_nested.opt_nested.opt_nested.opt_string", pref.ValueOfString("b")),
+			set("OptGroup.OptNestedGroup.opt_fixed32", pref.ValueOfUint32(3)),
+		},
+		want: `opt_nested {
+    opt_string: "a"
+    opt_nested {
+      opt_nested {
+        opt_string: "b"
+      }
+    }
+}
+OptGroup {
+  OptNestedGroup {
+    opt_fixed32: 3
+  }
+}
+`,
+	}, {
+		desc:   "single-line message",
+		schema: &pb2.Nests{},
+		input:  "opt_nested < opt_string: \"a\" >\n",
+		edits: []edit{
+			set("opt_nested.opt_nested", pref.ValueOfMessage((&pb2.Nested{OptString: proto.String("b")}).ProtoReflect())),
+		},
+		want: "opt_nested < opt_string: \"a\" opt_nested { opt_string: \"b\" } >\n",
+	}, {
+		desc:   "repeated messages",
+		schema: &pb2.Nests{},
+		input: `rpt_nested { opt_string: "a" }
+# separate
+rpt_nested: [{ opt_string: "b" }, { opt_string: "c" }]
+opt_nested {}
+`,
+		edits: []edit{
+			set("rpt_nested[2].opt_string", pref.ValueOfString("C")),
+			set("rpt_nested", pref.ValueOfMessage((&pb2.Nested{OptString: proto.String("d")}).ProtoReflect())),
+			remove("rpt_nested[1]"),
+			remove("rpt_nested[5]"),
+		},
+		want: `rpt_nested { opt_string: "a" }
+# separate
+rpt_nested: [{ opt_string: "C" }]
+rpt_nested {
+  opt_string: "d"
+}
+opt_nested {}
+`,
+	}, {
+		desc:   "remove fields and attached comments",
+		schema: &pb2.Scalars{},
+		input: `# header
+
+# about opt_bool
+opt_bool: true # on
+opt_int32: 1 # one
+
+# detached
+
+# about opt_int64
+opt_int64: 2
+opt_string: "x"
+`,
+		edits: []edit{
+			remove("opt_bool"),
+			remove("opt_int64"),
+			remove("opt_float"),
+		},
+		want: `# header
+
+opt_int32: 1 # one
+
+# detached
+
+opt_string: "x"
+`,
+	}, {
+		desc:   "oneof member",
+		schema: &pb3.Oneofs{},
+		input:  "# s\noneof_string: \"a\"\n",
+		edits: []edit{
+			set("oneof_enum", pref.ValueOfEnum(1)),
+		},
+		want: "oneof_enum: ONE\n",
+	}, {
+		desc:   "extensions and field numbers",
+		schema: &pb2.Extensions{},
+		input:  "opt_string: \"a\"\n[pb2.opt_ext_bool]: true\n2: 5\n",
+		edits: []edit{
+			set("[pb2.opt_ext_bool]", pref.ValueOfBool(false)),
+			set("opt_int32", pref.ValueOfInt32(6)),
+			set("[pb2.opt_ext_nested].opt_string", pref.ValueOfString("b")),
+			remove("1"),
+		},
+		want: "[pb2.opt_ext_bool]: false\n2: 6\n[pb2.opt_ext_nested] {\n  opt_string: \"b\"\n}\n",
+	}, {
+		desc:   "errors leave document unchanged",
+		schema: &pb2.Nests{},
+		input:  "opt_nested { opt_string: \"a\" }\n",
+		edits: []edit{
+			{path: "opt_nested.unknown", value: pref.ValueOfString("x"), errStr: "pb2.Nested has no field unknown"},
+			{path: "opt_nested.opt_nested.opt_string", value: pref.ValueOfInt32(1), errStr: "invalid value of type int32"},
+			{path: "opt_nested.opt_string.x", value: pref.ValueOfString("x"), errStr: "is not a message"},
+			{path: "rpt_nested.opt_string", value: pref.ValueOfString("x"), errStr: "requires an index"},
+			{path: "rpt_nested[0]", value: pref.ValueOfMessage((&pb2.Nested{}).ProtoReflect()), errStr: "index 0 out of range"},
+			{path: "opt_nested[0]", value: pref.ValueOfString("x"), errStr: "is not repeated"},
+			{path: "opt_nested..x", value: pref.ValueOfString("x"), errStr: "invalid path"},
+			{path: "opt_nested.opt_string", value: pref.ValueOfString("\xff"), errStr: "invalid UTF-8"},
+		},
+		want: "opt_nested { opt_string: \"a\" }\n",
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			d, err := textedit.ParseOptions{
+				Schema: tt.schema.ProtoReflect().Descriptor(),
+			}.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			for _, e := range tt.edits {
+				var err error
+				if e.value.IsValid() {
+					err = d.Set(e.path, e.value)
+				} else {
+					err = d.Remove(e.path)
+				}
+				if err != nil {
+					if e.errStr == "" || !strings.Contains(err.Error(), e.errStr) {
+						t.Errorf("edit %q: error got %v, want %q", e.path, err, e.errStr)
+					}
+					continue
+				}
+				if e.errStr != "" {
+					t.Errorf("edit %q: got nil error, want error %q", e.path, e.errStr)
+				}
+			}
+			if got := string(d.Format()); got != tt.want {
+				t.Errorf("Format() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	_, err := textedit.ParseOptions{
+		Schema: (&pb2.Scalars{}).ProtoReflect().Descriptor(),
+	}.Parse([]byte("opt_int32: \"a\""))
+	if err == nil {
+		t.Error("Parse() got nil error for invalid document")
+	}
+	d, err := textedit.Parse([]byte("opt_int32: 1"))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if err := d.Set("opt_int32", pref.ValueOfInt32(2)); err == nil {
+		t.Error("Set() got nil error for document without schema")
+	}
+}
+
+func TestReformat(t *testing.T) {
+	tests := []struct {
+		desc   string
+		opts   textedit.ReformatOptions
+		schema proto.Message
+		input  string
+		want   string
+	}{{
+		desc:  "empty",
+		input: "",
+		want:  "",
+	}, {
+		desc:  "layout",
+		input: "a:1,b <c:2;d:[1,2 ,3]>; e: [] f [{}, <g: 1>]",
+		want: `a: 1
+b {
+  c: 2
+  d: [1, 2, 3]
+}
+e: []
+f: [
+  {},
+  {
+    g: 1
+  }
+]
+`,
+	}, {
+		desc: "comments",
+		input: `
+
+# header
+
+
+# about a
+a: 1  # trailing a
+b { # open b
+    # about c
+  c: "x"   'y'
+  # end of b
+} # trailing b
+l: [ # open l
+  1, # one
+  # about two
+  2
+]
+
+
+# trailer
+`,
+		want: `# header
+
+# about a
+a: 1 # trailing a
+b { # open b
+  # about c
+  c: "x"   'y'
+  # end of b
+} # trailing b
+l: [ # open l
+  1, # one
+  # about two
+  2
+]
+
+# trailer
+`,
+	}, {
+		desc:  "indent",
+		opts:  textedit.ReformatOptions{Indent: "\t"},
+		input: "a { b { c: 1 } }",
+		want:  "a {\n\tb {\n\t\tc: 1\n\t}\n}\n",
+	}, {
+		desc:   "schema normalization",
+		schema: &pb2.Extensions{},
+		input:  "101: true\n[ pb2 . opt_ext_enum ]: 1\n[pb2.rpt_ext_enum]: [2, 42]\n2: 3\n",
+		want:   "opt_bool: true\n[pb2.opt_ext_enum]: ONE\n[pb2.rpt_ext_enum]: [TWO, 42]\nopt_int32: 3\n",
+	}, {
+		desc:   "sort fields",
+		opts:   textedit.ReformatOptions{SortFields: true},
+		schema: &pb2.Nests{},
+		input: `# about rpt_nested
+rpt_nested {
+  opt_nested { opt_string: "a" }
+  opt_string: "b"
+}
+OptGroup {} # group
+opt_nested {}
+rpt_nested {}
+`,
+		want: `opt_nested {}
+OptGroup {} # group
+# about rpt_nested
+rpt_nested {
+  opt_string: "b"
+  opt_nested {
+    opt_string: "a"
+  }
+}
+rpt_nested {}
+`,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			var o textedit.ParseOptions
+			if tt.schema != nil {
+				o.Schema = tt.schema.ProtoReflect().Descriptor()
+			}
+			d, err := o.Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			if err := d.Reformat(tt.opts); err != nil {
+				t.Fatalf("Reformat() error: %v", err)
+			}
+			got := string(d.Format())
+			if got != tt.want {
+				t.Errorf("Reformat() mismatch (-want +got):\n%s", cmp.Diff(tt.want, got))
+			}
+
+			// Reformatting is idempotent.
+			if err := d.Reformat(tt.opts); err != nil {
+				t.Fatalf("Reformat() error: %v", err)
+			}
+			if got2 := string(d.Format()); got2 != got {
+				t.Errorf("Reformat() is not idempotent (-first +second):\n%s", cmp.Diff(got, got2))
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	gengo "google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
@@ -77,8 +78,10 @@ func init() {
 					gengo.GenerateVersionMarkers = false
 					gengo.GenerateFile(gen, file)
 					generateFieldNumbers(gen, file)
+					generateSourcePaths(gen, file)
 				}
 			}
+			gen.SupportedFeatures = gengo.SupportedFeatures
 			return nil
 		})
 		os.Exit(0)
@@ -246,7 +249,7 @@ func generateRemoteProtos() {
 }
 
 func protoc(args ...string) {
-	cmd := exec.Command("protoc", "--plugin=protoc-gen-go="+os.Args[0])
+	cmd := exec.Command("protoc", "--plugin=protoc-gen-go="+os.Args[0], "--experimental_allow_proto3_optional")
 	cmd.Args = append(cmd.Args, args...)
 	cmd.Env = append(os.Environ(), "RUN_AS_PROTOC_PLUGIN=1")
 	out, err := cmd.CombinedOutput()
@@ -295,6 +298,59 @@ func generateFieldNumbers(gen *protogen.Plugin, file *protogen.File) {
 	processMessages(file.Messages)
 }
 
+// generateSourcePaths generates the field names of descriptor.proto used to
+// format and parse protoreflect.SourcePath.
+func generateSourcePaths(gen *protogen.Plugin, file *protogen.File) {
+	if file.Desc.Path() != "google/protobuf/descriptor.proto" {
+		return
+	}
+
+	importPath := modulePath + "/reflect/protoreflect"
+	g := gen.NewGeneratedFile(importPath+"/source_gen.go", protogen.GoImportPath(importPath))
+	for _, s := range generatedPreamble {
+		g.P(s)
+	}
+	g.P("package ", path.Base(importPath))
+	g.P("")
+
+	var messages []*protogen.Message
+	seen := map[*protogen.Message]bool{}
+	var walk func(*protogen.Message)
+	walk = func(message *protogen.Message) {
+		if seen[message] {
+			return
+		}
+		seen[message] = true
+		messages = append(messages, message)
+		for _, field := range message.Fields {
+			if field.Message != nil && field.Message.Desc.ParentFile() == file.Desc {
+				walk(field.Message)
+			}
+		}
+	}
+	for _, message := range file.Messages {
+		if message.Desc.Name() == "FileDescriptorProto" {
+			walk(message)
+		}
+	}
+
+	for _, message := range messages {
+		g.P("// source", message.GoIdent.GoName, " returns the fields of ", message.Desc.FullName(), ".")
+		g.P("func source", message.GoIdent.GoName, "() []sourceField {")
+		g.P("return []sourceField{")
+		for _, field := range message.Fields {
+			fields := "nil"
+			if seen[field.Message] {
+				fields = "source" + field.Message.GoIdent.GoName
+			}
+			g.P("{", field.Desc.Number(), ", ", strconv.Quote(string(field.Desc.Name())), ", ", field.Desc.IsList(), ", ", fields, "},")
+		}
+		g.P("}")
+		g.P("}")
+		g.P()
+	}
+}
+
 func syncOutput(dstDir, srcDir string) {
 	filepath.Walk(srcDir, func(srcPath string, _ os.FileInfo, _ error) error {
 		if !strings.HasSuffix(srcPath, ".go") && !strings.HasSuffix(srcPath, ".meta") {
@@ -25,6 +25,8 @@ func TestDescriptorAccessors(t *testing.T) {
 		"SourceLocations":       true, // specific to FileDescriptor
 		"ExtensionRangeOptions": true, // specific to MessageDescriptor
 		"DefaultEnumValue":      true, // specific to FieldDescriptor
+		"HasPresence":           true, // specific to FieldDescriptor
+		"HasOptionalKeyword":    true, // specific to FieldDescriptor
 		"MapKey":                true, // specific to FieldDescriptor
 		"MapValue":              true, // specific to FieldDescriptor
 	}
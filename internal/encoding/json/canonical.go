@@ -0,0 +1,181 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Canonicalize returns the given JSON value in the form defined by the JSON
+// Canonicalization Scheme (RFC 8785): without insignificant whitespace, with
+// object members sorted by the UTF-16 code units of their names, with numbers
+// formatted as ECMAScript does and with strings using the minimal escaping.
+//
+// As required by RFC 8785, all numbers are treated as IEEE 754 double
+// precision values, such that integers beyond 2^53 may lose precision.
+func Canonicalize(b []byte) ([]byte, error) {
+	d := NewDecoder(b)
+	out, err := appendCanonical(nil, d)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := d.Read()
+	if err != nil {
+		return nil, err
+	}
+	if tok.kind != EOF {
+		return nil, d.newSyntaxError(tok.pos, unexpectedFmt, tok.RawString())
+	}
+	return out, nil
+}
+
+// appendCanonical reads the next JSON value from d and appends its canonical
+// form to out.
+func appendCanonical(out []byte, d *Decoder) ([]byte, error) {
+	tok, err := d.Read()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case Null, Bool:
+		return append(out, tok.raw...), nil
+
+	case Number:
+		f, ok := tok.Float(64)
+		if !ok {
+			return nil, d.newSyntaxError(tok.pos, "invalid number %s", tok.RawString())
+		}
+		return appendES6Number(out, f), nil
+
+	case String:
+		return appendString(out, tok.str)
+
+	case ArrayOpen:
+		out = append(out, '[')
+		for i := 0; ; i++ {
+			next, err := d.Peek()
+			if err != nil {
+				return nil, err
+			}
+			if next.kind == ArrayClose {
+				d.Read()
+				return append(out, ']'), nil
+			}
+			if i > 0 {
+				out = append(out, ',')
+			}
+			if out, err = appendCanonical(out, d); err != nil {
+				return nil, err
+			}
+		}
+
+	case ObjectOpen:
+		type member struct {
+			name  []uint16
+			value []byte
+		}
+		var members []member
+		for {
+			tok, err := d.Read()
+			if err != nil {
+				return nil, err
+			}
+			if tok.kind == ObjectClose {
+				break
+			}
+			if tok.kind != Name {
+				return nil, d.newSyntaxError(tok.pos, unexpectedFmt, tok.RawString())
+			}
+			m := member{name: utf16.Encode([]rune(tok.str))}
+			if m.value, err = appendString(nil, tok.str); err != nil {
+				return nil, err
+			}
+			m.value = append(m.value, ':')
+			if m.value, err = appendCanonical(m.value, d); err != nil {
+				return nil, err
+			}
+			members = append(members, m)
+		}
+		sort.SliceStable(members, func(i, j int) bool {
+			x, y := members[i].name, members[j].name
+			for k := 0; k < len(x) && k < len(y); k++ {
+				if x[k] != y[k] {
+					return x[k] < y[k]
+				}
+			}
+			return len(x) < len(y)
+		})
+		out = append(out, '{')
+		for i, m := range members {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			out = append(out, m.value...)
+		}
+		return append(out, '}'), nil
+	}
+	return nil, d.newSyntaxError(tok.pos, unexpectedFmt, tok.RawString())
+}
+
+// appendES6Number appends the given finite number formatted according to the
+// Number.prototype.toString algorithm of ECMAScript (ECMA-262, 7.1.12.1).
+func appendES6Number(out []byte, f float64) []byte {
+	if f == 0 {
+		return append(out, '0') // also for negative zero
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		panic("non-finite number")
+	}
+	if f < 0 {
+		out = append(out, '-')
+		f = -f
+	}
+
+	// Obtain the shortest digits and the decimal exponent, where
+	// f = 0.digits × 10^n.
+	b := strconv.AppendFloat(nil, f, 'e', -1, 64)
+	var digits []byte
+	var i int
+	for i = 0; b[i] != 'e'; i++ {
+		if b[i] != '.' {
+			digits = append(digits, b[i])
+		}
+	}
+	exp, _ := strconv.Atoi(string(b[i+1:]))
+	n, k := exp+1, len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		out = append(out, digits...)
+		for ; k < n; k++ {
+			out = append(out, '0')
+		}
+	case 0 < n && n <= 21:
+		out = append(out, digits[:n]...)
+		out = append(out, '.')
+		out = append(out, digits[n:]...)
+	case -6 < n && n <= 0:
+		out = append(out, '0', '.')
+		for ; n < 0; n++ {
+			out = append(out, '0')
+		}
+		out = append(out, digits...)
+	default:
+		out = append(out, digits[0])
+		if k > 1 {
+			out = append(out, '.')
+			out = append(out, digits[1:]...)
+		}
+		out = append(out, 'e')
+		if n-1 >= 0 {
+			out = append(out, '+')
+		}
+		out = strconv.AppendInt(out, int64(n-1), 10)
+	}
+	return out
+}
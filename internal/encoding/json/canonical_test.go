@@ -0,0 +1,64 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package json_test
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/internal/encoding/json"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{{
+		in:   ` { "b" : [ 1 , true , null ] , "a" : { } } `,
+		want: `{"a":{},"b":[1,true,null]}`,
+	}, {
+		// Example from RFC 8785, section 3.2.2.
+		in: `{
+  "numbers": [333333333.33333329, 1E30, 4.50,
+              2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`,
+		want: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+	}, {
+		// Sorting example from RFC 8785, section 3.2.3.
+		in:   `{"€": "Euro Sign", "\r": "Carriage Return", "דּ": "Hebrew Letter Dalet With Dagesh", "1": "One", "😀": "Emoji: Grinning Face", "\u0080": "Control", "ö": "Latin Small Letter O With Diaeresis"}`,
+		want: `{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis","€":"Euro Sign","😀":"Emoji: Grinning Face","` + "\ufb33" + `":"Hebrew Letter Dalet With Dagesh"}`,
+	}, {
+		in:   `[0, -0, 1e21, 1e20, 123e-9, 1.5e-7, -5e-324, 1.7976931348623157e308, 9007199254740993]`,
+		want: `[0,0,1e+21,100000000000000000000,1.23e-7,1.5e-7,-5e-324,1.7976931348623157e+308,9007199254740992]`,
+	}, {
+		in:   `"str"`,
+		want: `"str"`,
+	}, {
+		in:      `{"a":1} 1`,
+		wantErr: `unexpected token 1`,
+	}, {
+		in:      `[1e400]`,
+		wantErr: `invalid number 1e400`,
+	}}
+
+	for _, tt := range tests {
+		got, err := json.Canonicalize([]byte(tt.in))
+		if err != nil {
+			if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Canonicalize(%s) error got %v, want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if tt.wantErr != "" {
+			t.Errorf("Canonicalize(%s) got nil error, want %q", tt.in, tt.wantErr)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Canonicalize(%s)\ngot  %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
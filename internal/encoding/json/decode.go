@@ -48,6 +48,10 @@ type Decoder struct {
 	orig []byte
 	// in contains the unconsumed input.
 	in []byte
+
+	// relaxed specifies whether to accept the syntax extensions described
+	// in NewRelaxedDecoder.
+	relaxed bool
 }
 
 // NewDecoder returns a Decoder to read the given []byte.
@@ -55,6 +59,22 @@ func NewDecoder(b []byte) *Decoder {
 	return &Decoder{orig: b, in: b}
 }
 
+// NewRelaxedDecoder returns a Decoder to read the given []byte that accepts
+// the following extensions to the JSON syntax:
+//
+// • Line comments (//) and block comments (/* */) wherever whitespace is
+// allowed.
+//
+// • A trailing comma after the last member of an object or array.
+//
+// • Strings enclosed in single quotes, in which \' is a valid escape.
+//
+// • Unquoted object member names consisting of ASCII letters, digits, '_' and
+// '$', not starting with a digit.
+func NewRelaxedDecoder(b []byte) *Decoder {
+	return &Decoder{orig: b, in: b, relaxed: true}
+}
+
 // Peek looks ahead and returns the next token kind without advancing a read.
 func (d *Decoder) Peek() (Token, error) {
 	defer func() { d.lastCall = peekCall }()
@@ -124,7 +144,7 @@ func (d *Decoder) Read() (Token, error) {
 
 	case ObjectClose:
 		if len(d.openStack) == 0 ||
-			d.lastToken.kind == comma ||
+			(d.lastToken.kind == comma && !d.relaxed) ||
 			d.openStack[len(d.openStack)-1] != ObjectOpen {
 			return Token{}, d.newSyntaxError(tok.pos, unexpectedFmt, tok.RawString())
 		}
@@ -132,7 +152,7 @@ func (d *Decoder) Read() (Token, error) {
 
 	case ArrayClose:
 		if len(d.openStack) == 0 ||
-			d.lastToken.kind == comma ||
+			(d.lastToken.kind == comma && !d.relaxed) ||
 			d.openStack[len(d.openStack)-1] != ArrayOpen {
 			return Token{}, d.newSyntaxError(tok.pos, unexpectedFmt, tok.RawString())
 		}
@@ -154,6 +174,38 @@ func (d *Decoder) Read() (Token, error) {
 	return tok, nil
 }
 
+// ReadValue reads the next JSON value, which can be a scalar, an object or an
+// array, and returns the raw bytes of it from the original input.
+func (d *Decoder) ReadValue() ([]byte, error) {
+	tok, err := d.Read()
+	if err != nil {
+		return nil, err
+	}
+	start, end := tok.pos, tok.pos+len(tok.raw)
+	switch tok.kind {
+	case Null, Bool, Number, String:
+	case ObjectOpen, ArrayOpen:
+		for depth := 1; depth > 0; {
+			tok, err := d.Read()
+			if err != nil {
+				return nil, err
+			}
+			switch tok.kind {
+			case ObjectOpen, ArrayOpen:
+				depth++
+			case ObjectClose, ArrayClose:
+				depth--
+			}
+			end = tok.pos + len(tok.raw)
+		}
+	case EOF:
+		return nil, ErrUnexpectedEOF
+	default:
+		return nil, d.newSyntaxError(tok.pos, unexpectedFmt, tok.RawString())
+	}
+	return d.orig[start:end], nil
+}
+
 // Any sequence that looks like a non-delimiter (for error reporting).
 var errRegexp = regexp.MustCompile(`^([-+._a-zA-Z0-9]{1,32}|.)`)
 
@@ -169,6 +221,19 @@ func (d *Decoder) parseNext() (Token, error) {
 		return d.consumeToken(EOF, 0), nil
 	}
 
+	if d.relaxed {
+		if n := d.matchUnquotedName(in); n > 0 {
+			return d.consumeStringToken(string(in[:n]), n), nil
+		}
+		if in[0] == '\'' {
+			s, n, err := d.parseString(in)
+			if err != nil {
+				return Token{}, err
+			}
+			return d.consumeStringToken(s, n), nil
+		}
+	}
+
 	switch in[0] {
 	case 'n':
 		if n := matchWithDelim("null", in); n != 0 {
@@ -215,13 +280,36 @@ func (d *Decoder) parseNext() (Token, error) {
 	return Token{}, d.newSyntaxError(d.currPos(), "invalid value %s", errRegexp.Find(in))
 }
 
+// SyntaxError is the error returned for invalid JSON input. It carries the
+// position of the offending input.
+type SyntaxError struct {
+	// Pos is the index of the offending input.
+	Pos int
+	// Line and Column are the 1-based line and column numbers of Pos.
+	Line, Column int
+
+	err error
+}
+
+func (e *SyntaxError) Error() string {
+	return e.err.Error()
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.err
+}
+
 // newSyntaxError returns an error with line and column information useful for
 // syntax errors.
 func (d *Decoder) newSyntaxError(pos int, f string, x ...interface{}) error {
 	e := errors.New(f, x...)
 	line, column := d.Position(pos)
-	// panic("")
-	return errors.New("syntax error (line %d:%d): %v", line, column, e)
+	return &SyntaxError{
+		Pos:    pos,
+		Line:   line,
+		Column: column,
+		err:    errors.New("syntax error (line %d:%d): %v", line, column, e),
+	}
 }
 
 // Position returns line and column number of given index of the original input.
@@ -236,6 +324,11 @@ func (d *Decoder) Position(idx int) (line int, column int) {
 	return line, column
 }
 
+// Offset returns the index of the unconsumed input in the original input.
+func (d *Decoder) Offset() int {
+	return d.currPos()
+}
+
 // currPos returns the current index position of d.in from d.orig.
 func (d *Decoder) currPos() int {
 	return len(d.orig) - len(d.in)
@@ -265,19 +358,62 @@ func isNotDelim(c byte) bool {
 		('0' <= c && c <= '9'))
 }
 
-// consume consumes n bytes of input and any subsequent whitespace.
+// consume consumes n bytes of input and any subsequent whitespace, which
+// includes comments if the Decoder is relaxed.
 func (d *Decoder) consume(n int) {
 	d.in = d.in[n:]
 	for len(d.in) > 0 {
 		switch d.in[0] {
 		case ' ', '\n', '\r', '\t':
 			d.in = d.in[1:]
+		case '/':
+			if !d.relaxed || len(d.in) < 2 {
+				return
+			}
+			switch d.in[1] {
+			case '/':
+				if i := bytes.IndexByte(d.in, '\n'); i >= 0 {
+					d.in = d.in[i+1:]
+				} else {
+					d.in = d.in[len(d.in):]
+				}
+			case '*':
+				i := bytes.Index(d.in[2:], []byte("*/"))
+				if i < 0 {
+					// Leave the unterminated comment to be reported as an
+					// invalid value.
+					return
+				}
+				d.in = d.in[2+i+2:]
+			default:
+				return
+			}
 		default:
 			return
 		}
 	}
 }
 
+// matchUnquotedName returns the length of the unquoted object member name at
+// the start of b, or 0 if there is none or a member name is not expected.
+func (d *Decoder) matchUnquotedName(b []byte) int {
+	if len(d.openStack) == 0 || d.openStack[len(d.openStack)-1] != ObjectOpen ||
+		d.lastToken.kind&(ObjectOpen|comma) == 0 {
+		return 0
+	}
+	isIdent := func(c byte, first bool) bool {
+		return c == '_' || c == '$' ||
+			('a' <= c && c <= 'z') ||
+			('A' <= c && c <= 'Z') ||
+			(!first && '0' <= c && c <= '9')
+	}
+	n := 0
+	for n < len(b) && isIdent(b[n], n == 0) {
+		n++
+	}
+	return n
+}
+
 // isValueNext returns true if next type should be a JSON value: Null,
 // Number, String or Bool.
 func (d *Decoder) isValueNext() bool {
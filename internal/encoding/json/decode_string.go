@@ -5,6 +5,7 @@
 package json
 
 import (
+	"bytes"
 	"strconv"
 	"unicode"
 	"unicode/utf16"
@@ -13,16 +14,19 @@ import (
 	"google.golang.org/protobuf/internal/strs"
 )
 
+// parseString parses a string enclosed in double quotes, or single quotes if
+// the Decoder is relaxed.
 func (d *Decoder) parseString(in []byte) (string, int, error) {
 	in0 := in
 	if len(in) == 0 {
 		return "", 0, ErrUnexpectedEOF
 	}
-	if in[0] != '"' {
+	quote := in[0]
+	if quote != '"' && !(quote == '\'' && d.relaxed) {
 		return "", 0, d.newSyntaxError(d.currPos(), "invalid character %q at start of string", in[0])
 	}
 	in = in[1:]
-	i := indexNeedEscapeInBytes(in)
+	i := indexNeedEscapeInQuotedBytes(in, quote)
 	in, out := in[i:], in[:i:i] // set cap to prevent mutations
 	for len(in) > 0 {
 		switch r, n := utf8.DecodeRune(in); {
@@ -30,10 +34,13 @@ func (d *Decoder) parseString(in []byte) (string, int, error) {
 			return "", 0, d.newSyntaxError(d.currPos(), "invalid UTF-8 in string")
 		case r < ' ':
 			return "", 0, d.newSyntaxError(d.currPos(), "invalid character %q in string", r)
-		case r == '"':
+		case r == rune(quote):
 			in = in[1:]
 			n := len(in0) - len(in)
 			return string(out), n, nil
+		case r == '"':
+			// Only reachable for single-quoted strings.
+			in, out = in[1:], append(out, '"')
 		case r == '\\':
 			if len(in) < 2 {
 				return "", 0, ErrUnexpectedEOF
@@ -41,6 +48,11 @@ func (d *Decoder) parseString(in []byte) (string, int, error) {
 			switch r := in[1]; r {
 			case '"', '\\', '/':
 				in, out = in[2:], append(out, r)
+			case '\'':
+				if !d.relaxed {
+					return "", 0, d.newSyntaxError(d.currPos(), "invalid escape code %q in string", in[:2])
+				}
+				in, out = in[2:], append(out, r)
 			case 'b':
 				in, out = in[2:], append(out, '\b')
 			case 'f':
@@ -79,7 +91,7 @@ func (d *Decoder) parseString(in []byte) (string, int, error) {
 				return "", 0, d.newSyntaxError(d.currPos(), "invalid escape code %q in string", in[:2])
 			}
 		default:
-			i := indexNeedEscapeInBytes(in[n:])
+			i := indexNeedEscapeInQuotedBytes(in[n:], quote)
 			in, out = in[n+i:], append(out, in[:n+i]...)
 		}
 	}
@@ -89,3 +101,15 @@ func (d *Decoder) parseString(in []byte) (string, int, error) {
 // indexNeedEscapeInBytes returns the index of the character that needs
 // escaping. If no characters need escaping, this returns the input length.
 func indexNeedEscapeInBytes(b []byte) int { return indexNeedEscapeInString(strs.UnsafeString(b)) }
+
+// indexNeedEscapeInQuotedBytes is like indexNeedEscapeInBytes, but also stops
+// at the given quote character.
+func indexNeedEscapeInQuotedBytes(b []byte, quote byte) int {
+	i := indexNeedEscapeInBytes(b)
+	if quote != '"' {
+		if j := bytes.IndexByte(b[:i], quote); j >= 0 {
+			return j
+		}
+	}
+	return i
+}
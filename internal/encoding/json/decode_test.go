@@ -1343,6 +1343,121 @@ func TestDecoder(t *testing.T) {
 	}
 }
 
+func TestRelaxedDecoder(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []R
+	}{
+		{
+			in: "// comment\n{ /* block\n comment */ \"a\" /**/: 1, // trailing\n}",
+			want: []R{
+				{V: ObjectOpen},
+				{V: Name{"a"}},
+				{V: I64{1}},
+				{V: ObjectClose},
+				{V: EOF},
+			},
+		},
+		{
+			in: `[1, 'two', "th'ree", 'fo"ur', 'fi\'ve',]`,
+			want: []R{
+				{V: ArrayOpen},
+				{V: I64{1}},
+				{V: Str{"two"}},
+				{V: Str{"th'ree"}},
+				{V: Str{`fo"ur`}},
+				{V: Str{"fi've"}},
+				{V: ArrayClose},
+				{V: EOF},
+			},
+		},
+		{
+			in: `{unquoted: true, $dollar_1 : null, 'single': {},}`,
+			want: []R{
+				{V: ObjectOpen},
+				{V: Name{"unquoted"}},
+				{V: Bool{true}},
+				{V: Name{"$dollar_1"}},
+				{V: Null},
+				{V: Name{"single"}},
+				{V: ObjectOpen},
+				{V: ObjectClose},
+				{V: ObjectClose},
+				{V: EOF},
+			},
+		},
+		{
+			// Unquoted strings are only valid as member names.
+			in: `{a: b}`,
+			want: []R{
+				{V: ObjectOpen},
+				{V: Name{"a"}},
+				{E: `syntax error (line 1:5): invalid value b`},
+			},
+		},
+		{
+			in: `[1,,]`,
+			want: []R{
+				{V: ArrayOpen},
+				{V: I64{1}},
+				{E: `syntax error (line 1:4): unexpected token ,`},
+			},
+		},
+		{
+			in: `[1 /* unterminated ]`,
+			want: []R{
+				{V: ArrayOpen},
+				{V: I64{1}},
+				{E: `syntax error (line 1:4): invalid value /`},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run("", func(t *testing.T) {
+			dec := json.NewRelaxedDecoder([]byte(tc.in))
+			for i, want := range tc.want {
+				tok, err := dec.Read()
+				if err != nil {
+					if want.E == "" {
+						errorf(t, tc.in, "want#%d: Read() got unexpected error: %v", i, err)
+					} else if !strings.Contains(err.Error(), want.E) {
+						errorf(t, tc.in, "want#%d: Read() got %q, want %q", i, err, want.E)
+					}
+					return
+				}
+				if want.E != "" {
+					errorf(t, tc.in, "want#%d: Read() got nil error, want %q", i, want.E)
+					return
+				}
+				checkToken(t, tok, i, want, tc.in)
+			}
+		})
+	}
+
+	// The strict decoder rejects all of the extensions.
+	for _, in := range []string{
+		`// comment` + "\n" + `{}`,
+		`[1,]`,
+		`{"a":1,}`,
+		`['a']`,
+		`{a: 1}`,
+	} {
+		dec := json.NewDecoder([]byte(in))
+		var err error
+		for err == nil {
+			var tok json.Token
+			if tok, err = dec.Read(); tok.Kind() == json.EOF {
+				break
+			}
+		}
+		if err == nil {
+			errorf(t, in, "NewDecoder: Read() got nil error, want error")
+		}
+	}
+}
+
 func checkToken(t *testing.T, tok json.Token, idx int, r R, in string) {
 	// Validate Token.Pos() if R.P is set.
 	if r.P > 0 {
@@ -1412,3 +1527,36 @@ func compareDecoders(t *testing.T, d1 *json.Decoder, d2 *json.Decoder) {
 		}
 	}
 }
+
+func TestReadValue(t *testing.T) {
+	input := `{"a": [1, {"b": null}], "c" : "str", "d": {}, "e": 1.5e3 }`
+	dec := json.NewDecoder([]byte(input))
+
+	dec.Read() // Read ObjectOpen.
+	var got []string
+	for {
+		tok, err := dec.Read()
+		if err != nil {
+			t.Fatalf("Read() returned error: %v", err)
+		}
+		if tok.Kind() == json.ObjectClose {
+			break
+		}
+		b, err := dec.ReadValue()
+		if err != nil {
+			t.Fatalf("ReadValue() returned error: %v", err)
+		}
+		got = append(got, string(b))
+	}
+	want := []string{`[1, {"b": null}]`, `"str"`, `{}`, `1.5e3`}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadValue() mismatch (-want +got):\n%s", diff)
+	}
+
+	// ReadValue should not accept a closing token.
+	dec = json.NewDecoder([]byte(`[]`))
+	dec.Read()
+	if _, err := dec.ReadValue(); err == nil {
+		t.Errorf("ReadValue() got nil error, want error")
+	}
+}
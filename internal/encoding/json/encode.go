@@ -142,6 +142,19 @@ func (e *Encoder) WriteFloat(n float64, bitSize int) {
 	e.out = appendFloat(e.out, n, bitSize)
 }
 
+// WriteFloatPrec writes out the given float and bitSize in JSON number value,
+// formatted according to strconv.FormatFloat with format 'f' or 'g' and the
+// given precision.
+func (e *Encoder) WriteFloatPrec(n float64, fmt byte, prec, bitSize int) {
+	e.prepareNext(scalar)
+	switch {
+	case math.IsNaN(n), math.IsInf(n, 0):
+		e.out = appendFloat(e.out, n, bitSize)
+	default:
+		e.out = strconv.AppendFloat(e.out, n, fmt, prec, bitSize)
+	}
+}
+
 // appendFloat formats given float in bitSize, and appends to the given []byte.
 func appendFloat(out []byte, n float64, bitSize int) []byte {
 	switch {
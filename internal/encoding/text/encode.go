@@ -24,6 +24,7 @@ const (
 	scalar
 	messageOpen
 	messageClose
+	comment
 )
 
 // Encoder provides methods to write out textproto constructs and values. The user is
@@ -31,10 +32,34 @@ const (
 type Encoder struct {
 	encoderState
 
-	indent      string
-	newline     string // set to "\n" if len(indent) > 0
-	delims      [2]byte
-	outputASCII bool
+	indent  string
+	newline string // set to "\n" if len(indent) > 0
+	delims  [2]byte
+	opts    EncoderOptions
+}
+
+// EncoderOptions specifies how an Encoder writes scalar values.
+type EncoderOptions struct {
+	// OutputASCII specifies whether strings are serialized in such a way that
+	// multi-byte UTF-8 sequences are escaped. This property ensures that the
+	// overall output is ASCII (as opposed to UTF-8).
+	OutputASCII bool
+
+	// OctalEscapes specifies whether bytes are escaped with three-digit octal
+	// escape sequences (e.g., "\303") instead of hexadecimal ones (e.g.,
+	// "\xc3"), as C's escaping does. Single quotes and the DEL character are
+	// then escaped as well, and multi-byte UTF-8 sequences escaped due to
+	// OutputASCII are escaped byte by byte instead of as "\u" sequences.
+	OctalEscapes bool
+
+	// FloatPrecision and DoublePrecision specify the number of significant
+	// digits to write 32-bit and 64-bit floating-point values with, in the
+	// manner of C's "%g" format. A value that does not round trip at that
+	// precision is written with 9 or 17 digits respectively, which always
+	// round trip. If zero, values are written with the fewest digits that
+	// round trip.
+	FloatPrecision  int
+	DoublePrecision int
 }
 
 type encoderState struct {
@@ -51,10 +76,8 @@ type encoderState struct {
 // If delims is not the zero value, it controls the delimiter characters used
 // for messages (e.g., "{}" vs "<>").
 //
-// If outputASCII is true, strings will be serialized in such a way that
-// multi-byte UTF-8 sequences are escaped. This property ensures that the
-// overall output is ASCII (as opposed to UTF-8).
-func NewEncoder(indent string, delims [2]byte, outputASCII bool) (*Encoder, error) {
+// The opts control the serialization of strings and floating-point values.
+func NewEncoder(indent string, delims [2]byte, opts EncoderOptions) (*Encoder, error) {
 	e := &Encoder{}
 	if len(indent) > 0 {
 		if strings.Trim(indent, " \t") != "" {
@@ -71,7 +94,7 @@ func NewEncoder(indent string, delims [2]byte, outputASCII bool) (*Encoder, erro
 	default:
 		return nil, errors.New("delimiters may only be \"{}\" or \"<>\"")
 	}
-	e.outputASCII = outputASCII
+	e.opts = opts
 
 	return e, nil
 }
@@ -112,10 +135,18 @@ func (e *Encoder) WriteBool(b bool) {
 // WriteString writes out the given string value.
 func (e *Encoder) WriteString(s string) {
 	e.prepareNext(scalar)
-	e.out = appendString(e.out, s, e.outputASCII)
+	e.out = appendString(e.out, s, e.opts.OutputASCII, e.opts.OctalEscapes)
+}
+
+// WriteBytes writes out the given bytes value. With OctalEscapes, it is
+// written as binary data with all non-ASCII bytes escaped. Otherwise, it is
+// written like a string value.
+func (e *Encoder) WriteBytes(b []byte) {
+	e.prepareNext(scalar)
+	e.out = appendString(e.out, string(b), e.opts.OutputASCII || e.opts.OctalEscapes, e.opts.OctalEscapes)
 }
 
-func appendString(out []byte, in string, outputASCII bool) []byte {
+func appendString(out []byte, in string, outputASCII, octal bool) []byte {
 	out = append(out, '"')
 	i := indexNeedEscapeInString(in)
 	in, out = in[i:], append(out, in[:i]...)
@@ -126,10 +157,10 @@ func appendString(out []byte, in string, outputASCII bool) []byte {
 			// are used to represent both the proto string and bytes type.
 			r = rune(in[0])
 			fallthrough
-		case r < ' ' || r == '"' || r == '\\':
+		case r < ' ' || r == '"' || r == '\\' || (octal && (r == '\'' || r == 0x7f)):
 			out = append(out, '\\')
 			switch r {
-			case '"', '\\':
+			case '"', '\'', '\\':
 				out = append(out, byte(r))
 			case '\n':
 				out = append(out, 'n')
@@ -138,11 +169,21 @@ func appendString(out []byte, in string, outputASCII bool) []byte {
 			case '\t':
 				out = append(out, 't')
 			default:
+				if octal {
+					out = appendOctal(out, byte(r))
+					break
+				}
 				out = append(out, 'x')
 				out = append(out, "00"[1+(bits.Len32(uint32(r))-1)/4:]...)
 				out = strconv.AppendUint(out, uint64(r), 16)
 			}
 			in = in[n:]
+		case outputASCII && octal && r >= utf8.RuneSelf:
+			for _, c := range []byte(in[:n]) {
+				out = append(out, '\\')
+				out = appendOctal(out, c)
+			}
+			in = in[n:]
 		case outputASCII && r >= utf8.RuneSelf:
 			out = append(out, '\\')
 			if r <= math.MaxUint16 {
@@ -164,11 +205,16 @@ func appendString(out []byte, in string, outputASCII bool) []byte {
 	return out
 }
 
+// appendOctal appends the three octal digits of c.
+func appendOctal(out []byte, c byte) []byte {
+	return append(out, '0'+c>>6, '0'+(c>>3)&7, '0'+c&7)
+}
+
 // indexNeedEscapeInString returns the index of the character that needs
 // escaping. If no characters need escaping, this returns the input length.
 func indexNeedEscapeInString(s string) int {
 	for i := 0; i < len(s); i++ {
-		if c := s[i]; c < ' ' || c == '"' || c == '\'' || c == '\\' || c >= utf8.RuneSelf {
+		if c := s[i]; c < ' ' || c == '"' || c == '\'' || c == '\\' || c == 0x7f || c >= utf8.RuneSelf {
 			return i
 		}
 	}
@@ -178,10 +224,14 @@ func indexNeedEscapeInString(s string) int {
 // WriteFloat writes out the given float value for given bitSize.
 func (e *Encoder) WriteFloat(n float64, bitSize int) {
 	e.prepareNext(scalar)
-	e.out = appendFloat(e.out, n, bitSize)
+	prec := e.opts.DoublePrecision
+	if bitSize == 32 {
+		prec = e.opts.FloatPrecision
+	}
+	e.out = appendFloat(e.out, n, bitSize, prec)
 }
 
-func appendFloat(out []byte, n float64, bitSize int) []byte {
+func appendFloat(out []byte, n float64, bitSize, prec int) []byte {
 	switch {
 	case math.IsNaN(n):
 		return append(out, "nan"...)
@@ -189,6 +239,16 @@ func appendFloat(out []byte, n float64, bitSize int) []byte {
 		return append(out, "inf"...)
 	case math.IsInf(n, -1):
 		return append(out, "-inf"...)
+	case prec > 0:
+		b := strconv.AppendFloat(out, n, 'g', prec, bitSize)
+		if v, err := strconv.ParseFloat(string(b[len(out):]), bitSize); err == nil && v == n {
+			return b
+		}
+		// Fall back to the precision that round trips any value.
+		if bitSize == 32 {
+			return strconv.AppendFloat(out, n, 'g', 9, bitSize)
+		}
+		return strconv.AppendFloat(out, n, 'g', 17, bitSize)
 	default:
 		return strconv.AppendFloat(out, n, 'g', -1, bitSize)
 	}
@@ -213,6 +273,20 @@ func (e *Encoder) WriteLiteral(s string) {
 	e.out = append(e.out, s...)
 }
 
+// WriteComment writes out the given text as comment lines, each starting with
+// '#', before the next field name. Comments are only written in multi-line
+// output, where they are indented like the fields that follow them.
+func (e *Encoder) WriteComment(s string) {
+	if len(e.indent) == 0 {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		e.prepareNext(comment)
+		e.out = append(e.out, '#')
+		e.out = append(e.out, strings.TrimRight(line, " \t")...)
+	}
+}
+
 // prepareNext adds possible space and indentation for the next value based
 // on last encType and indent option. It also updates e.lastType to next.
 func (e *Encoder) prepareNext(next encType) {
@@ -242,6 +316,10 @@ func (e *Encoder) prepareNext(next encType) {
 			e.out = append(e.out, ' ')
 		}
 
+	case e.lastType == comment:
+		e.out = append(e.out, '\n')
+		e.out = append(e.out, e.indents...)
+
 	case e.lastType == messageOpen && next != messageClose:
 		e.indents = append(e.indents, e.indent...)
 		e.out = append(e.out, '\n')
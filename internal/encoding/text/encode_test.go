@@ -315,6 +315,31 @@ m2: {
 }
 101: "unknown"`,
 		},
+		{
+			desc: "comments",
+			write: func(e *text.Encoder) {
+				e.WriteComment(" top\n")
+				e.WriteName("m1")
+				e.StartMessage()
+				e.WriteComment(" first\n\n second ")
+				e.WriteName("str")
+				e.WriteString("hello")
+				e.WriteComment(" last")
+				e.WriteName("bool")
+				e.WriteBool(true)
+				e.EndMessage()
+			},
+			wantOut: `m1:{str:"hello" bool:true}`,
+			wantOutIndent: `# top
+m1: {
+	# first
+	#
+	# second
+	str: "hello"
+	# last
+	bool: true
+}`,
+		},
 	}
 
 	for _, tc := range tests {
@@ -341,7 +366,7 @@ func runEncoderTest(t *testing.T, tc encoderTestCase, delims [2]byte) {
 	t.Helper()
 
 	if tc.wantOut != "" {
-		enc, err := text.NewEncoder("", delims, false)
+		enc, err := text.NewEncoder("", delims, text.EncoderOptions{})
 		if err != nil {
 			t.Fatalf("NewEncoder returned error: %v", err)
 		}
@@ -352,7 +377,7 @@ func runEncoderTest(t *testing.T, tc encoderTestCase, delims [2]byte) {
 		}
 	}
 	if tc.wantOutIndent != "" {
-		enc, err := text.NewEncoder("\t", delims, false)
+		enc, err := text.NewEncoder("\t", delims, text.EncoderOptions{})
 		if err != nil {
 			t.Fatalf("NewEncoder returned error: %v", err)
 		}
@@ -513,7 +538,7 @@ func runEncodeStringsTest(t *testing.T, in string, want string, outputASCII bool
 		charType = "ASCII"
 	}
 
-	enc, err := text.NewEncoder("", [2]byte{}, outputASCII)
+	enc, err := text.NewEncoder("", [2]byte{}, text.EncoderOptions{OutputASCII: outputASCII})
 	if err != nil {
 		t.Fatalf("[%s] NewEncoder returned error: %v", charType, err)
 	}
@@ -525,7 +550,7 @@ func runEncodeStringsTest(t *testing.T, in string, want string, outputASCII bool
 }
 
 func TestReset(t *testing.T) {
-	enc, err := text.NewEncoder("\t", [2]byte{}, false)
+	enc, err := text.NewEncoder("\t", [2]byte{}, text.EncoderOptions{})
 	if err != nil {
 		t.Fatalf("NewEncoder returned error: %v", err)
 	}
@@ -548,3 +573,78 @@ func TestReset(t *testing.T) {
 		t.Errorf("Reset did not restore given position:\n<got>\n%v\n<want>\n%v\n", got, want)
 	}
 }
+
+func TestEncoderOptions(t *testing.T) {
+	tests := []struct {
+		desc  string
+		opts  text.EncoderOptions
+		write func(*text.Encoder)
+		want  string
+	}{{
+		desc:  "octal escapes",
+		opts:  text.EncoderOptions{OctalEscapes: true},
+		write: func(e *text.Encoder) { e.WriteString("é'\x7f\x01\xff\n") },
+		want:  `"` + "é" + `\'\177\001\377\n"`,
+	}, {
+		desc:  "octal escapes with ASCII output",
+		opts:  text.EncoderOptions{OutputASCII: true, OctalEscapes: true},
+		write: func(e *text.Encoder) { e.WriteString("é'\x7f\x01\xff\n") },
+		want:  `"\303\251\'\177\001\377\n"`,
+	}, {
+		desc:  "bytes",
+		write: func(e *text.Encoder) { e.WriteBytes([]byte("é\xff")) },
+		want:  `"` + "é" + `\xff"`,
+	}, {
+		desc:  "bytes with ASCII output",
+		opts:  text.EncoderOptions{OutputASCII: true},
+		write: func(e *text.Encoder) { e.WriteBytes([]byte("é\xff")) },
+		want:  `"\u00e9\xff"`,
+	}, {
+		desc:  "bytes with octal escapes",
+		opts:  text.EncoderOptions{OctalEscapes: true},
+		write: func(e *text.Encoder) { e.WriteBytes([]byte("é\xff")) },
+		want:  `"\303\251\377"`,
+	}, {
+		desc:  "shortest double",
+		write: func(e *text.Encoder) { e.WriteFloat(1e6, 64) },
+		want:  `1e+06`,
+	}, {
+		desc:  "double precision",
+		opts:  text.EncoderOptions{DoublePrecision: 15},
+		write: func(e *text.Encoder) { e.WriteFloat(1e6, 64) },
+		want:  `1000000`,
+	}, {
+		desc:  "double precision exceeded",
+		opts:  text.EncoderOptions{DoublePrecision: 15},
+		write: func(e *text.Encoder) { e.WriteFloat(0.7999999999999999, 64) },
+		want:  `0.79999999999999993`,
+	}, {
+		desc:  "float precision",
+		opts:  text.EncoderOptions{FloatPrecision: 6, DoublePrecision: 15},
+		write: func(e *text.Encoder) { e.WriteFloat(float64(float32(0.1)), 32) },
+		want:  `0.1`,
+	}, {
+		desc:  "float precision exceeded",
+		opts:  text.EncoderOptions{FloatPrecision: 6},
+		write: func(e *text.Encoder) { e.WriteFloat(float64(float32(1.2345678)), 32) },
+		want:  `1.23456776`,
+	}, {
+		desc:  "float precision with special values",
+		opts:  text.EncoderOptions{FloatPrecision: 6},
+		write: func(e *text.Encoder) { e.WriteFloat(math.Inf(-1), 32) },
+		want:  `-inf`,
+	}}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			enc, err := text.NewEncoder("", [2]byte{}, tc.opts)
+			if err != nil {
+				t.Fatalf("NewEncoder returned error: %v", err)
+			}
+			tc.write(enc)
+			if got := string(enc.Bytes()); got != tc.want {
+				t.Errorf("<got>\n%v\n<want>\n%v\n", got, tc.want)
+			}
+		})
+	}
+}
@@ -431,6 +431,8 @@ func (fd *Field) unmarshalFull(b []byte, sb *strs.Builder, pf *File, pd pref.Des
 				fd.L1.Cardinality = pref.Cardinality(v)
 			case fieldnum.FieldDescriptorProto_Type:
 				fd.L1.Kind = pref.Kind(v)
+			case fieldnum.FieldDescriptorProto_Proto3Optional:
+				fd.L1.IsProto3Optional = wire.DecodeBool(v)
 			case fieldnum.FieldDescriptorProto_OneofIndex:
 				// In Message.unmarshalFull, we allocate slices for both
 				// the field and oneof descriptors before unmarshaling either
@@ -537,6 +539,13 @@ func (xd *Extension) unmarshalFull(b []byte, sb *strs.Builder) {
 		num, typ, n := wire.ConsumeTag(b)
 		b = b[n:]
 		switch typ {
+		case wire.VarintType:
+			v, m := wire.ConsumeVarint(b)
+			b = b[m:]
+			switch num {
+			case fieldnum.FieldDescriptorProto_Proto3Optional:
+				xd.L2.IsProto3Optional = wire.DecodeBool(v)
+			}
 		case wire.BytesType:
 			v, m := wire.ConsumeBytes(b)
 			b = b[m:]
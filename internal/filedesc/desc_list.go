@@ -13,6 +13,7 @@ import (
 	"google.golang.org/protobuf/internal/descfmt"
 	"google.golang.org/protobuf/internal/encoding/wire"
 	"google.golang.org/protobuf/internal/errors"
+	"google.golang.org/protobuf/internal/fieldnum"
 	"google.golang.org/protobuf/internal/pragma"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	pref "google.golang.org/protobuf/reflect/protoreflect"
@@ -278,9 +279,119 @@ func (p *OneofFields) lazyInit() *OneofFields {
 }
 
 type SourceLocations struct {
+	// List is a list of SourceLocations.
 	List []pref.SourceLocation
+
+	// File is the parent file descriptor that these locations are relative to.
+	// If non-nil, ByDescriptor verifies that the provided descriptor
+	// is a child of this file descriptor.
+	File pref.FileDescriptor
+
+	once   sync.Once
+	byPath map[pathKey]int
 }
 
-func (p *SourceLocations) Len() int                            { return len(p.List) }
-func (p *SourceLocations) Get(i int) pref.SourceLocation       { return p.List[i] }
+func (p *SourceLocations) Len() int                      { return len(p.List) }
+func (p *SourceLocations) Get(i int) pref.SourceLocation { return p.List[i] }
+func (p *SourceLocations) ByPath(path pref.SourcePath) pref.SourceLocation {
+	if i, ok := p.lazyInit().byPath[newPathKey(path)]; ok {
+		return p.List[i]
+	}
+	return pref.SourceLocation{}
+}
+func (p *SourceLocations) ByDescriptor(desc pref.Descriptor) pref.SourceLocation {
+	if desc == nil || (p.File != nil && p.File != desc.ParentFile()) {
+		return pref.SourceLocation{} // mismatching parent files
+	}
+	path, ok := sourcePath(desc)
+	if !ok {
+		return pref.SourceLocation{}
+	}
+	return p.ByPath(path)
+}
 func (p *SourceLocations) ProtoInternal(pragma.DoNotImplement) {}
+func (p *SourceLocations) lazyInit() *SourceLocations {
+	p.once.Do(func() {
+		if len(p.List) > 0 {
+			p.byPath = make(map[pathKey]int, len(p.List))
+			for i := len(p.List) - 1; i >= 0; i-- {
+				// Iterate in reverse so that the first location wins.
+				p.byPath[newPathKey(p.List[i].Path)] = i
+			}
+		}
+	})
+	return p
+}
+
+// sourcePath returns the path from the root file descriptor to desc.
+// It reports false if desc is not a declaration within a file.
+func sourcePath(desc pref.Descriptor) (pref.SourcePath, bool) {
+	var path pref.SourcePath
+	for desc != nil {
+		if _, ok := desc.(pref.FileDescriptor); ok {
+			break
+		}
+		parent := desc.Parent()
+		if parent == nil {
+			return nil, false
+		}
+		var num int32
+		switch desc.(type) {
+		case pref.MessageDescriptor:
+			switch parent.(type) {
+			case pref.FileDescriptor:
+				num = fieldnum.FileDescriptorProto_MessageType
+			case pref.MessageDescriptor:
+				num = fieldnum.DescriptorProto_NestedType
+			}
+		case pref.FieldDescriptor:
+			switch fd := desc.(pref.FieldDescriptor); {
+			case !fd.IsExtension():
+				num = fieldnum.DescriptorProto_Field
+			default:
+				switch parent.(type) {
+				case pref.FileDescriptor:
+					num = fieldnum.FileDescriptorProto_Extension
+				case pref.MessageDescriptor:
+					num = fieldnum.DescriptorProto_Extension
+				}
+			}
+		case pref.OneofDescriptor:
+			num = fieldnum.DescriptorProto_OneofDecl
+		case pref.EnumDescriptor:
+			switch parent.(type) {
+			case pref.FileDescriptor:
+				num = fieldnum.FileDescriptorProto_EnumType
+			case pref.MessageDescriptor:
+				num = fieldnum.DescriptorProto_EnumType
+			}
+		case pref.EnumValueDescriptor:
+			num = fieldnum.EnumDescriptorProto_Value
+		case pref.ServiceDescriptor:
+			num = fieldnum.FileDescriptorProto_Service
+		case pref.MethodDescriptor:
+			num = fieldnum.ServiceDescriptorProto_Method
+		}
+		if num == 0 {
+			return nil, false
+		}
+		path = append(path, int32(desc.Index()), num)
+		desc = parent
+	}
+	// Reverse the path since it was constructed from the leaf.
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+// pathKey is a comparable representation of a SourcePath.
+type pathKey string
+
+func newPathKey(p pref.SourcePath) pathKey {
+	b := make([]byte, 0, 4*len(p))
+	for _, n := range p {
+		b = append(b, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return pathKey(b)
+}
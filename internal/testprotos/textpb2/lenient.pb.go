@@ -0,0 +1,341 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test Protobuf definitions for lenient matching of JSON names.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: internal/testprotos/textpb2/lenient.proto
+
+package textpb2
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+// Enum whose value names are prefixed with the enum name.
+type Color int32
+
+const (
+	Color_COLOR_UNSPECIFIED Color = 0
+	Color_COLOR_RED         Color = 1
+	Color_COLOR_DARK_BLUE   Color = 2
+)
+
+// Enum value maps for Color.
+var (
+	Color_name = map[int32]string{
+		0: "COLOR_UNSPECIFIED",
+		1: "COLOR_RED",
+		2: "COLOR_DARK_BLUE",
+	}
+	Color_value = map[string]int32{
+		"COLOR_UNSPECIFIED": 0,
+		"COLOR_RED":         1,
+		"COLOR_DARK_BLUE":   2,
+	}
+)
+
+func (x Color) Enum() *Color {
+	p := new(Color)
+	*p = x
+	return p
+}
+
+func (x Color) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Color) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_testprotos_textpb2_lenient_proto_enumTypes[0].Descriptor()
+}
+
+func (Color) Type() protoreflect.EnumType {
+	return &file_internal_testprotos_textpb2_lenient_proto_enumTypes[0]
+}
+
+func (x Color) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Color) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Color(num)
+	return nil
+}
+
+// Deprecated: Use Color.Descriptor instead.
+func (Color) EnumDescriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb2_lenient_proto_rawDescGZIP(), []int{0}
+}
+
+// Enum whose value names only differ in case.
+type Mode int32
+
+const (
+	Mode_ON Mode = 1
+	Mode_on Mode = 2
+)
+
+// Enum value maps for Mode.
+var (
+	Mode_name = map[int32]string{
+		1: "ON",
+		2: "on",
+	}
+	Mode_value = map[string]int32{
+		"ON": 1,
+		"on": 2,
+	}
+)
+
+func (x Mode) Enum() *Mode {
+	p := new(Mode)
+	*p = x
+	return p
+}
+
+func (x Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_internal_testprotos_textpb2_lenient_proto_enumTypes[1].Descriptor()
+}
+
+func (Mode) Type() protoreflect.EnumType {
+	return &file_internal_testprotos_textpb2_lenient_proto_enumTypes[1]
+}
+
+func (x Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Mode) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Mode(num)
+	return nil
+}
+
+// Deprecated: Use Mode.Descriptor instead.
+func (Mode) EnumDescriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb2_lenient_proto_rawDescGZIP(), []int{1}
+}
+
+// Message contains enum fields and fields whose names only differ in case
+// and underscores.
+type Paint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Color  *Color           `protobuf:"varint,1,opt,name=color,enum=pb2.Color" json:"color,omitempty"`
+	Colors []Color          `protobuf:"varint,2,rep,name=colors,enum=pb2.Color" json:"colors,omitempty"`
+	ByName map[string]Color `protobuf:"bytes,3,rep,name=by_name,json=byName" json:"by_name,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value,enum=pb2.Color"`
+	Mode   *Mode            `protobuf:"varint,4,opt,name=mode,enum=pb2.Mode" json:"mode,omitempty"`
+	FooBar *string          `protobuf:"bytes,5,opt,name=foo_bar,json=fooBar" json:"foo_bar,omitempty"`
+	Foobar *string          `protobuf:"bytes,6,opt,name=foobar" json:"foobar,omitempty"`
+	Size   *int32           `protobuf:"varint,7,opt,name=size" json:"size,omitempty"`
+}
+
+func (x *Paint) Reset() {
+	*x = Paint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_testprotos_textpb2_lenient_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Paint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Paint) ProtoMessage() {}
+
+func (x *Paint) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_testprotos_textpb2_lenient_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Paint.ProtoReflect.Descriptor instead.
+func (*Paint) Descriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb2_lenient_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Paint) GetColor() Color {
+	if x != nil && x.Color != nil {
+		return *x.Color
+	}
+	return Color_COLOR_UNSPECIFIED
+}
+
+func (x *Paint) GetColors() []Color {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+func (x *Paint) GetByName() map[string]Color {
+	if x != nil {
+		return x.ByName
+	}
+	return nil
+}
+
+func (x *Paint) GetMode() Mode {
+	if x != nil && x.Mode != nil {
+		return *x.Mode
+	}
+	return Mode_ON
+}
+
+func (x *Paint) GetFooBar() string {
+	if x != nil && x.FooBar != nil {
+		return *x.FooBar
+	}
+	return ""
+}
+
+func (x *Paint) GetFoobar() string {
+	if x != nil && x.Foobar != nil {
+		return *x.Foobar
+	}
+	return ""
+}
+
+func (x *Paint) GetSize() int32 {
+	if x != nil && x.Size != nil {
+		return *x.Size
+	}
+	return 0
+}
+
+var File_internal_testprotos_textpb2_lenient_proto protoreflect.FileDescriptor
+
+var file_internal_testprotos_textpb2_lenient_proto_rawDesc = []byte{
+	0x0a, 0x29, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x74, 0x65, 0x78, 0x74, 0x70, 0x62, 0x32, 0x2f, 0x6c, 0x65,
+	0x6e, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x70, 0x62, 0x32,
+	0x22, 0xa9, 0x02, 0x0a, 0x05, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x12, 0x20, 0x0a, 0x05, 0x63, 0x6f,
+	0x6c, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x70, 0x62, 0x32, 0x2e,
+	0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x22, 0x0a, 0x06,
+	0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x70,
+	0x62, 0x32, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73,
+	0x12, 0x2f, 0x0a, 0x07, 0x62, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x70, 0x62, 0x32, 0x2e, 0x50, 0x61, 0x69, 0x6e, 0x74, 0x2e, 0x42, 0x79,
+	0x4e, 0x61, 0x6d, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x62, 0x79, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x1d, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x09, 0x2e, 0x70, 0x62, 0x32, 0x2e, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x12, 0x17, 0x0a, 0x07, 0x66, 0x6f, 0x6f, 0x5f, 0x62, 0x61, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x66, 0x6f, 0x6f, 0x42, 0x61, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x6f,
+	0x62, 0x61, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x6f, 0x62, 0x61,
+	0x72, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x1a, 0x45, 0x0a, 0x0b, 0x42, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x20, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x70, 0x62, 0x32, 0x2e, 0x43, 0x6f, 0x6c, 0x6f,
+	0x72, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a, 0x42, 0x0a, 0x05,
+	0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x4f, 0x4c, 0x4f, 0x52, 0x5f, 0x55,
+	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09,
+	0x43, 0x4f, 0x4c, 0x4f, 0x52, 0x5f, 0x52, 0x45, 0x44, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x43,
+	0x4f, 0x4c, 0x4f, 0x52, 0x5f, 0x44, 0x41, 0x52, 0x4b, 0x5f, 0x42, 0x4c, 0x55, 0x45, 0x10, 0x02,
+	0x2a, 0x16, 0x0a, 0x04, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4e, 0x10, 0x01,
+	0x12, 0x06, 0x0a, 0x02, 0x6f, 0x6e, 0x10, 0x02, 0x42, 0x38, 0x5a, 0x36, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x67, 0x6f, 0x6c, 0x61, 0x6e, 0x67, 0x2e, 0x6f, 0x72, 0x67, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f,
+	0x74, 0x65, 0x73, 0x74, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x74, 0x65, 0x78, 0x74, 0x70,
+	0x62, 0x32, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32,
+}
+
+var (
+	file_internal_testprotos_textpb2_lenient_proto_rawDescOnce sync.Once
+	file_internal_testprotos_textpb2_lenient_proto_rawDescData = file_internal_testprotos_textpb2_lenient_proto_rawDesc
+)
+
+func file_internal_testprotos_textpb2_lenient_proto_rawDescGZIP() []byte {
+	file_internal_testprotos_textpb2_lenient_proto_rawDescOnce.Do(func() {
+		file_internal_testprotos_textpb2_lenient_proto_rawDescData = protoimpl.X.CompressGZIP(file_internal_testprotos_textpb2_lenient_proto_rawDescData)
+	})
+	return file_internal_testprotos_textpb2_lenient_proto_rawDescData
+}
+
+var file_internal_testprotos_textpb2_lenient_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_internal_testprotos_textpb2_lenient_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_internal_testprotos_textpb2_lenient_proto_goTypes = []interface{}{
+	(Color)(0),    // 0: pb2.Color
+	(Mode)(0),     // 1: pb2.Mode
+	(*Paint)(nil), // 2: pb2.Paint
+	nil,           // 3: pb2.Paint.ByNameEntry
+}
+var file_internal_testprotos_textpb2_lenient_proto_depIdxs = []int32{
+	0, // 0: pb2.Paint.color:type_name -> pb2.Color
+	0, // 1: pb2.Paint.colors:type_name -> pb2.Color
+	3, // 2: pb2.Paint.by_name:type_name -> pb2.Paint.ByNameEntry
+	1, // 3: pb2.Paint.mode:type_name -> pb2.Mode
+	0, // 4: pb2.Paint.ByNameEntry.value:type_name -> pb2.Color
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_internal_testprotos_textpb2_lenient_proto_init() }
+func file_internal_testprotos_textpb2_lenient_proto_init() {
+	if File_internal_testprotos_textpb2_lenient_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_internal_testprotos_textpb2_lenient_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Paint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_internal_testprotos_textpb2_lenient_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_testprotos_textpb2_lenient_proto_goTypes,
+		DependencyIndexes: file_internal_testprotos_textpb2_lenient_proto_depIdxs,
+		EnumInfos:         file_internal_testprotos_textpb2_lenient_proto_enumTypes,
+		MessageInfos:      file_internal_testprotos_textpb2_lenient_proto_msgTypes,
+	}.Build()
+	File_internal_testprotos_textpb2_lenient_proto = out.File
+	file_internal_testprotos_textpb2_lenient_proto_rawDesc = nil
+	file_internal_testprotos_textpb2_lenient_proto_goTypes = nil
+	file_internal_testprotos_textpb2_lenient_proto_depIdxs = nil
+}
@@ -0,0 +1,535 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Test Protobuf definitions for the JSON representations of oneofs.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: internal/testprotos/textpb3/oneof.proto
+
+package textpb3
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+// Message type used as a oneof field.
+type Circle struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Radius float64 `protobuf:"fixed64,1,opt,name=radius,proto3" json:"radius,omitempty"`
+}
+
+func (x *Circle) Reset() {
+	*x = Circle{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Circle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Circle) ProtoMessage() {}
+
+func (x *Circle) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Circle.ProtoReflect.Descriptor instead.
+func (*Circle) Descriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb3_oneof_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Circle) GetRadius() float64 {
+	if x != nil {
+		return x.Radius
+	}
+	return 0
+}
+
+// Message contains a oneof next to another field.
+type Shape struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Types that are assignable to ShapeKind:
+	//	*Shape_Circle
+	//	*Shape_Point
+	//	*Shape_Label
+	ShapeKind isShape_ShapeKind `protobuf_oneof:"shape_kind"`
+}
+
+func (x *Shape) Reset() {
+	*x = Shape{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Shape) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shape) ProtoMessage() {}
+
+func (x *Shape) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shape.ProtoReflect.Descriptor instead.
+func (*Shape) Descriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb3_oneof_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Shape) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (m *Shape) GetShapeKind() isShape_ShapeKind {
+	if m != nil {
+		return m.ShapeKind
+	}
+	return nil
+}
+
+func (x *Shape) GetCircle() *Circle {
+	if x, ok := x.GetShapeKind().(*Shape_Circle); ok {
+		return x.Circle
+	}
+	return nil
+}
+
+func (x *Shape) GetPoint() *emptypb.Empty {
+	if x, ok := x.GetShapeKind().(*Shape_Point); ok {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *Shape) GetLabel() string {
+	if x, ok := x.GetShapeKind().(*Shape_Label); ok {
+		return x.Label
+	}
+	return ""
+}
+
+type isShape_ShapeKind interface {
+	isShape_ShapeKind()
+}
+
+type Shape_Circle struct {
+	Circle *Circle `protobuf:"bytes,2,opt,name=circle,proto3,oneof"`
+}
+
+type Shape_Point struct {
+	Point *emptypb.Empty `protobuf:"bytes,3,opt,name=point,proto3,oneof"`
+}
+
+type Shape_Label struct {
+	Label string `protobuf:"bytes,4,opt,name=label,proto3,oneof"`
+}
+
+func (*Shape_Circle) isShape_ShapeKind() {}
+
+func (*Shape_Point) isShape_ShapeKind() {}
+
+func (*Shape_Label) isShape_ShapeKind() {}
+
+// Message consists of a single oneof.
+type Union struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Value:
+	//	*Union_None
+	//	*Union_Circle
+	Value isUnion_Value `protobuf_oneof:"value"`
+}
+
+func (x *Union) Reset() {
+	*x = Union{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Union) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Union) ProtoMessage() {}
+
+func (x *Union) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Union.ProtoReflect.Descriptor instead.
+func (*Union) Descriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb3_oneof_proto_rawDescGZIP(), []int{2}
+}
+
+func (m *Union) GetValue() isUnion_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (x *Union) GetNone() *emptypb.Empty {
+	if x, ok := x.GetValue().(*Union_None); ok {
+		return x.None
+	}
+	return nil
+}
+
+func (x *Union) GetCircle() *Circle {
+	if x, ok := x.GetValue().(*Union_Circle); ok {
+		return x.Circle
+	}
+	return nil
+}
+
+type isUnion_Value interface {
+	isUnion_Value()
+}
+
+type Union_None struct {
+	None *emptypb.Empty `protobuf:"bytes,1,opt,name=none,proto3,oneof"`
+}
+
+type Union_Circle struct {
+	Circle *Circle `protobuf:"bytes,2,opt,name=circle,proto3,oneof"`
+}
+
+func (*Union_None) isUnion_Value() {}
+
+func (*Union_Circle) isUnion_Value() {}
+
+// Message contains a oneof field whose message type has a field named "type".
+type Conflict struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Kind:
+	//	*Conflict_Typed_
+	Kind isConflict_Kind `protobuf_oneof:"kind"`
+}
+
+func (x *Conflict) Reset() {
+	*x = Conflict{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Conflict) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conflict) ProtoMessage() {}
+
+func (x *Conflict) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conflict.ProtoReflect.Descriptor instead.
+func (*Conflict) Descriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb3_oneof_proto_rawDescGZIP(), []int{3}
+}
+
+func (m *Conflict) GetKind() isConflict_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return nil
+}
+
+func (x *Conflict) GetTyped() *Conflict_Typed {
+	if x, ok := x.GetKind().(*Conflict_Typed_); ok {
+		return x.Typed
+	}
+	return nil
+}
+
+type isConflict_Kind interface {
+	isConflict_Kind()
+}
+
+type Conflict_Typed_ struct {
+	Typed *Conflict_Typed `protobuf:"bytes,1,opt,name=typed,proto3,oneof"`
+}
+
+func (*Conflict_Typed_) isConflict_Kind() {}
+
+type Conflict_Typed struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+}
+
+func (x *Conflict_Typed) Reset() {
+	*x = Conflict_Typed{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Conflict_Typed) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conflict_Typed) ProtoMessage() {}
+
+func (x *Conflict_Typed) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_testprotos_textpb3_oneof_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conflict_Typed.ProtoReflect.Descriptor instead.
+func (*Conflict_Typed) Descriptor() ([]byte, []int) {
+	return file_internal_testprotos_textpb3_oneof_proto_rawDescGZIP(), []int{3, 0}
+}
+
+func (x *Conflict_Typed) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+var File_internal_testprotos_textpb3_oneof_proto protoreflect.FileDescriptor
+
+var file_internal_testprotos_textpb3_oneof_proto_rawDesc = []byte{
+	0x0a, 0x27, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x74, 0x65, 0x78, 0x74, 0x70, 0x62, 0x33, 0x2f, 0x6f, 0x6e,
+	0x65, 0x6f, 0x66, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x70, 0x62, 0x33, 0x1a, 0x1b,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x20, 0x0a, 0x06, 0x43,
+	0x69, 0x72, 0x63, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73, 0x22, 0x98, 0x01,
+	0x0a, 0x05, 0x53, 0x68, 0x61, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x63,
+	0x69, 0x72, 0x63, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x70, 0x62,
+	0x33, 0x2e, 0x43, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x06, 0x63, 0x69, 0x72, 0x63,
+	0x6c, 0x65, 0x12, 0x2e, 0x0a, 0x05, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x05, 0x70, 0x6f, 0x69,
+	0x6e, 0x74, 0x12, 0x16, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x42, 0x0c, 0x0a, 0x0a, 0x73, 0x68,
+	0x61, 0x70, 0x65, 0x5f, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0x65, 0x0a, 0x05, 0x55, 0x6e, 0x69, 0x6f,
+	0x6e, 0x12, 0x2c, 0x0a, 0x04, 0x6e, 0x6f, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x6f, 0x6e, 0x65, 0x12,
+	0x25, 0x0a, 0x06, 0x63, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x70, 0x62, 0x33, 0x2e, 0x43, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x06,
+	0x63, 0x69, 0x72, 0x63, 0x6c, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x5c, 0x0a, 0x08, 0x43, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x12, 0x2b, 0x0a, 0x05, 0x74,
+	0x79, 0x70, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x62, 0x33,
+	0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x64, 0x48,
+	0x00, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65, 0x64, 0x1a, 0x1b, 0x0a, 0x05, 0x54, 0x79, 0x70, 0x65,
+	0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x42, 0x38, 0x5a,
+	0x36, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x67, 0x6f, 0x6c, 0x61, 0x6e, 0x67, 0x2e, 0x6f,
+	0x72, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f,
+	0x74, 0x65, 0x78, 0x74, 0x70, 0x62, 0x33, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_internal_testprotos_textpb3_oneof_proto_rawDescOnce sync.Once
+	file_internal_testprotos_textpb3_oneof_proto_rawDescData = file_internal_testprotos_textpb3_oneof_proto_rawDesc
+)
+
+func file_internal_testprotos_textpb3_oneof_proto_rawDescGZIP() []byte {
+	file_internal_testprotos_textpb3_oneof_proto_rawDescOnce.Do(func() {
+		file_internal_testprotos_textpb3_oneof_proto_rawDescData = protoimpl.X.CompressGZIP(file_internal_testprotos_textpb3_oneof_proto_rawDescData)
+	})
+	return file_internal_testprotos_textpb3_oneof_proto_rawDescData
+}
+
+var file_internal_testprotos_textpb3_oneof_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_internal_testprotos_textpb3_oneof_proto_goTypes = []interface{}{
+	(*Circle)(nil),         // 0: pb3.Circle
+	(*Shape)(nil),          // 1: pb3.Shape
+	(*Union)(nil),          // 2: pb3.Union
+	(*Conflict)(nil),       // 3: pb3.Conflict
+	(*Conflict_Typed)(nil), // 4: pb3.Conflict.Typed
+	(*emptypb.Empty)(nil),  // 5: google.protobuf.Empty
+}
+var file_internal_testprotos_textpb3_oneof_proto_depIdxs = []int32{
+	0, // 0: pb3.Shape.circle:type_name -> pb3.Circle
+	5, // 1: pb3.Shape.point:type_name -> google.protobuf.Empty
+	5, // 2: pb3.Union.none:type_name -> google.protobuf.Empty
+	0, // 3: pb3.Union.circle:type_name -> pb3.Circle
+	4, // 4: pb3.Conflict.typed:type_name -> pb3.Conflict.Typed
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_internal_testprotos_textpb3_oneof_proto_init() }
+func file_internal_testprotos_textpb3_oneof_proto_init() {
+	if File_internal_testprotos_textpb3_oneof_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_internal_testprotos_textpb3_oneof_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Circle); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_testprotos_textpb3_oneof_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Shape); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_testprotos_textpb3_oneof_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Union); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_testprotos_textpb3_oneof_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Conflict); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_testprotos_textpb3_oneof_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Conflict_Typed); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_internal_testprotos_textpb3_oneof_proto_msgTypes[1].OneofWrappers = []interface{}{
+		(*Shape_Circle)(nil),
+		(*Shape_Point)(nil),
+		(*Shape_Label)(nil),
+	}
+	file_internal_testprotos_textpb3_oneof_proto_msgTypes[2].OneofWrappers = []interface{}{
+		(*Union_None)(nil),
+		(*Union_Circle)(nil),
+	}
+	file_internal_testprotos_textpb3_oneof_proto_msgTypes[3].OneofWrappers = []interface{}{
+		(*Conflict_Typed_)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_internal_testprotos_textpb3_oneof_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_internal_testprotos_textpb3_oneof_proto_goTypes,
+		DependencyIndexes: file_internal_testprotos_textpb3_oneof_proto_depIdxs,
+		MessageInfos:      file_internal_testprotos_textpb3_oneof_proto_msgTypes,
+	}.Build()
+	File_internal_testprotos_textpb3_oneof_proto = out.File
+	file_internal_testprotos_textpb3_oneof_proto_rawDesc = nil
+	file_internal_testprotos_textpb3_oneof_proto_goTypes = nil
+	file_internal_testprotos_textpb3_oneof_proto_depIdxs = nil
+}
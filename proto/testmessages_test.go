@@ -82,7 +82,7 @@ var testValidMessages = []testProto{
 			"optional_string":      "string",
 			"optional_bytes":       []byte("bytes"),
 			"optional_nested_enum": "BAR",
-		}),
+		}, &testpb.TestAllTypes{}, &test3pb.TestAllTypes{}, &testpb.TestAllExtensions{}, &test3pb.TestProto3Optional{}),
 		wire: pack.Message{
 			pack.Tag{1, pack.VarintType}, pack.Varint(1001),
 			pack.Tag{2, pack.VarintType}, pack.Varint(1002),
@@ -120,7 +120,7 @@ var testValidMessages = []testProto{
 			"optional_bool":     false,
 			"optional_string":   "",
 			"optional_bytes":    []byte{},
-		}),
+		}, &testpb.TestAllTypes{}, &test3pb.TestAllTypes{}, &testpb.TestAllExtensions{}, &test3pb.TestProto3Optional{}),
 		wire: pack.Message{
 			pack.Tag{1, pack.VarintType}, pack.Varint(0),
 			pack.Tag{2, pack.VarintType}, pack.Varint(0),
@@ -179,7 +179,7 @@ var testValidMessages = []testProto{
 					"optional_int32": 43,
 				},
 			},
-		}),
+		}, &testpb.TestAllTypes{}, &test3pb.TestAllTypes{}, &testpb.TestAllExtensions{}, &test3pb.TestProto3Optional{}),
 		wire: pack.Message{
 			pack.Tag{18, pack.BytesType}, pack.LengthPrefix(pack.Message{
 				pack.Tag{1, pack.VarintType}, pack.Varint(42),
@@ -1523,7 +1523,7 @@ var testInvalidMessages = []testProto{
 		desc: "invalid UTF-8 in optional string field",
 		decodeTo: makeMessages(protobuild.Message{
 			"optional_string": "abc\xff",
-		}, &test3pb.TestAllTypes{}),
+		}, &test3pb.TestAllTypes{}, &test3pb.TestProto3Optional{}),
 		wire: pack.Message{
 			pack.Tag{14, pack.BytesType}, pack.String("abc\xff"),
 		}.Marshal(),
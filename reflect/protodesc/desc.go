@@ -138,6 +138,7 @@ func (o FileOptions) New(fd *descriptorpb.FileDescriptorProto, r Resolver) (prot
 	}
 
 	// Handle source locations.
+	f.L2.Locations.File = f
 	for _, loc := range fd.GetSourceCodeInfo().GetLocation() {
 		var l protoreflect.SourceLocation
 		// TODO: Validate that the path points to an actual declaration?
@@ -213,6 +214,68 @@ func (o FileOptions) New(fd *descriptorpb.FileDescriptorProto, r Resolver) (prot
 	return f, nil
 }
 
+// NewFiles creates a new protoregistry.Files from the provided
+// FileDescriptorSet message. See FileOptions.NewFiles for more information.
+func NewFiles(fds *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
+	return FileOptions{}.NewFiles(fds)
+}
+
+// NewFiles creates a new protoregistry.Files from the provided
+// FileDescriptorSet message. The files may appear in any order; each file is
+// constructed only after all of the files that it imports. Every file must
+// represent a valid proto file according to protobuf semantics.
+// The returned descriptors are a deep copy of the input.
+//
+// Imports are resolved only against other files in the set,
+// which must not contain an import cycle or multiple files with the same path.
+// Unless AllowUnresolvable is set, every non-weak import must be in the set.
+func (o FileOptions) NewFiles(fds *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, fd := range fds.GetFile() {
+		if _, ok := files[fd.GetName()]; ok {
+			return nil, errors.New("file appears multiple times: %q", fd.GetName())
+		}
+		files[fd.GetName()] = fd
+	}
+	r := new(protoregistry.Files)
+	for _, fd := range fds.GetFile() {
+		if err := o.addFileDeps(r, fd, files); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// addFileDeps constructs and registers fd after recursively doing the same
+// for each of its imports that is still present in files.
+// Constructed files are removed from files.
+func (o FileOptions) addFileDeps(r *protoregistry.Files, fd *descriptorpb.FileDescriptorProto, files map[string]*descriptorpb.FileDescriptorProto) error {
+	if files[fd.GetName()] != fd {
+		return nil // already constructed
+	}
+	// Set the entry to nil while descending into the dependencies
+	// so that revisiting it detects a cycle.
+	files[fd.GetName()] = nil
+	for _, dep := range fd.GetDependency() {
+		depfd, ok := files[dep]
+		switch {
+		case ok && depfd == nil:
+			return errors.New("file %q has an import cycle through %q", fd.GetName(), dep)
+		case ok:
+			if err := o.addFileDeps(r, depfd, files); err != nil {
+				return err
+			}
+		}
+	}
+	delete(files, fd.GetName())
+
+	f, err := o.New(fd, r)
+	if err != nil {
+		return errors.New("file %q: %v", fd.GetName(), err)
+	}
+	return r.RegisterFile(f)
+}
+
 type importSet map[string]bool
 
 func (is importSet) importPublic(imps protoreflect.FileImports) {
@@ -136,6 +136,7 @@ func (r descsByName) initFieldsFromDescriptorProto(fds []*descriptorpb.FieldDesc
 		if fd.JsonName != nil {
 			f.L1.JSONName.Init(fd.GetJsonName())
 		}
+		f.L1.IsProto3Optional = fd.GetProto3Optional()
 	}
 	return fs, nil
 }
@@ -176,6 +177,7 @@ func (r descsByName) initExtensionDeclarations(xds []*descriptorpb.FieldDescript
 		if xd.JsonName != nil {
 			x.L2.JSONName.Init(xd.GetJsonName())
 		}
+		x.L2.IsProto3Optional = xd.GetProto3Optional()
 	}
 	return xs, nil
 }
@@ -170,7 +170,19 @@ func validateMessageDeclarations(ms []filedesc.Message, mds []*descriptorpb.Desc
 					return errors.New("message field %q using proto3 semantics may only depend on a proto3 enum", f.FullName())
 				}
 			}
+			if fd.GetProto3Optional() {
+				if f.Syntax() != protoreflect.Proto3 {
+					return errors.New("message field %q under proto3 optional semantics must be specified in the proto3 syntax", f.FullName())
+				}
+				if f.Cardinality() != protoreflect.Optional {
+					return errors.New("message field %q under proto3 optional semantics must have optional cardinality", f.FullName())
+				}
+				if f.ContainingOneof() == nil || f.ContainingOneof().Fields().Len() != 1 {
+					return errors.New("message field %q under proto3 optional semantics must be within a single element oneof", f.FullName())
+				}
+			}
 		}
+		seenSynthetic := false // synthetic oneofs for proto3 optional must come after real oneofs
 		for j := range md.GetOneofDecl() {
 			o := &m.L2.Oneofs.List[j]
 			if o.Fields().Len() == 0 {
@@ -179,6 +191,13 @@ func validateMessageDeclarations(ms []filedesc.Message, mds []*descriptorpb.Desc
 			if n := o.Fields().Len(); n-1 != (o.Fields().Get(n-1).Index() - o.Fields().Get(0).Index()) {
 				return errors.New("message oneof %q must have consecutively declared fields", o.FullName())
 			}
+			if o.IsSynthetic() {
+				seenSynthetic = true
+				continue
+			}
+			if seenSynthetic {
+				return errors.New("message oneof %q must be declared before synthetic oneofs", o.FullName())
+			}
 			for i := 0; i < o.Fields().Len(); i++ {
 				f := o.Fields().Get(i)
 				if f.Cardinality() != protoreflect.Optional {
@@ -224,6 +243,14 @@ func validateExtensionDeclarations(xs []filedesc.Extension, xds []*descriptorpb.
 		if xd.OneofIndex != nil {
 			return errors.New("extension field %q may not be part of a oneof", x.FullName())
 		}
+		if xd.GetProto3Optional() {
+			if x.Syntax() != protoreflect.Proto3 {
+				return errors.New("extension field %q under proto3 optional semantics must be specified in the proto3 syntax", x.FullName())
+			}
+			if x.Cardinality() != protoreflect.Optional {
+				return errors.New("extension field %q under proto3 optional semantics must have optional cardinality", x.FullName())
+			}
+		}
 		if md := x.ContainingMessage(); !md.IsPlaceholder() {
 			if !md.ExtensionRanges().Has(x.Number()) {
 				return errors.New("extension field %q extends %q with non-extension field number: %d", x.FullName(), md.FullName(), x.Number())
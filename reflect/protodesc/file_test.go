@@ -12,6 +12,7 @@ import (
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/internal/flags"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -862,6 +863,61 @@ func TestNewFile(t *testing.T) {
 		// TODO: Test validateExtensionDeclarations
 		// TODO: Test checkValidGroup
 		// TODO: Test checkValidMap
+	}, {
+		label: "proto3 optional fields",
+		inDesc: mustParseFile(`
+			syntax:  "proto3"
+			name:    "test.proto"
+			package: ""
+			message_type: [{
+				name: "M"
+				field: [
+					{name:"foo" number:1 label:LABEL_OPTIONAL type:TYPE_STRING oneof_index:0},
+					{name:"bar" number:2 label:LABEL_OPTIONAL type:TYPE_STRING oneof_index:1 proto3_optional:true}
+				]
+				oneof_decl: [{name:"o"}, {name:"_bar"}]
+			}]
+		`),
+	}, {
+		label: "proto3 optional field in proto2 file",
+		inDesc: mustParseFile(`
+			name:    "test.proto"
+			package: ""
+			message_type: [{
+				name:       "M"
+				field:      [{name:"foo" number:1 label:LABEL_OPTIONAL type:TYPE_STRING oneof_index:0 proto3_optional:true}]
+				oneof_decl: [{name:"_foo"}]
+			}]
+		`),
+		wantErr: `message field "M.foo" under proto3 optional semantics must be specified in the proto3 syntax`,
+	}, {
+		label: "proto3 optional field without oneof",
+		inDesc: mustParseFile(`
+			syntax:  "proto3"
+			name:    "test.proto"
+			package: ""
+			message_type: [{
+				name:  "M"
+				field: [{name:"foo" number:1 label:LABEL_OPTIONAL type:TYPE_STRING proto3_optional:true}]
+			}]
+		`),
+		wantErr: `message field "M.foo" under proto3 optional semantics must be within a single element oneof`,
+	}, {
+		label: "proto3 optional synthetic oneof before real oneof",
+		inDesc: mustParseFile(`
+			syntax:  "proto3"
+			name:    "test.proto"
+			package: ""
+			message_type: [{
+				name: "M"
+				field: [
+					{name:"foo" number:1 label:LABEL_OPTIONAL type:TYPE_STRING oneof_index:0 proto3_optional:true},
+					{name:"bar" number:2 label:LABEL_OPTIONAL type:TYPE_STRING oneof_index:1}
+				]
+				oneof_decl: [{name:"_foo"}, {name:"o"}]
+			}]
+		`),
+		wantErr: `message oneof "M.o" must be declared before synthetic oneofs`,
 	}, {
 		label: "empty service",
 		inDesc: mustParseFile(`
@@ -935,3 +991,207 @@ func TestNewFile(t *testing.T) {
 		})
 	}
 }
+
+func TestNewFiles(t *testing.T) {
+	tests := []struct {
+		label   string
+		inFiles []*descriptorpb.FileDescriptorProto
+		inOpts  FileOptions
+		wantErr string
+	}{{
+		label: "files in import order",
+		inFiles: []*descriptorpb.FileDescriptorProto{
+			proto2Enum, proto3Message, extendableMessage,
+			importPublicFile1, importPublicFile2, importPublicFile3, importPublicFile4,
+		},
+	}, {
+		label: "files in reverse import order",
+		inFiles: []*descriptorpb.FileDescriptorProto{
+			importPublicFile4, importPublicFile3, importPublicFile2, importPublicFile1,
+			extendableMessage, proto3Message, proto2Enum,
+		},
+	}, {
+		label:   "duplicate file",
+		inFiles: []*descriptorpb.FileDescriptorProto{proto2Enum, proto2Enum},
+		wantErr: `file appears multiple times: "proto2_enum.proto"`,
+	}, {
+		label: "missing import",
+		inFiles: []*descriptorpb.FileDescriptorProto{
+			importPublicFile1, proto3Message, extendableMessage,
+		},
+		wantErr: `file "import_public1.proto": could not resolve import "proto2_enum.proto"`,
+	}, {
+		label: "missing import but allowed",
+		inFiles: []*descriptorpb.FileDescriptorProto{
+			importPublicFile1, proto3Message, extendableMessage,
+		},
+		inOpts: FileOptions{AllowUnresolvable: true},
+	}, {
+		label: "import cycle",
+		inFiles: []*descriptorpb.FileDescriptorProto{
+			mustParseFile(`name:"a.proto" dependency:"b.proto"`),
+			mustParseFile(`name:"b.proto" dependency:"c.proto"`),
+			mustParseFile(`name:"c.proto" dependency:"a.proto"`),
+		},
+		wantErr: `file "c.proto" has an import cycle through "a.proto"`,
+	}, {
+		label: "self import",
+		inFiles: []*descriptorpb.FileDescriptorProto{
+			mustParseFile(`name:"a.proto" dependency:"a.proto"`),
+		},
+		wantErr: `file "a.proto" has an import cycle through "a.proto"`,
+	}, {
+		label: "invalid file",
+		inFiles: []*descriptorpb.FileDescriptorProto{
+			proto2Enum,
+			mustParseFile(`name:"a.proto" syntax:"proto9" dependency:"proto2_enum.proto"`),
+		},
+		wantErr: `file "a.proto": invalid syntax: "proto9"`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			r, err := tt.inOpts.NewFiles(&descriptorpb.FileDescriptorSet{File: tt.inFiles})
+			if ((err == nil) != (tt.wantErr == "")) || !strings.Contains(fmt.Sprint(err), tt.wantErr) {
+				t.Fatalf("NewFiles() error:\ngot:  %v\nwant: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			// Every file must be exported exactly once and after its imports.
+			gotSet := ToFileDescriptorSet(r)
+			if got, want := len(gotSet.GetFile()), len(tt.inFiles); got != want {
+				t.Fatalf("ToFileDescriptorSet() returned %d files, want %d", got, want)
+			}
+			want := make(map[string]bool)
+			for _, fd := range tt.inFiles {
+				want[fd.GetName()] = true
+			}
+			seen := make(map[string]bool)
+			for _, fd := range gotSet.GetFile() {
+				if !want[fd.GetName()] || seen[fd.GetName()] {
+					t.Errorf("ToFileDescriptorSet() has unexpected file %q", fd.GetName())
+				}
+				for _, dep := range fd.GetDependency() {
+					if want[dep] && !seen[dep] {
+						t.Errorf("ToFileDescriptorSet() has %q before its import %q", fd.GetName(), dep)
+					}
+				}
+				seen[fd.GetName()] = true
+			}
+
+			// The exported set must load back into an equivalent registry.
+			r2, err := tt.inOpts.NewFiles(gotSet)
+			if err != nil {
+				t.Fatalf("NewFiles(ToFileDescriptorSet()) error: %v", err)
+			}
+			if got := ToFileDescriptorSet(r2); !proto.Equal(got, gotSet) {
+				t.Errorf("ToFileDescriptorSet() round-trip mismatch:\ngot  %v\nwant %v", got, gotSet)
+			}
+		})
+	}
+}
+
+func TestToFileDescriptorSetOf(t *testing.T) {
+	r, err := NewFiles(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			importPublicFile4, importPublicFile3, importPublicFile2, importPublicFile1,
+			extendableMessage, proto3Message, proto2Enum,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewFiles() error: %v", err)
+	}
+	fd, err := r.FindFileByPath("import_public3.proto")
+	if err != nil {
+		t.Fatalf("FindFileByPath() error: %v", err)
+	}
+
+	var got []string
+	for _, fd := range ToFileDescriptorSetOf(fd).GetFile() {
+		got = append(got, fd.GetName())
+	}
+	want := []string{
+		"proto2_enum.proto",
+		"proto3_message.proto",
+		"extendable_message.proto",
+		"import_public1.proto",
+		"import_public2.proto",
+		"import_public3.proto",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ToFileDescriptorSetOf() files:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+func TestSourceLocations(t *testing.T) {
+	fd := mustParseFile(`
+		syntax:  "proto2"
+		name:    "source.proto"
+		package: "test"
+		message_type: [{
+			name:        "Message"
+			field:       [{name:"foo" number:1 label:LABEL_OPTIONAL type:TYPE_STRING oneof_index:0}]
+			nested_type: [{name:"Nested"}]
+			oneof_decl:  [{name:"oneof"}]
+			extension_range: [{start:100 end:200}]
+		}]
+		enum_type: [{name:"Enum" value:[{name:"ZERO" number:0}]}]
+		extension: [{name:"ext" number:100 label:LABEL_OPTIONAL type:TYPE_STRING extendee:".test.Message"}]
+		service: [{name:"Service" method:[{name:"Method" input_type:".test.Message" output_type:".test.Message"}]}]
+		source_code_info: {location: [
+			{path:[4,0]       span:[1,0,2] leading_comments:"message"},
+			{path:[4,0]       span:[1,0,2] leading_comments:"duplicate"},
+			{path:[4,0,2,0]   span:[3,0,4] leading_comments:"field"},
+			{path:[4,0,3,0]   span:[5,0,6] leading_comments:"nested"},
+			{path:[4,0,8,0]   span:[7,0,8] leading_comments:"oneof"},
+			{path:[5,0]       span:[9,0,10] leading_comments:"enum"},
+			{path:[5,0,2,0]   span:[11,0,12] leading_comments:"value"},
+			{path:[7,0]       span:[13,0,14] leading_comments:"extension"},
+			{path:[6,0]       span:[15,0,16] leading_comments:"service"},
+			{path:[6,0,2,0]   span:[17,0,18] leading_comments:"method"}
+		]}
+	`)
+	f, err := NewFile(fd, nil)
+	if err != nil {
+		t.Fatalf("NewFile() error: %v", err)
+	}
+	locs := f.SourceLocations()
+
+	other, err := NewFile(proto2Enum, nil)
+	if err != nil {
+		t.Fatalf("NewFile() error: %v", err)
+	}
+
+	md := f.Messages().Get(0)
+	sd := f.Services().Get(0)
+	for _, tt := range []struct {
+		desc protoreflect.Descriptor
+		want string
+	}{
+		{md, "message"},
+		{md.Fields().Get(0), "field"},
+		{md.Messages().Get(0), "nested"},
+		{md.Oneofs().Get(0), "oneof"},
+		{f.Enums().Get(0), "enum"},
+		{f.Enums().Get(0).Values().Get(0), "value"},
+		{f.Extensions().Get(0), "extension"},
+		{sd, "service"},
+		{sd.Methods().Get(0), "method"},
+		{f, ""},
+		{nil, ""},
+		{other.Enums().Get(0), ""}, // declared in another file
+	} {
+		if got := locs.ByDescriptor(tt.desc).LeadingComments; got != tt.want {
+			t.Errorf("ByDescriptor(%v).LeadingComments = %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+
+	if got := locs.ByPath(protoreflect.SourcePath{4, 0, 2, 0}); got.LeadingComments != "field" || got.StartLine != 3 || got.EndColumn != 4 {
+		t.Errorf("ByPath(.message_type[0].field[0]) = %+v, want field location", got)
+	}
+	if got := locs.ByPath(protoreflect.SourcePath{4, 1}); got.Path != nil {
+		t.Errorf("ByPath(.message_type[1]) = %+v, want zero value", got)
+	}
+}
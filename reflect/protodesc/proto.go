@@ -6,11 +6,13 @@ package protodesc
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"google.golang.org/protobuf/internal/encoding/defval"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 
 	"google.golang.org/protobuf/types/descriptorpb"
 )
@@ -73,6 +75,48 @@ func ToFileDescriptorProto(file protoreflect.FileDescriptor) *descriptorpb.FileD
 	return p
 }
 
+// ToFileDescriptorSet copies every file in the registry, along with all of
+// the files that they transitively import, into a
+// google.protobuf.FileDescriptorSet message.
+// The files are ordered as described in ToFileDescriptorSetOf.
+func ToFileDescriptorSet(r *protoregistry.Files) *descriptorpb.FileDescriptorSet {
+	var files []protoreflect.FileDescriptor
+	r.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		files = append(files, file)
+		return true
+	})
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path() < files[j].Path()
+	})
+	return ToFileDescriptorSetOf(files...)
+}
+
+// ToFileDescriptorSetOf copies the provided files and all of the files that
+// they transitively import into a google.protobuf.FileDescriptorSet message.
+//
+// Each file appears once and only after all of the files it imports,
+// which is the order that protoc uses for --descriptor_set_out.
+// Placeholder files for unresolved imports are omitted.
+func ToFileDescriptorSetOf(files ...protoreflect.FileDescriptor) *descriptorpb.FileDescriptorSet {
+	p := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	var add func(protoreflect.FileDescriptor)
+	add = func(file protoreflect.FileDescriptor) {
+		if file.IsPlaceholder() || seen[file.Path()] {
+			return
+		}
+		seen[file.Path()] = true
+		for i, imports := 0, file.Imports(); i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		p.File = append(p.File, ToFileDescriptorProto(file))
+	}
+	for _, file := range files {
+		add(file)
+	}
+	return p
+}
+
 // ToDescriptorProto copies a protoreflect.MessageDescriptor into a
 // google.protobuf.DescriptorProto message.
 func ToDescriptorProto(message protoreflect.MessageDescriptor) *descriptorpb.DescriptorProto {
@@ -152,6 +196,9 @@ func ToFieldDescriptorProto(field protoreflect.FieldDescriptor) *descriptorpb.Fi
 	if oneof := field.ContainingOneof(); oneof != nil {
 		p.OneofIndex = proto.Int32(int32(oneof.Index()))
 	}
+	if field.Syntax() == protoreflect.Proto3 && field.HasOptionalKeyword() {
+		p.Proto3Optional = proto.Bool(true)
+	}
 	return p
 }
 
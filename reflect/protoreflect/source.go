@@ -4,6 +4,13 @@
 
 package protoreflect
 
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/internal/errors"
+)
+
 // SourceLocations is a list of source locations.
 type SourceLocations interface {
 	// Len reports the number of source locations in the proto file.
@@ -11,9 +18,17 @@ type SourceLocations interface {
 	// Get returns the ith SourceLocation. It panics if out of bounds.
 	Get(int) SourceLocation
 
-	doNotImplement
+	// ByPath returns the SourceLocation for the given path,
+	// returning the first location if multiple exist for the same path.
+	// If no location exists, it returns the zero value.
+	ByPath(path SourcePath) SourceLocation
+
+	// ByDescriptor returns the SourceLocation for the given descriptor,
+	// returning the first location if multiple exist for the same path.
+	// If no location exists, it returns the zero value.
+	ByDescriptor(desc Descriptor) SourceLocation
 
-	// TODO: Add ByPath and ByDescriptor helper methods.
+	doNotImplement
 }
 
 // SourceLocation describes a source location and
@@ -48,5 +63,105 @@ type SourceLocation struct {
 // See google.protobuf.SourceCodeInfo.Location.path.
 type SourcePath []int32
 
-// TODO: Add SourcePath.String method to pretty-print the path. For example:
+// String formats the path in a humanly readable manner, naming each field
+// of google.protobuf.FileDescriptorProto along the path. For example:
 //	".message_type[6].nested_type[15].field[3]"
+//
+// Field numbers that are not known are formatted as a number (e.g., ".99").
+func (p SourcePath) String() string {
+	var b []byte
+	fields := sourceFileDescriptorProto()
+	for i := 0; i < len(p); i++ {
+		f := findSourceField(fields, p[i])
+		if f == nil {
+			b = append(b, '.')
+			b = strconv.AppendInt(b, int64(p[i]), 10)
+			fields = nil
+			continue
+		}
+		b = append(b, '.')
+		b = append(b, f.name...)
+		if f.repeated && i+1 < len(p) {
+			i++
+			b = append(b, '[')
+			b = strconv.AppendInt(b, int64(p[i]), 10)
+			b = append(b, ']')
+		}
+		fields = nil
+		if f.message != nil {
+			fields = f.message()
+		}
+	}
+	return string(b)
+}
+
+// ParseSourcePath parses a path in the format produced by SourcePath.String.
+func ParseSourcePath(s string) (SourcePath, error) {
+	var p SourcePath
+	fields := sourceFileDescriptorProto()
+	for in := s; len(in) > 0; {
+		if in[0] != '.' {
+			return nil, errors.New("invalid source path %q", s)
+		}
+		in = in[1:]
+		n := strings.IndexAny(in, ".[")
+		if n < 0 {
+			n = len(in)
+		}
+		name := in[:n]
+		in = in[n:]
+
+		// Field numbers are used for fields that are not known.
+		if num, err := strconv.ParseInt(name, 10, 32); err == nil {
+			p = append(p, int32(num))
+			fields = nil
+			continue
+		}
+		var f *sourceField
+		for i := range fields {
+			if fields[i].name == name {
+				f = &fields[i]
+				break
+			}
+		}
+		if f == nil {
+			return nil, errors.New("invalid source path %q: unknown field %q", s, name)
+		}
+		p = append(p, f.number)
+		if len(in) > 0 && in[0] == '[' {
+			n := strings.IndexByte(in, ']')
+			if !f.repeated || n < 0 {
+				return nil, errors.New("invalid source path %q: invalid index for field %q", s, name)
+			}
+			i, err := strconv.ParseInt(in[1:n], 10, 32)
+			if err != nil || i < 0 {
+				return nil, errors.New("invalid source path %q: invalid index for field %q", s, name)
+			}
+			p = append(p, int32(i))
+			in = in[n+1:]
+		}
+		fields = nil
+		if f.message != nil {
+			fields = f.message()
+		}
+	}
+	return p, nil
+}
+
+// sourceField describes a field of a message in descriptor.proto
+// for the purpose of formatting a SourcePath.
+type sourceField struct {
+	number   int32
+	name     string
+	repeated bool
+	message  func() []sourceField
+}
+
+func findSourceField(fields []sourceField, n int32) *sourceField {
+	for i := range fields {
+		if fields[i].number == n {
+			return &fields[i]
+		}
+	}
+	return nil
+}
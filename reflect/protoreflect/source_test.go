@@ -0,0 +1,60 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protoreflect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSourcePathString(t *testing.T) {
+	tests := []struct {
+		in   SourcePath
+		want string
+	}{
+		{nil, ""},
+		{SourcePath{1}, ".name"},
+		{SourcePath{4}, ".message_type"},
+		{SourcePath{4, 6}, ".message_type[6]"},
+		{SourcePath{4, 6, 3, 15, 2, 3}, ".message_type[6].nested_type[15].field[3]"},
+		{SourcePath{4, 6, 2, 3, 1}, ".message_type[6].field[3].name"},
+		{SourcePath{5, 0, 2, 1, 2}, ".enum_type[0].value[1].number"},
+		{SourcePath{6, 0, 2, 1, 4, 33}, ".service[0].method[1].options.deprecated"},
+		{SourcePath{4, 0, 7, 1000, 5}, ".message_type[0].options.1000.5"},
+		{SourcePath{99}, ".99"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("SourcePath(%v).String() = %q, want %q", []int32(tt.in), got, tt.want)
+		}
+		got, err := ParseSourcePath(tt.want)
+		if err != nil {
+			t.Errorf("ParseSourcePath(%q) error: %v", tt.want, err)
+			continue
+		}
+		if len(got) != 0 || len(tt.in) != 0 {
+			if !reflect.DeepEqual(got, tt.in) {
+				t.Errorf("ParseSourcePath(%q) = %v, want %v", tt.want, []int32(got), []int32(tt.in))
+			}
+		}
+	}
+}
+
+func TestParseSourcePathErrors(t *testing.T) {
+	for _, in := range []string{
+		"message_type",
+		".unknown",
+		".name[0]",
+		".message_type[",
+		".message_type[-1]",
+		".message_type[x]",
+		".message_type[0]field",
+	} {
+		if got, err := ParseSourcePath(in); err == nil {
+			t.Errorf("ParseSourcePath(%q) = %v, want error", in, []int32(got))
+		}
+	}
+}
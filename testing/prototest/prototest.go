@@ -123,21 +123,10 @@ func testField(t testing.TB, m pref.Message, fd pref.FieldDescriptor) {
 		v := newValue(m, fd, n, nil)
 		m.Set(fd, v)
 		wantHas := true
-		if n == 0 {
-			if fd.Syntax() == pref.Proto3 && fd.Message() == nil {
-				wantHas = false
-			}
-			if fd.IsExtension() {
-				wantHas = true
-			}
-			if fd.Cardinality() == pref.Repeated {
-				wantHas = false
-			}
-			if fd.ContainingOneof() != nil {
-				wantHas = true
-			}
+		if n == 0 && !fd.HasPresence() {
+			wantHas = false
 		}
-		if fd.Syntax() == pref.Proto3 && fd.Cardinality() != pref.Repeated && fd.ContainingOneof() == nil && fd.Kind() == pref.EnumKind && v.Enum() == 0 {
+		if !fd.HasPresence() && fd.Cardinality() != pref.Repeated && fd.Kind() == pref.EnumKind && v.Enum() == 0 {
 			wantHas = false
 		}
 		if got, want := m.Has(fd), wantHas; got != want {
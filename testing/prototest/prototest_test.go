@@ -26,6 +26,7 @@ func Test(t *testing.T) {
 	ms := []proto.Message{
 		(*testpb.TestAllTypes)(nil),
 		(*test3pb.TestAllTypes)(nil),
+		(*test3pb.TestProto3Optional)(nil),
 		(*testpb.TestRequired)(nil),
 		(*irregularpb.Message)(nil),
 		(*testpb.TestAllExtensions)(nil),
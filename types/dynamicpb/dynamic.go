@@ -420,9 +420,9 @@ func isSet(fd pref.FieldDescriptor, v pref.Value) bool {
 		return v.Map().Len() > 0
 	case fd.IsList():
 		return v.List().Len() > 0
-	case fd.ContainingOneof() != nil:
+	case fd.HasPresence():
 		return true
-	case fd.Syntax() == pref.Proto3 && !fd.IsExtension():
+	default:
 		switch fd.Kind() {
 		case pref.BoolKind:
 			return v.Bool()
@@ -21,6 +21,7 @@ func TestConformance(t *testing.T) {
 	for _, message := range []proto.Message{
 		(*testpb.TestAllTypes)(nil),
 		(*test3pb.TestAllTypes)(nil),
+		(*test3pb.TestProto3Optional)(nil),
 		(*testpb.TestAllExtensions)(nil),
 	} {
 		mt := dynamicpb.NewMessageType(message.ProtoReflect().Descriptor())
@@ -62,3 +63,44 @@ func (extResolver) RangeExtensionsByMessage(message pref.FullName, f func(pref.E
 		return f(dynamicpb.NewExtensionType(xt.TypeDescriptor().Descriptor()))
 	})
 }
+
+func TestTypes(t *testing.T) {
+	types := dynamicpb.NewTypes(preg.GlobalFiles)
+
+	mt := dynamicpb.NewMessageType((*testpb.TestAllExtensions)(nil).ProtoReflect().Descriptor())
+	prototest.Message{Resolver: types}.Test(t, mt)
+
+	const message = "goproto.proto.test.TestAllExtensions"
+	if _, err := types.FindMessageByURL("type.googleapis.com/" + message); err != nil {
+		t.Errorf("FindMessageByURL(%q) error: %v", message, err)
+	}
+	if _, err := types.FindMessageByName("goproto.proto.test.optional_int32_extension"); err != preg.NotFound {
+		t.Errorf("FindMessageByName(extension) error got %v, want NotFound", err)
+	}
+
+	var want, got int
+	preg.GlobalTypes.RangeExtensionsByMessage(message, func(pref.ExtensionType) bool {
+		want++
+		return true
+	})
+	types.RangeExtensionsByMessage(message, func(xt pref.ExtensionType) bool {
+		got++
+		xd := xt.TypeDescriptor()
+		if _, err := types.FindExtensionByName(xd.FullName()); err != nil {
+			t.Errorf("FindExtensionByName(%v) error: %v", xd.FullName(), err)
+		}
+		xt2, err := types.FindExtensionByNumber(message, xd.Number())
+		if err != nil {
+			t.Errorf("FindExtensionByNumber(%v) error: %v", xd.Number(), err)
+		} else if xt2.TypeDescriptor().FullName() != xd.FullName() {
+			t.Errorf("FindExtensionByNumber(%v) = %v, want %v", xd.Number(), xt2.TypeDescriptor().FullName(), xd.FullName())
+		}
+		return true
+	})
+	if got == 0 || got != want {
+		t.Errorf("RangeExtensionsByMessage(%v) ranged over %v extensions, want %v", message, got, want)
+	}
+	if _, err := types.FindExtensionByNumber(message, 536870911); err != preg.NotFound {
+		t.Errorf("FindExtensionByNumber(unknown) error got %v, want NotFound", err)
+	}
+}
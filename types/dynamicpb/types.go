@@ -0,0 +1,122 @@
+// Copyright 2019 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynamicpb
+
+import (
+	"strings"
+	"sync"
+
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Types is a resolver of dynamic message and extension types for the
+// descriptors in a protoregistry.Files. It implements the resolver interfaces
+// used by the encoding packages, such that messages are unmarshaled as
+// dynamic messages.
+//
+// It is safe for concurrent use, as long as the Files is not modified
+// concurrently.
+type Types struct {
+	files *protoregistry.Files
+
+	mu        sync.Mutex
+	numFiles  int // number of files when extsByMsg was built
+	extsByMsg map[pref.FullName][]pref.ExtensionDescriptor
+}
+
+// NewTypes creates a new Types resolving the descriptors in f.
+func NewTypes(f *protoregistry.Files) *Types {
+	return &Types{files: f}
+}
+
+// FindMessageByName looks up a message by its full name.
+// It returns protoregistry.NotFound if the message is not in the Files.
+func (t *Types) FindMessageByName(name pref.FullName) (pref.MessageType, error) {
+	d, err := t.files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	md, ok := d.(pref.MessageDescriptor)
+	if !ok {
+		return nil, protoregistry.NotFound
+	}
+	return NewMessageType(md), nil
+}
+
+// FindMessageByURL looks up a message by a URL identifier, whose last path
+// segment is the full name of the message.
+// It returns protoregistry.NotFound if the message is not in the Files.
+func (t *Types) FindMessageByURL(url string) (pref.MessageType, error) {
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		url = url[i+1:]
+	}
+	return t.FindMessageByName(pref.FullName(url))
+}
+
+// FindExtensionByName looks up an extension field by its full name.
+// It returns protoregistry.NotFound if the extension is not in the Files.
+func (t *Types) FindExtensionByName(name pref.FullName) (pref.ExtensionType, error) {
+	d, err := t.files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	xd, ok := d.(pref.ExtensionDescriptor)
+	if !ok || !xd.IsExtension() {
+		return nil, protoregistry.NotFound
+	}
+	return NewExtensionType(xd), nil
+}
+
+// FindExtensionByNumber looks up an extension field of the message named
+// message by its field number.
+// It returns protoregistry.NotFound if the extension is not in the Files.
+func (t *Types) FindExtensionByNumber(message pref.FullName, field pref.FieldNumber) (pref.ExtensionType, error) {
+	for _, xd := range t.extensions(message) {
+		if xd.Number() == field {
+			return NewExtensionType(xd), nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+// RangeExtensionsByMessage calls f for each extension of the message named
+// message in the Files. If f returns false, RangeExtensionsByMessage stops
+// the iteration.
+func (t *Types) RangeExtensionsByMessage(message pref.FullName, f func(pref.ExtensionType) bool) {
+	for _, xd := range t.extensions(message) {
+		if !f(NewExtensionType(xd)) {
+			return
+		}
+	}
+}
+
+// extensions returns the extensions of the message named message, indexing
+// the extensions in the Files if files were added since the last call.
+func (t *Types) extensions(message pref.FullName) []pref.ExtensionDescriptor {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.extsByMsg == nil || t.numFiles != t.files.NumFiles() {
+		t.extsByMsg = make(map[pref.FullName][]pref.ExtensionDescriptor)
+		t.numFiles = t.files.NumFiles()
+		t.files.RangeFiles(func(fd pref.FileDescriptor) bool {
+			t.indexExtensions(fd.Extensions(), fd.Messages())
+			return true
+		})
+	}
+	return t.extsByMsg[message]
+}
+
+func (t *Types) indexExtensions(xds pref.ExtensionDescriptors, mds pref.MessageDescriptors) {
+	for i := 0; i < xds.Len(); i++ {
+		xd := xds.Get(i)
+		name := xd.ContainingMessage().FullName()
+		t.extsByMsg[name] = append(t.extsByMsg[name], xd)
+	}
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		t.indexExtensions(md.Extensions(), md.Messages())
+	}
+}